@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/pkg/services/analytics"
+	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
+	"net/http"
+	"time"
+)
+
+type MerchantDistributionRequest struct {
+	Level       string `query:"level" validate:"omitempty,oneof=county sub_county ward"`
+	Days        int    `query:"days" validate:"omitempty,min=1,max=366"`
+	CountyId    int    `query:"county_id" validate:"omitempty,numeric"`
+	SubCountyId int    `query:"sub_county_id" validate:"omitempty,numeric"`
+	Format      string `query:"format" validate:"omitempty,oneof=json csv geojson"`
+}
+
+func GetMerchantDistribution(service analytics.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MerchantDistributionRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+		if request.Level == "" {
+			request.Level = consts.LEVEL_COUNTY
+		}
+		if request.Days == 0 {
+			request.Days = 30
+		}
+
+		fetched, err := service.GetMerchantDistribution(analytics.Filters{
+			Level:       request.Level,
+			CountyId:    request.CountyId,
+			SubCountyId: request.SubCountyId,
+		}, request.Days)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		switch request.Format {
+		case "csv":
+			var out bytes.Buffer
+			if err = analytics.WriteCSV(&out, fetched); err != nil {
+				return utils.HandleErrorResponse(ctx, err)
+			}
+
+			filename := fmt.Sprintf("merchants-by-%s-%s.csv", request.Level, fetched.GeneratedAt.Format(time.DateOnly))
+			ctx.Set(fiber.HeaderContentType, "text/csv")
+			ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+			return ctx.Send(out.Bytes())
+		case "geojson":
+			ctx.Set(fiber.HeaderContentType, "application/geo+json")
+			return ctx.JSON(analytics.GeoJSON(fetched))
+		default:
+			return utils.HandleSuccessResponse(ctx, fetched)
+		}
+	}
+}
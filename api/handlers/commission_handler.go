@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/services/commission"
+	"merchants.sidooh/utils"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type CommissionPlanRequest struct {
+	Rates      []float32 `json:"rates" validate:"required,min=1"`
+	DailyCap   *float32  `json:"daily_cap" validate:"omitempty,min=0"`
+	MonthlyCap *float32  `json:"monthly_cap" validate:"omitempty,min=0"`
+}
+
+func GetCommissionPlans(service commission.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		fetched, err := service.GetPlans()
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func SetCommissionPlan(service commission.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request CommissionPlanRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		product := strings.ToUpper(ctx.Params("product"))
+		if !slices.Contains(commission.Products, product) {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid product parameter")))
+		}
+
+		fetched, err := service.SetPlan(&presenter.CommissionPlan{
+			Product:    product,
+			Rates:      request.Rates,
+			DailyCap:   request.DailyCap,
+			MonthlyCap: request.MonthlyCap,
+		})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/services/document"
+	"merchants.sidooh/utils"
+	"net/http"
+)
+
+type DocumentsQueueRequest struct {
+	Status   string `query:"status" validate:"omitempty,oneof=PENDING APPROVED REJECTED SUPERSEDED"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+func UploadMerchantDocument(service document.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		file, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid file")))
+		}
+
+		content, err := file.Open()
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+		defer content.Close()
+
+		fetched, err := service.UploadDocument(uint(id), document.Upload{
+			Type:     ctx.FormValue("type"),
+			FileName: file.Filename,
+			Size:     file.Size,
+			Content:  content,
+		})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetMerchantDocuments(service document.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetMerchantDocuments(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetDocumentsQueue(service document.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request DocumentsQueueRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		fetched, err := service.GetReviewQueue(request.Status, datastore.Pagination{Page: request.Page, PageSize: request.PageSize})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetDocumentFile(service document.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, file, err := service.OpenDocument(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		ctx.Set(fiber.HeaderContentType, fetched.ContentType)
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", fetched.FileName))
+
+		// the stream is closed once it has been sent
+		return ctx.SendStream(file, int(fetched.Size))
+	}
+}
+
+func ApproveDocument(service document.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.ApproveDocument(uint(id), jwt.Actor(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func RejectDocument(service document.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MerchantActionRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.RejectDocument(uint(id), request.Reason, jwt.Actor(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
@@ -3,11 +3,19 @@ package handlers
 import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/services/earning_account"
 	"merchants.sidooh/utils"
 	"net/http"
 )
 
+type EarningAccountTransactionsRequest struct {
+	Page     int `query:"page" validate:"omitempty,min=1"`
+	PageSize int `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
 func GetEarningAccountsByMerchant(service earning_account.Service) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		id, err := ctx.ParamsInt("id")
@@ -24,3 +32,30 @@ func GetEarningAccountsByMerchant(service earning_account.Service) fiber.Handler
 		return utils.HandleSuccessResponse(ctx, fetched)
 	}
 }
+
+func GetEarningAccountTransactions(service earning_account.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request EarningAccountTransactionsRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		owner := jwt.AccountId(ctx)
+		if owner == nil {
+			return utils.HandleUnauthorized(ctx)
+		}
+
+		fetched, err := service.GetTransactions(uint(id), *owner, datastore.Pagination{Page: request.Page, PageSize: request.PageSize})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
@@ -32,3 +32,29 @@ func QueryPaymentsStatus(service jobs.Service) fiber.Handler {
 		return utils.HandleSuccessResponse(ctx, nil)
 	}
 }
+
+func RunScheduledPurchases(service jobs.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		logger.ClientLog.Info(ctx.String(), "data", string(ctx.Body()), "headers", ctx.GetReqHeaders())
+
+		err := service.RunScheduledPurchases()
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, nil)
+	}
+}
+
+func ProcessCommissions(service jobs.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		logger.ClientLog.Info(ctx.String(), "data", string(ctx.Body()), "headers", ctx.GetReqHeaders())
+
+		err := service.ProcessCommissions()
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, nil)
+	}
+}
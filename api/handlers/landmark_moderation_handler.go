@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/services/landmark_moderation"
+	"merchants.sidooh/utils"
+	"net/http"
+)
+
+type UnmatchedLandmarksRequest struct {
+	Page     int `query:"page" validate:"omitempty,min=1"`
+	PageSize int `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// ResolveLandmarksRequest maps the landmarks to an existing landmark_id, or to a new landmark named name under ward_id.
+type ResolveLandmarksRequest struct {
+	Landmarks  []string `json:"landmarks" validate:"required,min=1,dive,required"`
+	LandmarkId int      `json:"landmark_id" validate:"required_without=WardId,omitempty,min=1"`
+	WardId     int      `json:"ward_id" validate:"required_without=LandmarkId,omitempty,min=1"`
+	Name       string   `json:"name" validate:"required_with=WardId,max=64"`
+}
+
+func GetUnmatchedLandmarks(service landmark_moderation.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request UnmatchedLandmarksRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		fetched, err := service.GetQueue(datastore.Pagination{Page: request.Page, PageSize: request.PageSize})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func ResolveLandmarks(service landmark_moderation.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request ResolveLandmarksRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+		if request.LandmarkId != 0 && request.WardId != 0 {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("either landmark_id or ward_id is allowed")))
+		}
+
+		fetched, err := service.Resolve(landmark_moderation.Resolution{
+			Landmarks:  request.Landmarks,
+			LandmarkId: request.LandmarkId,
+			WardId:     request.WardId,
+			Name:       request.Name,
+		}, jwt.Actor(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
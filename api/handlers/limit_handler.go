@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/limit"
+	"merchants.sidooh/utils"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+type TransactionLimitRequest struct {
+	MinAmount     *float32 `json:"min_amount" validate:"omitempty,min=0"`
+	MaxAmount     *float32 `json:"max_amount" validate:"omitempty,min=0"`
+	DailyAmount   *float32 `json:"daily_amount" validate:"omitempty,min=0"`
+	MonthlyAmount *float32 `json:"monthly_amount" validate:"omitempty,min=0"`
+	DailyCount    *int     `json:"daily_count" validate:"omitempty,min=0"`
+	MonthlyCount  *int     `json:"monthly_count" validate:"omitempty,min=0"`
+}
+
+type MerchantTierRequest struct {
+	Tier string `json:"tier" validate:"required,alphanum,max=16"`
+}
+
+func GetMerchantRemainingLimits(service limit.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetRemainingLimits(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetMerchantLimitOverrides(service limit.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetMerchantLimits(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func SetMerchantLimit(service limit.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request TransactionLimitRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		product, err := limitProduct(ctx)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(err))
+		}
+
+		data := request.toEntity(product)
+		data.MerchantId = uint(id)
+
+		fetched, err := service.SetMerchantLimit(data)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func RemoveMerchantLimit(service limit.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		product, err := limitProduct(ctx)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(err))
+		}
+
+		if err = service.RemoveMerchantLimit(uint(id), product); err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, nil)
+	}
+}
+
+func SetMerchantTier(service limit.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MerchantTierRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.SetMerchantTier(uint(id), strings.ToUpper(request.Tier), jwt.Actor(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetTierLimits(service limit.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		fetched, err := service.GetTierLimits(strings.ToUpper(ctx.Params("tier")))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func SetTierLimit(service limit.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request TransactionLimitRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		product, err := limitProduct(ctx)
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(err))
+		}
+
+		data := request.toEntity(product)
+		data.Tier = strings.ToUpper(ctx.Params("tier"))
+
+		fetched, err := service.SetTierLimit(data)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func (r TransactionLimitRequest) toEntity(product string) *entities.TransactionLimit {
+	return &entities.TransactionLimit{
+		Product:       product,
+		MinAmount:     r.MinAmount,
+		MaxAmount:     r.MaxAmount,
+		DailyAmount:   r.DailyAmount,
+		MonthlyAmount: r.MonthlyAmount,
+		DailyCount:    r.DailyCount,
+		MonthlyCount:  r.MonthlyCount,
+	}
+}
+
+func limitProduct(ctx *fiber.Ctx) (string, error) {
+	product := strings.ToUpper(ctx.Params("product"))
+	if !slices.Contains(limit.Products, product) {
+		return "", errors.New("invalid product parameter")
+	}
+
+	return product, nil
+}
@@ -3,11 +3,25 @@ package handlers
 import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
 	"merchants.sidooh/pkg/services/location"
 	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
 	"net/http"
+	"path/filepath"
+	"strings"
 )
 
+type LocationSearchRequest struct {
+	Query string `query:"q" validate:"required,min=2,max=64"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+type LocationImportRequest struct {
+	DryRun bool   `query:"dry_run"`
+	Format string `query:"format" validate:"omitempty,oneof=csv json"`
+}
+
 func GetCounties(service location.Service) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		fetched, err := service.GetCounties()
@@ -69,3 +83,57 @@ func GetLandmarks(service location.Service) fiber.Handler {
 		return utils.HandleSuccessResponse(ctx, fetched)
 	}
 }
+
+func SearchLocations(service location.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request LocationSearchRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+		if request.Limit == 0 {
+			request.Limit = 10
+		}
+
+		fetched, err := service.SearchLocations(request.Query, request.Limit)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+// ImportLocations takes the format from the query, the file extension or the content type, in that order.
+func ImportLocations(service location.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request LocationImportRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		fileName, content, err := uploadedFile(ctx, "")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid file")))
+		}
+		defer content.Close()
+
+		format := request.Format
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+		}
+		if format == "" {
+			format = consts.LOCATION_FORMAT_CSV
+			if strings.Contains(string(ctx.Request().Header.ContentType()), "json") {
+				format = consts.LOCATION_FORMAT_JSON
+			}
+		}
+
+		fetched, err := service.ImportLocations(content, format, request.DryRun)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
@@ -3,25 +3,53 @@ package handlers
 import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
 	"merchants.sidooh/api/middleware"
-	"merchants.sidooh/pkg/entities"
-	"merchants.sidooh/pkg/services/location"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/services/merchant"
 	"merchants.sidooh/utils"
 	"net/http"
+	"time"
 )
 
-type CreateMerchantRequest struct {
-	FirstName string `json:"first_name" validate:"required"`
-	LastName  string `json:"last_name" validate:"required"`
-	IdNumber  string `json:"id_number" validate:"required,numeric,min=8"`
-	AccountId uint   `json:"account_id" validate:"required,numeric"`
+type MerchantsSearchRequest struct {
+	Query       string `query:"q" validate:"omitempty,max=64"`
+	Status      string `query:"status" validate:"omitempty,oneof=PENDING_KYC PENDING_KYB ACTIVE SUSPENDED CLOSED"`
+	CountyId    int    `query:"county_id" validate:"omitempty,numeric"`
+	SubCountyId int    `query:"sub_county_id" validate:"omitempty,numeric"`
+	WardId      int    `query:"ward_id" validate:"omitempty,numeric"`
+	From        string `query:"from" validate:"omitempty,len=10"`
+	To          string `query:"to" validate:"omitempty,len=10"`
+	Sort        string `query:"sort" validate:"omitempty,oneof=id -id created_at -created_at business_name -business_name first_name -first_name last_name -last_name code -code"`
+	Page        int    `query:"page" validate:"omitempty,min=1"`
+	PageSize    int    `query:"page_size" validate:"omitempty,min=1,max=100"`
 }
 
-type UpdateMerchantKYBRequest struct {
-	BusinessName string `json:"business_name" validate:"required"`
-	Landmark     string `json:"landmark" validate:"required"`
+// NearbyMerchantsRequest takes the radius in kilometres.
+type NearbyMerchantsRequest struct {
+	Lat    *float64 `query:"lat" validate:"required,min=-90,max=90"`
+	Lng    *float64 `query:"lng" validate:"required,min=-180,max=180"`
+	Radius float64  `query:"radius" validate:"omitempty,gt=0,max=50"`
+	Limit  int      `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type MerchantAuditRequest struct {
+	Page     int `query:"page" validate:"omitempty,min=1"`
+	PageSize int `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type MerchantQRRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=text png"`
+	Size   int    `query:"size" validate:"omitempty,min=128,max=1024"`
+}
+
+type ParseQRRequest struct {
+	Payload string `json:"payload" validate:"required,max=512"`
+}
+
+type MerchantActionRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=255"`
 }
 
 func GetMerchant(service merchant.Service) fiber.Handler {
@@ -75,9 +103,91 @@ func GetMerchantByIdNumber(service merchant.Service) fiber.Handler {
 	}
 }
 
+func GetMerchantByCode(service merchant.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		code := ctx.Params("code")
+		if code == "" {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid code parameter")))
+		}
+
+		fetched, err := service.GetMerchantByCode(code)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetNearbyMerchants(service merchant.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request NearbyMerchantsRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+		if request.Radius == 0 {
+			request.Radius = 2
+		}
+		if request.Limit == 0 {
+			request.Limit = 20
+		}
+
+		fetched, err := service.GetNearbyMerchants(*request.Lat, *request.Lng, request.Radius, request.Limit)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
 func GetMerchants(service merchant.Service) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		fetched, err := service.FetchMerchants([]string{})
+		// Without search params the full list is returned as before, so existing clients keep the array shape
+		if len(ctx.Queries()) == 0 {
+			fetched, err := service.FetchMerchants([]string{})
+			if err != nil {
+				return utils.HandleErrorResponse(ctx, err)
+			}
+
+			return utils.HandleSuccessResponse(ctx, fetched)
+		}
+
+		var request MerchantsSearchRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		filters := merchant.SearchFilters{
+			Pagination:  datastore.Pagination{Page: request.Page, PageSize: request.PageSize},
+			Query:       request.Query,
+			Status:      request.Status,
+			CountyId:    request.CountyId,
+			SubCountyId: request.SubCountyId,
+			WardId:      request.WardId,
+			Sort:        request.Sort,
+		}
+		if request.From != "" {
+			from, err := time.Parse(time.DateOnly, request.From)
+			if err != nil {
+				ctx.Status(http.StatusBadRequest)
+				return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid from parameter")))
+			}
+			filters.From = &from
+		}
+		if request.To != "" {
+			to, err := time.Parse(time.DateOnly, request.To)
+			if err != nil {
+				ctx.Status(http.StatusBadRequest)
+				return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid to parameter")))
+			}
+			// inclusive of the whole "to" day
+			to = to.AddDate(0, 0, 1)
+			filters.To = &to
+		}
+
+		fetched, err := service.SearchMerchants(filters)
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
 		}
@@ -86,20 +196,41 @@ func GetMerchants(service merchant.Service) fiber.Handler {
 	}
 }
 
-func CreateMerchant(service merchant.Service) fiber.Handler {
+func SuspendMerchant(service merchant.Service) fiber.Handler {
+	return merchantAction(service.SuspendMerchant)
+}
+
+func ReactivateMerchant(service merchant.Service) fiber.Handler {
+	return merchantAction(service.ReactivateMerchant)
+}
+
+func CloseMerchant(service merchant.Service) fiber.Handler {
+	return merchantAction(service.CloseMerchant)
+}
+
+func RegenerateMerchantCode(service merchant.Service) fiber.Handler {
+	return merchantAction(service.RegenerateMerchantCode)
+}
+
+func RetireMerchantCode(service merchant.Service) fiber.Handler {
+	return merchantAction(service.RetireMerchantCode)
+}
+
+// merchantAction handles admin actions on a merchant that require a reason to be recorded.
+func merchantAction(transition func(id uint, reason, actor string) (*presenter.Merchant, error)) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		var request CreateMerchantRequest
+		var request MerchantActionRequest
 		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
 			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
 		}
 
-		fetched, err := service.CreateMerchant(&entities.Merchant{
-			FirstName: request.FirstName,
-			LastName:  request.LastName,
-			IdNumber:  request.IdNumber,
-			//Code:            "",
-			AccountId: request.AccountId,
-		})
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := transition(uint(id), request.Reason, jwt.Actor(ctx))
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
 		}
@@ -108,10 +239,49 @@ func CreateMerchant(service merchant.Service) fiber.Handler {
 	}
 }
 
-func UpdateMerchantKYB(service merchant.Service) fiber.Handler {
+func GetMerchantStatusHistory(service merchant.Service) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		var request UpdateMerchantKYBRequest
-		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetStatusHistory(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetMerchantAuditLog(service merchant.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MerchantAuditRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetAuditLog(uint(id), datastore.Pagination{Page: request.Page, PageSize: request.PageSize})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetMerchantQR(service merchant.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MerchantQRRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
 			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
 		}
 
@@ -121,19 +291,33 @@ func UpdateMerchantKYB(service merchant.Service) fiber.Handler {
 			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
 		}
 
-		data := &entities.Merchant{
-			ModelID:      entities.ModelID{Id: uint(id)},
-			BusinessName: &request.BusinessName,
+		if request.Format == "png" {
+			png, err := service.GetMerchantQRImage(uint(id), request.Size)
+			if err != nil {
+				return utils.HandleErrorResponse(ctx, err)
+			}
+
+			ctx.Set(fiber.HeaderContentType, "image/png")
+			return ctx.Send(png)
+		}
+
+		payload, err := service.GetMerchantQR(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
 		}
 
-		loc, err := location.NewRepo().GetLandmark(request.Landmark)
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			data.LocationId = &loc.Id
-		} else {
-			data.Landmark = &request.Landmark
+		return utils.HandleSuccessResponse(ctx, fiber.Map{"payload": payload})
+	}
+}
+
+func ParseMerchantQR(service merchant.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request ParseQRRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
 		}
 
-		fetched, err := service.UpdateMerchantKYB(data)
+		fetched, err := service.ResolveQR(request.Payload)
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
 		}
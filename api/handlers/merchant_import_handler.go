@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/services/merchant_import"
+	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
+	"net/http"
+)
+
+type MerchantImportRequest struct {
+	DryRun bool `query:"dry_run"`
+}
+
+// ImportMerchants accepts the csv as a multipart "file" or as the raw request body.
+func ImportMerchants(service merchant_import.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MerchantImportRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		fileName, content, err := uploadedFile(ctx, "upload.csv")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid file")))
+		}
+		defer content.Close()
+
+		if request.DryRun {
+			report, err := service.Validate(fileName, content)
+			if err != nil {
+				return utils.HandleErrorResponse(ctx, err)
+			}
+
+			return utils.HandleSuccessResponse(ctx, report)
+		}
+
+		job, err := service.CreateImport(fileName, content, jwt.Actor(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+		if job.Status == consts.IMPORT_PENDING {
+			job, err = service.Start(job.Id)
+			if err != nil {
+				return utils.HandleErrorResponse(ctx, err)
+			}
+		}
+
+		return utils.HandleSuccessResponse(ctx, job)
+	}
+}
+
+// uploadedFile returns the multipart "file" or, failing that, the raw request body under the default name.
+func uploadedFile(ctx *fiber.Ctx, defaultName string) (string, io.ReadCloser, error) {
+	if file, err := ctx.FormFile("file"); err == nil {
+		content, err := file.Open()
+		if err != nil {
+			return "", nil, err
+		}
+
+		return file.Filename, content, nil
+	}
+
+	if len(ctx.Body()) == 0 {
+		return "", nil, errors.New("no file uploaded")
+	}
+
+	return defaultName, io.NopCloser(bytes.NewReader(ctx.Body())), nil
+}
+
+func GetMerchantImport(service merchant_import.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetImport(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func ResumeMerchantImport(service merchant_import.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.Start(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/services/mpesa_float"
+	"merchants.sidooh/utils"
+	"net/http"
+)
+
+type MpesaFloatScheduleRequest struct {
+	StoreAccountId uint     `json:"store_account_id" validate:"required"`
+	Amount         int      `json:"amount" validate:"required,min=1"`
+	Days           []string `json:"days" validate:"required,min=1,max=7,dive,oneof=MON TUE WED THU FRI SAT SUN"`
+	Time           string   `json:"time" validate:"required,len=5"`
+}
+
+type MpesaFloatScheduleRunsRequest struct {
+	Page     int `query:"page" validate:"omitempty,min=1"`
+	PageSize int `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+func RepeatMpesaFloatPurchase(service mpesa_float.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		storeId, err := ctx.ParamsInt("storeId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid store id parameter")))
+		}
+
+		fetched, err := service.RepeatLastPurchase(uint(id), uint(storeId), jwt.AccountId(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetMpesaFloatSchedules(service mpesa_float.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetSchedules(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func CreateMpesaFloatSchedule(service mpesa_float.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MpesaFloatScheduleRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.CreateSchedule(mpesa_float.ScheduleRequest{
+			MerchantId:        uint(id),
+			StoreAccountId:    request.StoreAccountId,
+			Amount:            float32(request.Amount),
+			Days:              request.Days,
+			Time:              request.Time,
+			OperatorAccountId: jwt.AccountId(ctx),
+		})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetMpesaFloatSchedule(service mpesa_float.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		scheduleId, err := ctx.ParamsInt("scheduleId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid schedule id parameter")))
+		}
+
+		fetched, err := service.GetSchedule(uint(id), uint(scheduleId))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func PauseMpesaFloatSchedule(service mpesa_float.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		scheduleId, err := ctx.ParamsInt("scheduleId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid schedule id parameter")))
+		}
+
+		fetched, err := service.PauseSchedule(uint(id), uint(scheduleId))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func ResumeMpesaFloatSchedule(service mpesa_float.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		scheduleId, err := ctx.ParamsInt("scheduleId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid schedule id parameter")))
+		}
+
+		fetched, err := service.ResumeSchedule(uint(id), uint(scheduleId))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetMpesaFloatScheduleRuns(service mpesa_float.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MpesaFloatScheduleRunsRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		scheduleId, err := ctx.ParamsInt("scheduleId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid schedule id parameter")))
+		}
+
+		fetched, err := service.GetScheduleRuns(uint(id), uint(scheduleId), datastore.Pagination{Page: request.Page, PageSize: request.PageSize})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
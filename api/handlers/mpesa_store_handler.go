@@ -3,14 +3,40 @@ package handlers
 import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
 	"merchants.sidooh/pkg/services/mpesa_store"
 	"merchants.sidooh/utils"
 	"net/http"
 )
 
+type MpesaStoreRequest struct {
+	Agent     string  `json:"agent" validate:"required,numeric,min=5,max=8"`
+	Store     string  `json:"store" validate:"required,numeric,max=8"`
+	Nickname  *string `json:"nickname" validate:"omitempty,max=64"`
+	Favourite bool    `json:"favourite"`
+}
+
+type MpesaStoresRequest struct {
+	Query    string `query:"q" validate:"omitempty,max=64"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type UpdateMpesaStoreRequest struct {
+	Nickname  *string `json:"nickname" validate:"omitempty,max=64"`
+	Favourite *bool   `json:"favourite"`
+}
+
 func GetMpesaStores(service mpesa_store.Service) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
-		fetched, err := service.FetchAllStores()
+		var request MpesaStoresRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		fetched, err := service.SearchStores(request.Query, datastore.Pagination{Page: request.Page, PageSize: request.PageSize})
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
 		}
@@ -35,3 +61,107 @@ func GetMpesaStoresByMerchant(service mpesa_store.Service) fiber.Handler {
 		return utils.HandleSuccessResponse(ctx, fetched)
 	}
 }
+
+func GetMpesaStore(service mpesa_store.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		storeId, err := ctx.ParamsInt("storeId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid store id parameter")))
+		}
+
+		fetched, err := service.GetStore(uint(id), uint(storeId))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func CreateMpesaStore(service mpesa_store.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request MpesaStoreRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.CreateStore(&entities.MpesaAgentStoreAccount{
+			Agent:      request.Agent,
+			Store:      request.Store,
+			Nickname:   request.Nickname,
+			Favourite:  request.Favourite,
+			MerchantId: uint(id),
+		})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func UpdateMpesaStore(service mpesa_store.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request UpdateMpesaStoreRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		storeId, err := ctx.ParamsInt("storeId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid store id parameter")))
+		}
+
+		fetched, err := service.UpdateStore(uint(id), uint(storeId), mpesa_store.StoreUpdate{
+			Nickname:  request.Nickname,
+			Favourite: request.Favourite,
+		})
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func DeleteMpesaStore(service mpesa_store.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		storeId, err := ctx.ParamsInt("storeId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid store id parameter")))
+		}
+
+		if err = service.DeleteStore(uint(id), uint(storeId)); err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, nil)
+	}
+}
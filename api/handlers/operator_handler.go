@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/operator"
+	"merchants.sidooh/utils"
+	"net/http"
+)
+
+type AddOperatorRequest struct {
+	Phone       string   `json:"phone" validate:"required,numeric"`
+	Name        string   `json:"name" validate:"omitempty,max=64"`
+	Role        string   `json:"role" validate:"required,oneof=OWNER CASHIER VIEWER"`
+	MaxAmount   *float32 `json:"max_amount" validate:"omitempty,min=0"`
+	DailyAmount *float32 `json:"daily_amount" validate:"omitempty,min=0"`
+}
+
+type UpdateOperatorRequest struct {
+	Name        string   `json:"name" validate:"omitempty,max=64"`
+	Role        string   `json:"role" validate:"required,oneof=OWNER CASHIER VIEWER"`
+	MaxAmount   *float32 `json:"max_amount" validate:"omitempty,min=0"`
+	DailyAmount *float32 `json:"daily_amount" validate:"omitempty,min=0"`
+}
+
+func GetOperators(service operator.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.GetOperators(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func GetOperatorMerchants(service operator.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("accountId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid account id parameter")))
+		}
+
+		fetched, err := service.GetOperatorMerchants(uint(id))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func AddOperator(service operator.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request AddOperatorRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		fetched, err := service.AddOperator(&entities.MerchantOperator{
+			Phone:       request.Phone,
+			Name:        request.Name,
+			Role:        request.Role,
+			MaxAmount:   request.MaxAmount,
+			DailyAmount: request.DailyAmount,
+			MerchantId:  uint(id),
+		}, jwt.AccountId(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func UpdateOperator(service operator.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request UpdateOperatorRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		operatorId, err := ctx.ParamsInt("operatorId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid operator id parameter")))
+		}
+
+		fetched, err := service.UpdateOperator(&entities.MerchantOperator{
+			ModelID:     entities.ModelID{Id: uint(operatorId)},
+			Name:        request.Name,
+			Role:        request.Role,
+			MaxAmount:   request.MaxAmount,
+			DailyAmount: request.DailyAmount,
+			MerchantId:  uint(id),
+		}, jwt.AccountId(ctx))
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, fetched)
+	}
+}
+
+func RemoveOperator(service operator.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		operatorId, err := ctx.ParamsInt("operatorId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid operator id parameter")))
+		}
+
+		if err = service.RemoveOperator(uint(id), uint(operatorId), jwt.AccountId(ctx)); err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, nil)
+	}
+}
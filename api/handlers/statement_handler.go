@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/pkg/services/statement"
+	"merchants.sidooh/utils"
+	"net/http"
+	"time"
+)
+
+type StatementRequest struct {
+	From   string `query:"from" validate:"omitempty,len=10"`
+	To     string `query:"to" validate:"omitempty,len=10"`
+	Format string `query:"format" validate:"omitempty,oneof=json csv pdf"`
+}
+
+func GetMerchantStatement(service statement.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request StatementRequest
+		if err := middleware.BindAndValidateQuery(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("id")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid id parameter")))
+		}
+
+		// Defaults to the current month to date
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if request.From != "" {
+			from, err = time.Parse(time.DateOnly, request.From)
+			if err != nil {
+				ctx.Status(http.StatusBadRequest)
+				return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid from parameter")))
+			}
+		}
+		if request.To != "" {
+			to, err = time.Parse(time.DateOnly, request.To)
+			if err != nil {
+				ctx.Status(http.StatusBadRequest)
+				return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid to parameter")))
+			}
+		}
+
+		fetched, err := service.GetStatement(uint(id), from, to)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		var out bytes.Buffer
+		switch request.Format {
+		case "csv":
+			ctx.Set(fiber.HeaderContentType, "text/csv")
+			err = statement.WriteCSV(&out, fetched)
+		case "pdf":
+			ctx.Set(fiber.HeaderContentType, "application/pdf")
+			err = statement.WritePDF(&out, fetched)
+		default:
+			return utils.HandleSuccessResponse(ctx, fetched)
+		}
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		filename := fmt.Sprintf("statement-%d-%s-%s.%s", id, from.Format(time.DateOnly), to.Format(time.DateOnly), request.Format)
+		ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+		return ctx.Send(out.Bytes())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/middleware/jwt"
 	"merchants.sidooh/pkg/entities"
 	"merchants.sidooh/pkg/services/transaction"
 	"merchants.sidooh/utils"
@@ -21,9 +22,16 @@ type MpesaFloatPurchaseRequest struct {
 	DebitAccount string `json:"debit_account" validate:"omitempty,numeric"`
 }
 
+// FloatTransferRequest takes the recipient from the preview the token was issued for.
 type FloatTransferRequest struct {
-	Account string `json:"account" validate:"required,numeric"`
-	Amount  int    `json:"amount" validate:"required,numeric"`
+	Amount       int    `json:"amount" validate:"required,numeric"`
+	PreviewToken string `json:"preview_token" validate:"required"`
+}
+
+type FloatTransferPreviewRequest struct {
+	Recipient     string `json:"recipient" validate:"required"`
+	RecipientType string `json:"recipient_type" validate:"omitempty,oneof=CODE PHONE ID"`
+	Amount        int    `json:"amount" validate:"required,numeric"`
 }
 
 type MpesaWithdrawalRequest struct {
@@ -123,6 +131,8 @@ func MpesaFloat(service transaction.Service) fiber.Handler {
 			Destination: &dest,
 			MerchantId:  uint(id),
 			Product:     consts.MPESA_FLOAT,
+
+			OperatorAccountId: jwt.AccountId(ctx),
 		}, request.Agent, request.Store, request.Method, request.DebitAccount)
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
@@ -151,6 +161,8 @@ func MpesaWithdrawal(service transaction.Service) fiber.Handler {
 			Destination: &request.Phone,
 			MerchantId:  uint(id),
 			Product:     consts.CASH_WITHDRAW,
+
+			OperatorAccountId: jwt.AccountId(ctx),
 		})
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
@@ -179,6 +191,8 @@ func FloatTopUp(service transaction.Service) fiber.Handler {
 			Destination: &request.Phone,
 			MerchantId:  uint(id),
 			Product:     consts.FLOAT_PURCHASE,
+
+			OperatorAccountId: jwt.AccountId(ctx),
 		})
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
@@ -188,6 +202,34 @@ func FloatTopUp(service transaction.Service) fiber.Handler {
 	}
 }
 
+func PreviewFloatTransfer(service transaction.Service) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var request FloatTransferPreviewRequest
+		if err := middleware.BindAndValidateRequest(ctx, &request); err != nil {
+			return ctx.Status(http.StatusUnprocessableEntity).JSON(err)
+		}
+
+		id, err := ctx.ParamsInt("merchantId")
+		if err != nil {
+			ctx.Status(http.StatusBadRequest)
+			return ctx.JSON(utils.ValidationErrorResponse(errors.New("invalid merchant id parameter")))
+		}
+
+		preview, err := service.PreviewFloatTransfer(&entities.Transaction{
+			Amount:     float32(request.Amount),
+			MerchantId: uint(id),
+			Product:    consts.FLOAT_TRANSFER,
+
+			OperatorAccountId: jwt.AccountId(ctx),
+		}, request.Recipient, request.RecipientType)
+		if err != nil {
+			return utils.HandleErrorResponse(ctx, err)
+		}
+
+		return utils.HandleSuccessResponse(ctx, preview)
+	}
+}
+
 func FloatTransfer(service transaction.Service) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		var request FloatTransferRequest
@@ -204,10 +246,11 @@ func FloatTransfer(service transaction.Service) fiber.Handler {
 		fetched, err := service.FloatTransfer(&entities.Transaction{
 			Amount:      float32(request.Amount),
 			Description: "Voucher Transfer",
-			Destination: &request.Account,
 			MerchantId:  uint(id),
 			Product:     consts.FLOAT_TRANSFER,
-		})
+
+			OperatorAccountId: jwt.AccountId(ctx),
+		}, request.PreviewToken)
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
 		}
@@ -235,6 +278,8 @@ func FloatWithdraw(service transaction.Service) fiber.Handler {
 			Destination: &request.Account,
 			MerchantId:  uint(id),
 			Product:     consts.FLOAT_WITHDRAW,
+
+			OperatorAccountId: jwt.AccountId(ctx),
 		}, request.Destination, request.Account)
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
@@ -265,6 +310,8 @@ func WithdrawEarnings(service transaction.Service) fiber.Handler {
 			Destination: &dest,
 			MerchantId:  uint(id),
 			Product:     consts.EARNINGS_WITHDRAW,
+
+			OperatorAccountId: jwt.AccountId(ctx),
 		}, request.Source, request.Destination, request.Account)
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
@@ -295,6 +342,8 @@ func WithdrawSavings(service transaction.Service) fiber.Handler {
 			Destination: &dest,
 			MerchantId:  uint(id),
 			Product:     consts.SAVINGS_WITHDRAW,
+
+			OperatorAccountId: jwt.AccountId(ctx),
 		}, request.Source, request.Destination, request.Account)
 		if err != nil {
 			return utils.HandleErrorResponse(ctx, err)
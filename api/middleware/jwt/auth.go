@@ -6,8 +6,11 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/spf13/viper"
+	"math"
 	"merchants.sidooh/pkg"
 	"merchants.sidooh/utils"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -194,6 +197,95 @@ func New(config Config) fiber.Handler {
 	}
 }
 
+// identityClaim is the claim tokens identify the caller's Sidooh account by, the only one the issuer is known to set.
+const identityClaim = "id"
+
+/*
+Middleware specific
+
+Function to identify the authenticated caller from the claims set by the middleware, for audit trails
+*/
+func Actor(c *fiber.Ctx) string {
+	claims, ok := c.Locals("jwtClaims").(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+
+	// Tokens without an account, such as service tokens, are named by their subject or email
+	for _, key := range []string{identityClaim, "sub", "email"} {
+		if value, ok := claims[key]; ok && value != nil {
+			return fmt.Sprint(value)
+		}
+	}
+
+	return ""
+}
+
+/*
+Middleware specific
+
+Function to read the account acting in the request, the same identity Actor records, be it the merchant's own
+account or one of its operators'
+*/
+func AccountId(c *fiber.Ctx) *uint {
+	claims, ok := c.Locals("jwtClaims").(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+
+	var value float64
+	switch claim := claims[identityClaim].(type) {
+	case float64:
+		value = claim
+	case string:
+		value, _ = strconv.ParseFloat(claim, 64)
+	}
+	if value <= 0 || value != math.Trunc(value) {
+		return nil
+	}
+
+	id := uint(value)
+	return &id
+}
+
+/*
+Middleware specific
+
+Function to check the caller is an admin, from a role claim or a list of roles claim
+*/
+func IsAdmin(c *fiber.Ctx) bool {
+	claims, ok := c.Locals("jwtClaims").(jwt.MapClaims)
+	if !ok {
+		return false
+	}
+
+	if role, ok := claims["role"].(string); ok && strings.EqualFold(role, "ADMIN") {
+		return true
+	}
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			if role, ok := role.(string); ok && strings.EqualFold(role, "ADMIN") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+/*
+Middleware specific
+
+Handler that only lets admins through to the routes after it
+*/
+func RequireAdmin(c *fiber.Ctx) error {
+	if !IsAdmin(c) {
+		return utils.HandleErrorResponse(c, pkg.ErrAdminRequired)
+	}
+
+	return c.Next()
+}
+
 //
 //func setUserInContext(c *fiber.Ctx, id int) error {
 //	user, err := user.NewRepo().ReadUserByIdWithMerchant(id)
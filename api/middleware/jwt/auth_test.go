@@ -0,0 +1,38 @@
+package jwt
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccountId_SameIdentityAsActor(t *testing.T) {
+	read := func(claims jwt.MapClaims) (accountId *uint, actor string) {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			c.Locals("jwtClaims", claims)
+			accountId, actor = AccountId(c), Actor(c)
+			return nil
+		})
+		_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		assert.NoError(t, err)
+		return
+	}
+
+	accountId, actor := read(jwt.MapClaims{"id": float64(10), "email": "owner@sidooh.co.ke"})
+	assert.Equal(t, uint(10), *accountId)
+	assert.Equal(t, "10", actor)
+
+	accountId, _ = read(jwt.MapClaims{"id": "12"})
+	assert.Equal(t, uint(12), *accountId)
+
+	// Tokens without an account are named for audits, but act as no account
+	accountId, actor = read(jwt.MapClaims{"sub": "jobs", "account_id": float64(10)})
+	assert.Nil(t, accountId)
+	assert.Equal(t, "jobs", actor)
+
+	accountId, _ = read(jwt.MapClaims{"id": 1.5})
+	assert.Nil(t, accountId)
+}
@@ -48,3 +48,15 @@ func BindAndValidateRequest(context *fiber.Ctx, request interface{}) error {
 
 	return nil
 }
+
+func BindAndValidateQuery(context *fiber.Ctx, request interface{}) error {
+	if err := context.QueryParser(request); err != nil {
+		return err
+	}
+
+	if err := validate(request); err != nil {
+		return err
+	}
+
+	return nil
+}
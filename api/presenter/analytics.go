@@ -0,0 +1,42 @@
+package presenter
+
+import "time"
+
+// MerchantDistribution groups merchants by location level, activity and volume cover the last Days days.
+type MerchantDistribution struct {
+	Level       string              `json:"level"`
+	Days        int                 `json:"days"`
+	Since       time.Time           `json:"since"`
+	Groups      []DistributionGroup `json:"groups"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// DistributionGroup is a county, sub-county or ward, id 0 holds the merchants without a location.
+// Latitude and Longitude are the centre of its landmarks, nil when none have coordinates.
+type DistributionGroup struct {
+	Id              int                `json:"id"`
+	Name            string             `json:"name"`
+	Merchants       int                `json:"merchants"`
+	ActiveMerchants int                `json:"active_merchants"`
+	Transactions    int                `json:"transactions"`
+	Amount          float32            `json:"amount"`
+	Products        []ProductStatistic `json:"products"`
+	Latitude        *float64           `json:"latitude"`
+	Longitude       *float64           `json:"longitude"`
+}
+
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string      `json:"type"`
+	Geometry   *Geometry   `json:"geometry"`
+	Properties interface{} `json:"properties"`
+}
+
+type Geometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
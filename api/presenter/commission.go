@@ -0,0 +1,9 @@
+package presenter
+
+// CommissionPlan is how a product's inviter commission is shared, Rates[0] being the direct inviter's share.
+type CommissionPlan struct {
+	Product    string    `json:"product"`
+	Rates      []float32 `json:"rates"`
+	DailyCap   *float32  `json:"daily_cap"`
+	MonthlyCap *float32  `json:"monthly_cap"`
+}
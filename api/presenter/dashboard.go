@@ -0,0 +1,26 @@
+package presenter
+
+import "time"
+
+// Dashboard holds a merchant's home screen figures, FloatBalance is nil when the voucher balance could not be fetched.
+type Dashboard struct {
+	FloatBalance    *float32           `json:"float_balance"`
+	EarningBalances map[string]float32 `json:"earning_balances"`
+	Today           DashboardPeriod    `json:"today"`
+	Month           DashboardPeriod    `json:"month"`
+	GeneratedAt     time.Time          `json:"generated_at"`
+}
+
+type DashboardPeriod struct {
+	Since    time.Time          `json:"since"`
+	Count    int                `json:"count"`
+	Amount   float32            `json:"amount"`
+	Products []ProductStatistic `json:"products"`
+	Earnings map[string]float32 `json:"earnings"`
+}
+
+type ProductStatistic struct {
+	Product string  `json:"product"`
+	Count   int     `json:"count"`
+	Amount  float32 `json:"amount"`
+}
@@ -1,7 +1,21 @@
 package presenter
 
+import "time"
+
 type EarningAccount struct {
 	Id     uint    `json:"id"`
 	Type   string  `json:"type"`
 	Amount float32 `json:"amount"`
 }
+
+type EarningAccountTransaction struct {
+	Id           uint      `json:"id"`
+	Type         string    `json:"type"`
+	Amount       float32   `json:"amount"`
+	BalanceAfter *float32  `json:"balance_after"`
+	Description  string    `json:"description"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	TransactionId *uint `json:"transaction_id"`
+	EarningId     *uint `json:"earning_id"`
+}
@@ -0,0 +1,13 @@
+package presenter
+
+import "time"
+
+type FloatTransferPreview struct {
+	Token        string    `json:"token"`
+	RecipientId  uint      `json:"recipient_id"`
+	BusinessName string    `json:"business_name"`
+	Amount       float32   `json:"amount"`
+	Charge       float32   `json:"charge"`
+	Balance      float32   `json:"balance"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
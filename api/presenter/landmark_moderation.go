@@ -0,0 +1,21 @@
+package presenter
+
+// LandmarkGroup gathers free-text landmarks that look alike, named after the spelling most merchants used.
+type LandmarkGroup struct {
+	Landmark    string                 `json:"landmark"`
+	Merchants   int                    `json:"merchants"`
+	Variants    []LandmarkVariant      `json:"variants"`
+	Suggestions []LocationSearchResult `json:"suggestions"`
+}
+
+type LandmarkVariant struct {
+	Landmark  string `json:"landmark"`
+	Merchants int    `json:"merchants"`
+}
+
+// LandmarkResolution lists the merchants whose location was backfilled, and any that could not be updated.
+type LandmarkResolution struct {
+	Location  LocationSearchResult `json:"location"`
+	Merchants []uint               `json:"merchants"`
+	Failed    []uint               `json:"failed"`
+}
@@ -19,3 +19,42 @@ type Landmark struct {
 	LandmarkId uint   `json:"id"`
 	Landmark   string `json:"landmark"`
 }
+
+// LocationImport reports what importing a location dataset changed, or would change on a dry run.
+type LocationImport struct {
+	Applied   bool                  `json:"applied"`
+	Total     int                   `json:"total"`
+	Added     int                   `json:"added"`
+	Changed   int                   `json:"changed"`
+	Unchanged int                   `json:"unchanged"`
+	Changes   []LocationChange      `json:"changes"`
+	Errors    []LocationImportError `json:"errors"`
+}
+
+type LocationChange struct {
+	LandmarkId int                            `json:"landmark_id"`
+	Action     string                         `json:"action"`
+	Fields     map[string]LocationFieldChange `json:"fields,omitempty"`
+}
+
+type LocationFieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// LocationImportError points at the offending record, its csv line or its position among the json landmarks.
+type LocationImportError struct {
+	Record     int    `json:"record"`
+	LandmarkId int    `json:"landmark_id"`
+	Error      string `json:"error"`
+}
+
+// LocationSearchResult is a landmark with its full path up the hierarchy.
+type LocationSearchResult struct {
+	County    County    `json:"county"`
+	SubCounty SubCounty `json:"sub_county"`
+	Ward      Ward      `json:"ward"`
+	Landmark  Landmark  `json:"landmark"`
+	Path      string    `json:"path"`
+	Score     float64   `json:"score"`
+}
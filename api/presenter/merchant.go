@@ -1,5 +1,7 @@
 package presenter
 
+import "time"
+
 type Merchant struct {
 	Id             uint   `json:"id"`
 	FirstName      string `json:"first_name"`
@@ -10,4 +12,42 @@ type Merchant struct {
 	Code           string `json:"code"`
 	AccountId      uint   `json:"account_id"`
 	FloatAccountId uint   `json:"float_account_id"`
+	Status         string `json:"status"`
+	Tier           string `json:"tier"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+
+	OnboardingStep  string `json:"onboarding_step"`
+	OnboardingError string `json:"onboarding_error,omitempty"`
+}
+
+// NearbyMerchant is a float-selling merchant with its distance in kilometres from the searched point.
+type NearbyMerchant struct {
+	Id           uint    `json:"id"`
+	BusinessName string  `json:"business_name"`
+	Code         string  `json:"code"`
+	Landmark     string  `json:"landmark"`
+	Ward         string  `json:"ward"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Distance     float64 `json:"distance"`
+}
+
+type OnboardingStep struct {
+	Step        string     `json:"step"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	CompletedAt *time.Time `json:"completed_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type MerchantStatusChange struct {
+	Id         uint      `json:"id"`
+	FromStatus string    `json:"from"`
+	ToStatus   string    `json:"to"`
+	Reason     string    `json:"reason"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
 }
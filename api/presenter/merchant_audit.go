@@ -0,0 +1,14 @@
+package presenter
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+type MerchantAudit struct {
+	Id        uint           `json:"id"`
+	Changes   datatypes.JSON `json:"changes"`
+	Actor     string         `json:"actor"`
+	Source    string         `json:"source"`
+	CreatedAt time.Time      `json:"created_at"`
+}
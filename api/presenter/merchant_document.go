@@ -0,0 +1,17 @@
+package presenter
+
+import "time"
+
+type MerchantDocument struct {
+	Id          uint       `json:"id"`
+	MerchantId  uint       `json:"merchant_id"`
+	Type        string     `json:"type"`
+	FileName    string     `json:"file_name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	Status      string     `json:"status"`
+	Reason      string     `json:"reason,omitempty"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package presenter
+
+import "time"
+
+// MerchantImport is an import job, dry runs are reported the same way without an id.
+type MerchantImport struct {
+	Id        uint                `json:"id"`
+	FileName  string              `json:"file_name"`
+	Status    string              `json:"status"`
+	Actor     string              `json:"actor"`
+	Total     int                 `json:"total"`
+	Invalid   int                 `json:"invalid"`
+	Created   int                 `json:"created"`
+	Failed    int                 `json:"failed"`
+	Rows      []MerchantImportRow `json:"rows" gorm:"-"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+type MerchantImportRow struct {
+	Line         int     `json:"line"`
+	FirstName    string  `json:"first_name"`
+	LastName     string  `json:"last_name"`
+	IdNumber     string  `json:"id_number"`
+	Phone        string  `json:"phone"`
+	AccountId    *uint   `json:"account_id"`
+	BusinessName string  `json:"business_name"`
+	Landmark     string  `json:"landmark"`
+	Status       string  `json:"status"`
+	Error        *string `json:"error"`
+	MerchantId   *uint   `json:"merchant_id"`
+}
@@ -0,0 +1,37 @@
+package presenter
+
+import "time"
+
+type MpesaFloatSchedule struct {
+	Id        uint       `json:"id"`
+	Amount    float32    `json:"amount"`
+	Days      string     `json:"days"`
+	Time      string     `json:"time"`
+	Status    string     `json:"status"`
+	NextRunAt *time.Time `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+
+	StoreAccountId uint    `json:"store_account_id"`
+	Agent          string  `json:"agent"`
+	Store          string  `json:"store"`
+	Name           string  `json:"name"`
+	Nickname       *string `json:"nickname"`
+
+	MerchantId        uint      `json:"merchant_id"`
+	OperatorAccountId *uint     `json:"operator_account_id"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// MpesaFloatScheduleRun is a time a schedule was due, with the status of the transaction it created if any.
+type MpesaFloatScheduleRun struct {
+	Id           uint      `json:"id"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	Status       string    `json:"status"`
+	Error        *string   `json:"error"`
+
+	TransactionId     *uint    `json:"transaction_id"`
+	TransactionStatus *string  `json:"transaction_status"`
+	Amount            *float32 `json:"amount"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -1,14 +1,22 @@
 package presenter
 
+import "time"
+
 type MpesaAgentStoreAccount struct {
 	Id    uint   `json:"id"`
 	Agent string `json:"agent"`
 	Store string `json:"store"`
 	Name  string `json:"name"`
+
+	Nickname  *string `json:"nickname"`
+	Favourite bool    `json:"favourite"`
 }
 
 type MpesaStore struct {
 	Agent string `json:"agent"`
 	Store string `json:"store"`
 	Name  string `json:"name"`
+
+	UsageCount uint      `json:"usage_count"`
+	LastSeenAt time.Time `json:"last_seen_at"`
 }
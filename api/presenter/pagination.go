@@ -0,0 +1,8 @@
+package presenter
+
+type Paginated struct {
+	Data     interface{} `json:"data"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	Total    int64       `json:"total"`
+}
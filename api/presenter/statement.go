@@ -0,0 +1,29 @@
+package presenter
+
+import "time"
+
+type Statement struct {
+	MerchantId   uint      `json:"merchant_id"`
+	BusinessName string    `json:"business_name"`
+	Code         string    `json:"code"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+
+	OpeningBalances map[string]float32 `json:"opening_balances"`
+	ClosingBalances map[string]float32 `json:"closing_balances"`
+	TotalDebit      float32            `json:"total_debit"`
+	TotalCredit     float32            `json:"total_credit"`
+
+	Entries []StatementEntry `json:"entries"`
+}
+
+// StatementEntry is a single debit or credit, Balance is nil for accounts whose balance is not held locally.
+type StatementEntry struct {
+	Date        time.Time `json:"date"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Account     string    `json:"account"`
+	Debit       float32   `json:"debit"`
+	Credit      float32   `json:"credit"`
+	Balance     *float32  `json:"balance"`
+}
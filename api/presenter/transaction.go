@@ -13,4 +13,6 @@ type Transaction struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Payment     *Payment  `json:"payment,omitempty"`
+
+	OperatorAccountId *uint `json:"operator_account_id"`
 }
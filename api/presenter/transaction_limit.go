@@ -0,0 +1,19 @@
+package presenter
+
+// TransactionLimit is a merchant's effective limit for a product, nil limits are unlimited.
+type TransactionLimit struct {
+	Product   string     `json:"product"`
+	MinAmount *float32   `json:"min_amount"`
+	MaxAmount *float32   `json:"max_amount"`
+	Daily     LimitUsage `json:"daily"`
+	Monthly   LimitUsage `json:"monthly"`
+}
+
+type LimitUsage struct {
+	Amount          *float32 `json:"amount"`
+	AmountUsed      float32  `json:"amount_used"`
+	AmountRemaining *float32 `json:"amount_remaining"`
+	Count           *int     `json:"count"`
+	CountUsed       int      `json:"count_used"`
+	CountRemaining  *int     `json:"count_remaining"`
+}
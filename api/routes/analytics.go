@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/services/analytics"
+)
+
+func AnalyticsRouter(app fiber.Router, service analytics.Service) {
+	app.Get("/analytics/merchants/distribution", jwt.RequireAdmin, handlers.GetMerchantDistribution(service))
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/services/commission"
+)
+
+func CommissionRouter(app fiber.Router, service commission.Service) {
+	app.Get("/commission-plans", handlers.GetCommissionPlans(service))
+	app.Put("/commission-plans/:product", jwt.RequireAdmin, handlers.SetCommissionPlan(service))
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/pkg/services/dashboard"
+)
+
+func DashboardRouter(app fiber.Router, service dashboard.Service) {
+	app.Get("/merchants/:id/dashboard", handlers.GetMerchantDashboard(service))
+}
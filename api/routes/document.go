@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/services/document"
+)
+
+func DocumentRouter(app fiber.Router, service document.Service) {
+	app.Get("/merchants/:id/documents", handlers.GetMerchantDocuments(service))
+	app.Post("/merchants/:id/documents", handlers.UploadMerchantDocument(service))
+
+	app.Get("/documents", jwt.RequireAdmin, handlers.GetDocumentsQueue(service))
+	app.Get("/documents/:id/file", jwt.RequireAdmin, handlers.GetDocumentFile(service))
+	app.Post("/documents/:id/approve", jwt.RequireAdmin, handlers.ApproveDocument(service))
+	app.Post("/documents/:id/reject", jwt.RequireAdmin, handlers.RejectDocument(service))
+}
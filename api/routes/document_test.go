@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	jwtv4 "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/api/middleware"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/services/document"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestApp serves the routes with the claims the jwt middleware would have decoded from the caller's token.
+func newTestApp(claims jwtv4.MapClaims, router func(app fiber.Router)) *fiber.App {
+	middleware.Validator = validator.New()
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("jwtClaims", claims)
+		return c.Next()
+	})
+	router(app)
+
+	return app
+}
+
+type fakeDocumentService struct {
+	document.Service
+
+	approved []uint
+}
+
+func (f *fakeDocumentService) GetReviewQueue(status string, pagination datastore.Pagination) (*presenter.Paginated, error) {
+	return &presenter.Paginated{Data: []presenter.MerchantDocument{}}, nil
+}
+
+func (f *fakeDocumentService) ApproveDocument(id uint, actor string) (*presenter.MerchantDocument, error) {
+	f.approved = append(f.approved, id)
+	return &presenter.MerchantDocument{Id: id}, nil
+}
+
+func TestDocumentRouter_ReviewRequiresAdmin(t *testing.T) {
+	service := &fakeDocumentService{}
+	router := func(app fiber.Router) { DocumentRouter(app, service) }
+
+	merchant := newTestApp(jwtv4.MapClaims{"id": float64(10)}, router)
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/documents", nil),
+		httptest.NewRequest(http.MethodGet, "/documents/1/file", nil),
+		httptest.NewRequest(http.MethodPost, "/documents/1/approve", nil),
+		httptest.NewRequest(http.MethodPost, "/documents/1/reject", nil),
+	} {
+		res, err := merchant.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode, req.URL.Path)
+	}
+	assert.Empty(t, service.approved)
+
+	admin := newTestApp(jwtv4.MapClaims{"id": float64(1), "role": "ADMIN"}, router)
+	res, err := admin.Test(httptest.NewRequest(http.MethodGet, "/documents", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = admin.Test(httptest.NewRequest(http.MethodPost, "/documents/1/approve", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []uint{1}, service.approved)
+}
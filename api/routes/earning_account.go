@@ -8,4 +8,5 @@ import (
 
 func EarningAccountRouter(app fiber.Router, service earning_account.Service) {
 	app.Get("earning-accounts/merchant/:id", handlers.GetEarningAccountsByMerchant(service))
+	app.Get("earning-accounts/:id/transactions", handlers.GetEarningAccountTransactions(service))
 }
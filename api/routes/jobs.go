@@ -10,4 +10,8 @@ func JobsRouter(app fiber.Router, service jobs.Service) {
 	app.Post("/jobs/invest-earnings", handlers.HandleEarningsInvestments(service))
 
 	app.Get("/jobs/query-payments-status", handlers.QueryPaymentsStatus(service))
+
+	app.Post("/jobs/run-scheduled-purchases", handlers.RunScheduledPurchases(service))
+
+	app.Post("/jobs/process-commissions", handlers.ProcessCommissions(service))
 }
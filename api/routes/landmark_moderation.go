@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/services/landmark_moderation"
+)
+
+func LandmarkModerationRouter(app fiber.Router, service landmark_moderation.Service) {
+	app.Get("/landmarks/unmatched", jwt.RequireAdmin, handlers.GetUnmatchedLandmarks(service))
+	app.Post("/landmarks/unmatched/resolve", jwt.RequireAdmin, handlers.ResolveLandmarks(service))
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/services/limit"
+)
+
+func LimitRouter(app fiber.Router, service limit.Service) {
+	app.Get("/merchants/:id/limits", handlers.GetMerchantRemainingLimits(service))
+	app.Get("/merchants/:id/limits/overrides", handlers.GetMerchantLimitOverrides(service))
+	app.Put("/merchants/:id/limits/:product", jwt.RequireAdmin, handlers.SetMerchantLimit(service))
+	app.Delete("/merchants/:id/limits/:product", jwt.RequireAdmin, handlers.RemoveMerchantLimit(service))
+	app.Post("/merchants/:id/tier", jwt.RequireAdmin, handlers.SetMerchantTier(service))
+
+	app.Get("/limits/tiers/:tier", handlers.GetTierLimits(service))
+	app.Put("/limits/tiers/:tier/:product", jwt.RequireAdmin, handlers.SetTierLimit(service))
+}
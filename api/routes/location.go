@@ -3,6 +3,7 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
 	"merchants.sidooh/pkg/services/location"
 )
 
@@ -11,4 +12,7 @@ func LocationRouter(app fiber.Router, service location.Service) {
 	app.Get("/counties/:county", handlers.GetSubCounties(service))
 	app.Get("/counties/:county/sub-counties/:subCounty", handlers.GetWards(service))
 	app.Get("/counties/:county/sub-counties/:subCounty/wards/:ward", handlers.GetLandmarks(service))
+
+	app.Get("/locations/search", handlers.SearchLocations(service))
+	app.Post("/locations/import", jwt.RequireAdmin, handlers.ImportLocations(service))
 }
@@ -3,14 +3,26 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
 	"merchants.sidooh/pkg/services/merchant"
 )
 
 func MerchantRouter(app fiber.Router, service merchant.Service) {
 	app.Get("/merchants", handlers.GetMerchants(service))
+	app.Get("/merchants/nearby", handlers.GetNearbyMerchants(service))
 	app.Get("/merchants/account/:accountId", handlers.GetMerchantByAccount(service))
 	app.Get("/merchants/id-number/:idNumber", handlers.GetMerchantByIdNumber(service))
-	app.Post("/merchants", handlers.CreateMerchant(service))
+	app.Get("/merchants/code/:code", handlers.GetMerchantByCode(service))
+	app.Post("/merchants/qr/parse", handlers.ParseMerchantQR(service))
 	app.Get("/merchants/:id", handlers.GetMerchant(service))
-	app.Post("/merchants/:id/kyb", handlers.UpdateMerchantKYB(service))
+
+	app.Get("/merchants/:id/status-history", handlers.GetMerchantStatusHistory(service))
+	app.Get("/merchants/:id/audit", handlers.GetMerchantAuditLog(service))
+	app.Post("/merchants/:id/suspend", jwt.RequireAdmin, handlers.SuspendMerchant(service))
+	app.Post("/merchants/:id/reactivate", jwt.RequireAdmin, handlers.ReactivateMerchant(service))
+	app.Post("/merchants/:id/close", jwt.RequireAdmin, handlers.CloseMerchant(service))
+
+	app.Post("/merchants/:id/code/regenerate", jwt.RequireAdmin, handlers.RegenerateMerchantCode(service))
+	app.Post("/merchants/:id/code/retire", jwt.RequireAdmin, handlers.RetireMerchantCode(service))
+	app.Get("/merchants/:id/qr", handlers.GetMerchantQR(service))
 }
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/api/middleware/jwt"
+	"merchants.sidooh/pkg/services/merchant_import"
+)
+
+func MerchantImportRouter(app fiber.Router, service merchant_import.Service) {
+	app.Post("/merchant-imports", jwt.RequireAdmin, handlers.ImportMerchants(service))
+	app.Get("/merchant-imports/:id", jwt.RequireAdmin, handlers.GetMerchantImport(service))
+	app.Post("/merchant-imports/:id/resume", jwt.RequireAdmin, handlers.ResumeMerchantImport(service))
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/pkg/services/mpesa_float"
+)
+
+func MpesaFloatRouter(app fiber.Router, service mpesa_float.Service) {
+	app.Post("/merchants/:id/mpesa-store-accounts/:storeId/repeat", handlers.RepeatMpesaFloatPurchase(service))
+
+	app.Get("/merchants/:id/mpesa-float-schedules", handlers.GetMpesaFloatSchedules(service))
+	app.Post("/merchants/:id/mpesa-float-schedules", handlers.CreateMpesaFloatSchedule(service))
+	app.Get("/merchants/:id/mpesa-float-schedules/:scheduleId", handlers.GetMpesaFloatSchedule(service))
+	app.Post("/merchants/:id/mpesa-float-schedules/:scheduleId/pause", handlers.PauseMpesaFloatSchedule(service))
+	app.Post("/merchants/:id/mpesa-float-schedules/:scheduleId/resume", handlers.ResumeMpesaFloatSchedule(service))
+	app.Get("/merchants/:id/mpesa-float-schedules/:scheduleId/transactions", handlers.GetMpesaFloatScheduleRuns(service))
+}
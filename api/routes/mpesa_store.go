@@ -8,5 +8,9 @@ import (
 
 func MpesaStoreRouter(app fiber.Router, service mpesa_store.Service) {
 	app.Get("merchants/:id/mpesa-store-accounts", handlers.GetMpesaStoresByMerchant(service))
+	app.Post("merchants/:id/mpesa-store-accounts", handlers.CreateMpesaStore(service))
+	app.Get("merchants/:id/mpesa-store-accounts/:storeId", handlers.GetMpesaStore(service))
+	app.Put("merchants/:id/mpesa-store-accounts/:storeId", handlers.UpdateMpesaStore(service))
+	app.Delete("merchants/:id/mpesa-store-accounts/:storeId", handlers.DeleteMpesaStore(service))
 	app.Get("mpesa-store-accounts", handlers.GetMpesaStores(service))
 }
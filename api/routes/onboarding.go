@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/pkg/services/onboarding"
+)
+
+func OnboardingRouter(app fiber.Router, service onboarding.Service) {
+	app.Post("/merchants", handlers.CreateMerchant(service))
+	app.Post("/merchants/:id/kyb", handlers.UpdateMerchantKYB(service))
+	app.Get("/merchants/:id/onboarding", handlers.GetOnboardingSteps(service))
+	app.Post("/merchants/:id/onboarding/resume", handlers.ResumeOnboarding(service))
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/pkg/services/operator"
+)
+
+func OperatorRouter(app fiber.Router, service operator.Service) {
+	app.Get("/operators/account/:accountId/merchants", handlers.GetOperatorMerchants(service))
+
+	app.Get("/merchants/:id/operators", handlers.GetOperators(service))
+	app.Post("/merchants/:id/operators", handlers.AddOperator(service))
+	app.Put("/merchants/:id/operators/:operatorId", handlers.UpdateOperator(service))
+	app.Delete("/merchants/:id/operators/:operatorId", handlers.RemoveOperator(service))
+}
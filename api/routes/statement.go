@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"merchants.sidooh/api/handlers"
+	"merchants.sidooh/pkg/services/statement"
+)
+
+func StatementRouter(app fiber.Router, service statement.Service) {
+	app.Get("/merchants/:id/statement", handlers.GetMerchantStatement(service))
+}
@@ -12,6 +12,7 @@ func TransactionRouter(app fiber.Router, service transaction.Service) {
 
 	app.Get("/merchants/:merchantId/transactions", handlers.GetTransactionsByMerchant(service))
 	app.Post("/merchants/:merchantId/float-top-up", handlers.FloatTopUp(service))
+	app.Post("/merchants/:merchantId/float-transfer/preview", handlers.PreviewFloatTransfer(service))
 	app.Post("/merchants/:merchantId/float-transfer", handlers.FloatTransfer(service))
 	app.Post("/merchants/:merchantId/float-withdraw", handlers.FloatWithdraw(service))
 	app.Post("/merchants/:merchantId/buy-mpesa-float", handlers.MpesaFloat(service))
@@ -17,17 +17,30 @@ import (
 	"merchants.sidooh/api/routes"
 	"merchants.sidooh/pkg/clients"
 	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/alert"
+	"merchants.sidooh/pkg/services/analytics"
+	"merchants.sidooh/pkg/services/commission"
+	"merchants.sidooh/pkg/services/dashboard"
+	"merchants.sidooh/pkg/services/document"
 	"merchants.sidooh/pkg/services/earning"
 	"merchants.sidooh/pkg/services/earning_account"
 	"merchants.sidooh/pkg/services/earning_account_transaction"
 	"merchants.sidooh/pkg/services/ipn"
 	"merchants.sidooh/pkg/services/jobs"
+	"merchants.sidooh/pkg/services/landmark_moderation"
+	"merchants.sidooh/pkg/services/limit"
 	"merchants.sidooh/pkg/services/location"
 	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/services/merchant_import"
+	"merchants.sidooh/pkg/services/mpesa_float"
 	"merchants.sidooh/pkg/services/mpesa_store"
+	"merchants.sidooh/pkg/services/onboarding"
+	"merchants.sidooh/pkg/services/operator"
 	"merchants.sidooh/pkg/services/payment"
 	"merchants.sidooh/pkg/services/savings"
+	"merchants.sidooh/pkg/services/statement"
 	"merchants.sidooh/pkg/services/transaction"
+	"merchants.sidooh/pkg/storage"
 	"merchants.sidooh/utils"
 	"time"
 )
@@ -79,13 +92,29 @@ func setHandlers(app *fiber.App) {
 	clients.InitPaymentClient()
 	clients.InitNotifyClient()
 	clients.InitSavingsClient()
+	clients.InitWebhookClient()
+
+	alertSrv := alert.NewService()
 
 	merchantRep := merchant.NewRepo()
 	merchantSrv := merchant.NewService(merchantRep)
 
+	documentStorage, err := storage.New()
+	if err != nil {
+		panic(err)
+	}
+
+	documentRep := document.NewRepo()
+	onboardingSrv := onboarding.NewService(onboarding.NewRepo(), merchantRep, merchantSrv, documentRep)
+	documentSrv := document.NewService(documentRep, merchantRep, documentStorage, onboardingSrv)
+
 	locationRep := location.NewRepo()
 	locationSrv := location.NewService(locationRep)
 
+	landmarkModerationSrv := landmark_moderation.NewService(landmark_moderation.NewRepo(), locationRep, locationSrv, merchantRep)
+
+	merchantImportSrv := merchant_import.NewService(merchant_import.NewRepo(), merchantRep, locationRep, onboardingSrv)
+
 	paymentRep := payment.NewRepo()
 	paymentSrv := payment.NewService(paymentRep, merchantRep)
 
@@ -103,11 +132,21 @@ func setHandlers(app *fiber.App) {
 	mpesaStoreRep := mpesa_store.NewRepo()
 	mpesaStoreSrv := mpesa_store.NewService(mpesaStoreRep)
 
+	commissionRep := commission.NewRepo()
+	commissionSrv := commission.NewService(commissionRep)
+	limitSrv := limit.NewService(limit.NewRepo(), merchantRep)
+	operatorSrv := operator.NewService(operator.NewRepo(), merchantRep)
+
 	transactionRep := transaction.NewRepo()
-	transactionSrv := transaction.NewService(transactionRep, merchantRep, paymentRep, savingsRep, earningAccRep, earningRep, mpesaStoreRep, earningAccSrv, earningSrv)
+	transactionSrv := transaction.NewService(transactionRep, merchantRep, merchantSrv, paymentRep, savingsRep, earningAccRep, earningRep, commissionRep, mpesaStoreSrv, earningAccSrv, earningSrv, commissionSrv, limitSrv, operatorSrv)
+
+	statementSrv := statement.NewService(statement.NewRepo(), merchantRep)
+	dashboardSrv := dashboard.NewService(dashboard.NewRepo(), merchantRep)
+	analyticsSrv := analytics.NewService(analytics.NewRepo())
+	mpesaFloatSrv := mpesa_float.NewService(mpesa_float.NewRepo(), mpesaStoreRep, merchantRep, transactionSrv, operatorSrv)
 
-	ipnSrv := ipn.NewService(paymentRep, savingsRep, transactionRep, merchantRep, mpesaStoreRep, earningAccRep, earningRep, transactionSrv, earningAccSrv, earningSrv)
-	jobsSrv := jobs.NewService(earningSrv, paymentSrv, transactionSrv)
+	ipnSrv := ipn.NewService(paymentRep, savingsRep, transactionRep, merchantRep, mpesaStoreRep, earningAccRep, earningRep, transactionSrv, earningAccSrv, earningSrv, alertSrv)
+	jobsSrv := jobs.NewService(earningSrv, paymentSrv, transactionSrv, alertSrv, mpesaFloatSrv)
 
 	routes.IpnRouter(v1, ipnSrv)
 	routes.JobsRouter(v1, jobsSrv)
@@ -118,10 +157,21 @@ func setHandlers(app *fiber.App) {
 	}))
 
 	routes.MerchantRouter(v1, merchantSrv)
+	routes.OnboardingRouter(v1, onboardingSrv)
+	routes.MerchantImportRouter(v1, merchantImportSrv)
+	routes.DocumentRouter(v1, documentSrv)
+	routes.LimitRouter(v1, limitSrv)
+	routes.OperatorRouter(v1, operatorSrv)
 	routes.LocationRouter(v1, locationSrv)
+	routes.LandmarkModerationRouter(v1, landmarkModerationSrv)
 	routes.TransactionRouter(v1, transactionSrv)
+	routes.StatementRouter(v1, statementSrv)
+	routes.DashboardRouter(v1, dashboardSrv)
+	routes.AnalyticsRouter(v1, analyticsSrv)
 	routes.MpesaStoreRouter(v1, mpesaStoreSrv)
+	routes.MpesaFloatRouter(v1, mpesaFloatSrv)
 	routes.EarningAccountRouter(v1, earningAccSrv)
+	routes.CommissionRouter(v1, commissionSrv)
 }
 
 func Server() *fiber.App {
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/services/document"
+	"merchants.sidooh/pkg/services/location"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/services/merchant_import"
+	"merchants.sidooh/pkg/services/onboarding"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commands run once instead of the server, e.g. `merchants import-merchants -dry-run merchants.csv`.
+var commands = map[string]func(args []string) error{
+	"import-merchants": importMerchants,
+	"import-locations": importLocations,
+}
+
+func runCommand(name string, args []string) {
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		os.Exit(2)
+	}
+
+	if err := command(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func importMerchants(args []string) error {
+	flags := flag.NewFlagSet("import-merchants", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "validate the file and print the report without importing")
+	actor := flags.String("actor", "CLI", "who the import is attributed to")
+	resume := flags.Uint("resume", 0, "id of an import to resume instead of importing a file")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: import-merchants [-dry-run] [-actor name] file.csv | import-merchants -resume id")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	clients.InitAccountClient()
+	clients.InitPaymentClient()
+	clients.InitNotifyClient()
+
+	merchantRep := merchant.NewRepo()
+	onboardingSrv := onboarding.NewService(onboarding.NewRepo(), merchantRep, merchant.NewService(merchantRep), document.NewRepo())
+	service := merchant_import.NewService(merchant_import.NewRepo(), merchantRep, location.NewRepo(), onboardingSrv)
+
+	if *resume != 0 {
+		job, err := service.Run(*resume)
+		if err != nil {
+			return err
+		}
+		return printJSON(job)
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileName := filepath.Base(file.Name())
+	if *dryRun {
+		report, err := service.Validate(fileName, file)
+		if err != nil {
+			return err
+		}
+		return printJSON(report)
+	}
+
+	job, err := service.CreateImport(fileName, file, *actor)
+	if err != nil {
+		return err
+	}
+	if job.Invalid == 0 {
+		if job, err = service.Run(job.Id); err != nil {
+			return err
+		}
+	}
+
+	return printJSON(job)
+}
+
+func importLocations(args []string) error {
+	flags := flag.NewFlagSet("import-locations", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report what would be added or changed without saving")
+	format := flags.String("format", "", "csv or json, defaults to the file extension")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: import-locations [-dry-run] [-format csv|json] file")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Name())), ".")
+	}
+
+	report, err := location.NewService(location.NewRepo()).ImportLocations(file, *format, *dryRun)
+	if err != nil {
+		return err
+	}
+	if err = printJSON(report); err != nil {
+		return err
+	}
+	if len(report.Errors) > 0 {
+		return fmt.Errorf("%d invalid records, nothing was imported", len(report.Errors))
+	}
+
+	return nil
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -18,6 +18,16 @@ import (
 func main() {
 	utils.SetupConfig(".")
 
+	if len(os.Args) > 1 {
+		logger.Init()
+		datastore.Init()
+		cache.Init()
+		clients.Init()
+
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	jwtKey := viper.GetString("JWT_KEY")
 	if len(jwtKey) == 0 {
 		panic("JWT_KEY is not set")
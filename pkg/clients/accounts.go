@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/spf13/viper"
 	"net/http"
+	"strconv"
 )
 
 var accountClient *ApiClient
@@ -80,11 +81,12 @@ func (api *ApiClient) GetAccountById(id string) (*Account, error) {
 	return apiResponse.Data, nil
 }
 
-func (api *ApiClient) GetInviters(accountId string) ([]Account, error) {
+// GetInviters returns the account followed by its inviters up to levels above it.
+func (api *ApiClient) GetInviters(accountId string, levels int) ([]Account, error) {
 	var apiResponse = new(AccountsApiResponse)
 
 	//TODO: Cache this
-	err := api.NewRequest(http.MethodGet, "/accounts/"+accountId+"/ancestors?level_limit=2", nil).Send(apiResponse)
+	err := api.NewRequest(http.MethodGet, "/accounts/"+accountId+"/ancestors?level_limit="+strconv.Itoa(levels), nil).Send(apiResponse)
 	if err != nil {
 		return nil, err
 	}
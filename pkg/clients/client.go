@@ -70,7 +70,7 @@ func (api *ApiClient) Send(data interface{}) error {
 	start := time.Now()
 	response, err := api.client.Do(api.request)
 	if err != nil {
-		logger.ClientLog.Error("Error sending request to API endpoint: ", err)
+		logger.ClientLog.Error("Error sending request to API endpoint", "err", err)
 		return err
 	}
 	// Close the connection to reuse it
@@ -135,7 +135,7 @@ func (api *ApiClient) baseRequest(method string, endpoint string, body io.Reader
 	endpoint = api.getUrl(endpoint)
 	request, err := http.NewRequest(method, endpoint, body)
 	if err != nil {
-		logger.ClientLog.Error("error creating HTTP request: %v", err)
+		logger.ClientLog.Error("error creating HTTP request", "err", err)
 	}
 
 	api.request = request
@@ -166,7 +166,7 @@ func (api *ApiClient) ensureAuthenticated() {
 
 	err = api.authenticate(jsonData)
 	if err != nil {
-		logger.ClientLog.Error("error authenticating: %v", err)
+		logger.ClientLog.Error("error authenticating", "err", err)
 	}
 }
 
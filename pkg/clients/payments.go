@@ -3,11 +3,13 @@ package clients
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/spf13/viper"
 	"merchants.sidooh/pkg/cache"
 	"merchants.sidooh/utils"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -32,6 +34,11 @@ type FloatAccountApiResponse struct {
 	Data *FloatAccount `json:"data"`
 }
 
+type FloatAccountsApiResponse struct {
+	ApiResponse
+	Data *[]FloatAccount `json:"data"`
+}
+
 type FloatAccountTransactionsApiResponse struct {
 	ApiResponse
 	Data *[]FloatAccountTransaction `json:"data"`
@@ -65,6 +72,34 @@ func (api *ApiClient) CreateFloatAccount(merchantId, accountId, code int) (*Floa
 	return apiResponse.Data, err
 }
 
+// FetchMerchantFloatAccount finds the float account opened for a merchant by CreateFloatAccount, nil if there is none.
+// Accounts returned that were not opened for the merchant's account are an error rather than ignored, as are several
+// for the same merchant, so a loose lookup never binds the merchant to another's float.
+func (api *ApiClient) FetchMerchantFloatAccount(merchantId, accountId int) (*FloatAccount, error) {
+	var apiResponse = new(FloatAccountsApiResponse)
+
+	var endpoint = "/float-accounts?initiator=MERCHANT&reference=" + strconv.Itoa(merchantId) + "&account_id=" + strconv.Itoa(accountId)
+	err := api.NewRequest(http.MethodGet, endpoint, nil).Send(apiResponse)
+	if err != nil {
+		return nil, err
+	}
+	if apiResponse.Data == nil || len(*apiResponse.Data) == 0 {
+		return nil, nil
+	}
+
+	for _, account := range *apiResponse.Data {
+		if account.FloatableId != merchantId || account.AccountId != accountId || !strings.EqualFold(account.FloatableType, "MERCHANT") {
+			return nil, fmt.Errorf("float account %d is not merchant %d's, it is %s %d's of account %d",
+				account.Id, merchantId, account.FloatableType, account.FloatableId, account.AccountId)
+		}
+	}
+	if len(*apiResponse.Data) > 1 {
+		return nil, fmt.Errorf("merchant %d has %d float accounts", merchantId, len(*apiResponse.Data))
+	}
+
+	return &(*apiResponse.Data)[0], nil
+}
+
 func (api *ApiClient) CreditFloatAccount(accountId, floatAccountId, amount, phone int) (*interface{}, error) {
 	var apiResponse = new(ApiResponse)
 
@@ -248,6 +283,26 @@ func (api *ApiClient) GetMpesaCollectionCharges() ([]utils.AmountCharge, error)
 	return *apiResponse.Data, nil
 }
 
+func (api *ApiClient) GetFloatTransferCharges() ([]utils.AmountCharge, error) {
+	endpoint := "/charges/float-transfer"
+	apiResponse := new(utils.ChargesApiResponse)
+
+	cacheValue := paymentsCache.Get(endpoint)
+	if cacheValue != nil {
+		charges := *(*cacheValue).(*[]utils.AmountCharge)
+		if charges != nil && len(charges) > 0 {
+			return charges, nil
+		}
+	}
+
+	if err := api.NewRequest(http.MethodGet, endpoint, nil).Send(&apiResponse); err != nil {
+		return nil, err
+	}
+	paymentsCache.Set(endpoint, apiResponse.Data, 24*time.Hour)
+
+	return *apiResponse.Data, nil
+}
+
 func (api *ApiClient) Withdraw(accountId, floatAccountId uint, amount int, destination, destinationAccount string) (*utils.Payment, error) {
 	var apiResponse = new(utils.PaymentApiResponse)
 
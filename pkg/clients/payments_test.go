@@ -0,0 +1,52 @@
+package clients
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func floatAccountsRequest(body string) RoundTripFunc {
+	return func(req *http.Request) *http.Response {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(strings.NewReader(`{"result":1,"data":` + body + `}`)),
+		}
+	}
+}
+
+func TestApiClient_FetchMerchantFloatAccount(t *testing.T) {
+	InitPaymentClient()
+	api := GetPaymentClient()
+
+	own := `{"id":7,"balance":0,"account_id":10,"floatable_id":1,"floatable_type":"MERCHANT"}`
+
+	tests := []struct {
+		name    string
+		apiMock RoundTripFunc
+		want    *FloatAccount
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{"merchant has no float account", floatAccountsRequest(`[]`), nil, assert.NoError},
+		{"merchant's float account", floatAccountsRequest(`[` + own + `]`),
+			&FloatAccount{Id: 7, AccountId: 10, FloatableId: 1, FloatableType: "MERCHANT"}, assert.NoError},
+		{"another merchant's float account", floatAccountsRequest(`[{"id":8,"account_id":10,"floatable_id":2,"floatable_type":"MERCHANT"}]`), nil, assert.Error},
+		{"another account's float account", floatAccountsRequest(`[{"id":8,"account_id":11,"floatable_id":1,"floatable_type":"MERCHANT"}]`), nil, assert.Error},
+		{"another initiator's float account", floatAccountsRequest(`[{"id":8,"account_id":10,"floatable_id":1,"floatable_type":"USER"}]`), nil, assert.Error},
+		{"filters ignored", floatAccountsRequest(`[` + own + `,{"id":8,"account_id":12,"floatable_id":3,"floatable_type":"MERCHANT"}]`), nil, assert.Error},
+		{"several float accounts", floatAccountsRequest(`[` + own + `,` + own + `]`), nil, assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api.client = &http.Client{Transport: tt.apiMock}
+			got, err := api.FetchMerchantFloatAccount(1, 10)
+			if !tt.wantErr(t, err, fmt.Sprintf("FetchMerchantFloatAccount(%v, %v)", 1, 10)) {
+				return
+			}
+			assert.Equalf(t, tt.want, got, "FetchMerchantFloatAccount(%v, %v)", 1, 10)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+var webhookClient *ApiClient
+
+func InitWebhookClient() {
+	webhookClient = New("")
+	webhookClient.client = &http.Client{Timeout: 30 * time.Second}
+}
+
+func GetWebhookClient() *ApiClient {
+	return webhookClient
+}
+
+// PostWebhook sends the payload to an absolute url without the sidooh auth handshake,
+// since webhook receivers are third parties (e.g. chat integrations).
+func (api *ApiClient) PostWebhook(url string, payload interface{}) error {
+	var apiResponse = new(ApiResponse)
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	dataBytes := bytes.NewBuffer(jsonData)
+
+	return api.baseRequest(http.MethodPost, url, dataBytes).Send(apiResponse)
+}
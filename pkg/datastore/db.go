@@ -11,6 +11,7 @@ import (
 	"log"
 	"merchants.sidooh/pkg/entities"
 	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
 	"strings"
 	"time"
 )
@@ -60,23 +61,141 @@ func Init() {
 	}
 
 	if viper.GetBool("MIGRATE_DB") {
+		dedupEarnings(gormDb)
+
 		err := gormDb.AutoMigrate(
 			&entities.Merchant{},
 			&entities.Location{},
 			&entities.Transaction{},
 			&entities.Payment{},
 			&entities.MpesaAgentStoreAccount{},
+			&entities.MpesaAgentStore{},
+			&entities.MpesaFloatSchedule{},
+			&entities.MpesaFloatScheduleRun{},
 			&entities.EarningAccount{},
 			&entities.Earning{},
 			&entities.EarningAccountTransaction{},
 			&entities.SavingsTransaction{},
+			&entities.MerchantStatusChange{},
+			&entities.MerchantCode{},
+			&entities.OnboardingStep{},
+			&entities.MerchantDocument{},
+			&entities.TransactionLimit{},
+			&entities.CommissionPlan{},
+			&entities.PendingCommission{},
+			&entities.MerchantOperator{},
+			&entities.FloatTransferPreview{},
+			&entities.MerchantAudit{},
+			&entities.MerchantImport{},
+			&entities.MerchantImportRow{},
 		)
 		if err != nil {
 			logrus.Error(err)
 			panic("failed to auto-migrate")
 		}
+
+		backfillMerchantStatus(gormDb)
+		backfillMerchantCodes(gormDb)
+		backfillOnboardingStep(gormDb)
+		backfillMpesaStores(gormDb)
 		logrus.Println("Auto-migrated db")
 	}
 
 	DB = gormDb
 }
+
+// dedupEarnings readies earnings recorded before they were unique to their transaction and account for the index.
+// Earnings without an account are set to account 0, and of duplicates only the first recorded is kept, with the
+// earning account entries of the others linked to it.
+func dedupEarnings(db *gorm.DB) {
+	if !db.Migrator().HasTable(&entities.Earning{}) {
+		return
+	}
+
+	err := db.Exec(`UPDATE earnings SET account_id = 0 WHERE account_id IS NULL`).Error
+	if err == nil && db.Migrator().HasColumn(&entities.EarningAccountTransaction{}, "earning_id") {
+		err = db.Exec(`UPDATE earning_account_transactions SET earning_id = (
+			SELECT MIN(k.id) FROM earnings k JOIN earnings d ON k.transaction_id = d.transaction_id AND k.account_id = d.account_id
+			WHERE d.id = earning_account_transactions.earning_id)
+			WHERE earning_id IN (SELECT id FROM earnings)`).Error
+	}
+	if err == nil {
+		result := db.Exec(`DELETE FROM earnings WHERE id NOT IN (
+			SELECT id FROM (SELECT MIN(id) AS id FROM earnings GROUP BY transaction_id, account_id) kept)`)
+		if result.RowsAffected > 0 {
+			logrus.Printf("Removed %d duplicate earnings", result.RowsAffected)
+		}
+		err = result.Error
+	}
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+// backfillMerchantStatus sets a status on merchants created before statuses existed,
+// those with a float account have completed onboarding.
+func backfillMerchantStatus(db *gorm.DB) {
+	err := db.Model(&entities.Merchant{}).
+		Where("status IS NULL OR status = ''").
+		Where("float_account_id IS NOT NULL").
+		Update("status", consts.MERCHANT_ACTIVE).Error
+	if err == nil {
+		err = db.Model(&entities.Merchant{}).
+			Where("status IS NULL OR status = ''").
+			Update("status", consts.MERCHANT_PENDING_KYB).Error
+	}
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+// backfillMerchantCodes reserves codes issued before the allocator existed so they are never handed out again.
+func backfillMerchantCodes(db *gorm.DB) {
+	err := db.Exec(`INSERT INTO merchant_codes (code, status, merchant_id, created_at, updated_at)
+		SELECT code, ?, id, created_at, updated_at FROM merchants
+		WHERE code IS NOT NULL AND code NOT IN (SELECT code FROM merchant_codes)`, consts.CODE_ACTIVE).Error
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+// backfillMpesaStores lists stores saved before the directory existed, counting each merchant that saved one as a use.
+func backfillMpesaStores(db *gorm.DB) {
+	err := db.Exec(`INSERT INTO mpesa_agent_stores (agent, store, name, usage_count, last_seen_at, created_at, updated_at)
+		SELECT agent, store, MAX(name), COUNT(*), MAX(updated_at), MIN(created_at), MAX(updated_at) FROM mpesa_agent_store_accounts a
+		WHERE NOT EXISTS (SELECT 1 FROM mpesa_agent_stores s WHERE s.agent = a.agent AND s.store = a.store)
+		GROUP BY agent, store`).Error
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+// backfillOnboardingStep places merchants created before onboarding steps existed at the step they stopped at.
+// Those that had submitted KYB details predate document requirements, so they resume at the code or float account.
+func backfillOnboardingStep(db *gorm.DB) {
+	err := db.Model(&entities.Merchant{}).
+		Where("onboarding_step IS NULL OR onboarding_step = ''").
+		Where("float_account_id IS NOT NULL").
+		Update("onboarding_step", consts.ONBOARDING_COMPLETED).Error
+	if err == nil {
+		err = db.Model(&entities.Merchant{}).
+			Where("onboarding_step IS NULL OR onboarding_step = ''").
+			Where("business_name IS NOT NULL AND business_name != ''").
+			Where("code IS NOT NULL").
+			Update("onboarding_step", consts.ONBOARDING_FLOAT_ACCOUNT).Error
+	}
+	if err == nil {
+		err = db.Model(&entities.Merchant{}).
+			Where("onboarding_step IS NULL OR onboarding_step = ''").
+			Where("business_name IS NOT NULL AND business_name != ''").
+			Update("onboarding_step", consts.ONBOARDING_CODE_ALLOCATION).Error
+	}
+	if err == nil {
+		err = db.Model(&entities.Merchant{}).
+			Where("onboarding_step IS NULL OR onboarding_step = ''").
+			Update("onboarding_step", consts.ONBOARDING_KYB).Error
+	}
+	if err != nil {
+		logrus.Error(err)
+	}
+}
@@ -2,6 +2,10 @@ package datastore
 
 import (
 	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"merchants.sidooh/pkg/entities"
 	"os"
 	"testing"
 )
@@ -21,3 +25,52 @@ func TestDBInit(t *testing.T) {
 
 	os.Remove("test.db")
 }
+
+// legacyEarning is an earning as recorded before earnings were unique to their transaction and account.
+type legacyEarning struct {
+	Id            uint `gorm:"primaryKey"`
+	Amount        float32
+	TransactionId uint
+	AccountId     *uint
+}
+
+func (legacyEarning) TableName() string {
+	return "earnings"
+}
+
+func TestDBInit_DedupsEarnings(t *testing.T) {
+	t.Cleanup(func() { os.Remove("test.db") })
+
+	legacy, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := uint(10)
+	assert.Nil(t, legacy.AutoMigrate(&legacyEarning{}, &entities.EarningAccountTransaction{}))
+	assert.Nil(t, legacy.Create(&[]legacyEarning{
+		{Amount: 6, TransactionId: 1, AccountId: &account},
+		{Amount: 6, TransactionId: 1, AccountId: &account},
+		{Amount: 3, TransactionId: 1},
+		{Amount: 3, TransactionId: 1},
+		{Amount: 6, TransactionId: 2, AccountId: &account},
+	}).Error)
+	duplicate := uint(2)
+	assert.Nil(t, legacy.Create(&entities.EarningAccountTransaction{Type: "CREDIT", Amount: 6, EarningAccountId: 1, EarningId: &duplicate}).Error)
+
+	viper.Set("APP_ENV", "TEST")
+	viper.Set("MIGRATE_DB", true)
+	Init()
+
+	var earnings []entities.Earning
+	assert.Nil(t, DB.Order("id").Find(&earnings).Error)
+	assert.Len(t, earnings, 3)
+	assert.Equal(t, []uint{1, 3, 5}, []uint{earnings[0].Id, earnings[1].Id, earnings[2].Id})
+	assert.Equal(t, uint(0), earnings[1].AccountId)
+
+	entry := entities.EarningAccountTransaction{}
+	assert.Nil(t, DB.First(&entry).Error)
+	assert.Equal(t, uint(1), *entry.EarningId)
+
+	// The index now holds
+	assert.Error(t, DB.Create(&entities.Earning{Amount: 6, TransactionId: 1, AccountId: 10}).Error)
+}
@@ -0,0 +1,37 @@
+package datastore
+
+import "gorm.io/gorm"
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type Pagination struct {
+	Page     int
+	PageSize int
+}
+
+// Normalize applies defaults so that a zero value Pagination returns the first page.
+func (p Pagination) Normalize() Pagination {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize < 1 {
+		p.PageSize = DefaultPageSize
+	}
+	if p.PageSize > MaxPageSize {
+		p.PageSize = MaxPageSize
+	}
+
+	return p
+}
+
+// Paginate is a gorm scope that limits a query to the requested page.
+func Paginate(p Pagination) func(db *gorm.DB) *gorm.DB {
+	p = p.Normalize()
+
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset((p.Page - 1) * p.PageSize).Limit(p.PageSize)
+	}
+}
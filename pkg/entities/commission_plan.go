@@ -0,0 +1,16 @@
+package entities
+
+// CommissionPlan sets how a product's inviter commission is shared up the referral tree. Rates holds the share of the
+// commission paid at each level, from the direct inviter up, so its length is how deep the tree is paid. The caps bound
+// what a single inviter may earn on the product in a day or month, nil is uncapped.
+type CommissionPlan struct {
+	ModelID
+
+	Product string `json:"product" gorm:"not null;size:32;uniqueIndex"`
+	Rates   string `json:"rates" gorm:"not null;size:64"` // e.g. 1,0.5
+
+	DailyCap   *float32 `json:"daily_cap" gorm:"type:decimal(12,2)"`
+	MonthlyCap *float32 `json:"monthly_cap" gorm:"type:decimal(12,2)"`
+
+	ModelTimeStamps
+}
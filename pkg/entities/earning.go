@@ -7,11 +7,16 @@ type Earning struct {
 	Type   string  `json:"type" gorm:"size:16;"`                   //SELF / INVITE / SYSTEM
 	Status string  `json:"status" gorm:"size:16; default:PENDING"` //PENDING / SAVED
 
+	// Level is how far up the referral tree an INVITE earning's account is, 1 being the direct inviter, and Inviters
+	// the chain of inviter account ids it was paid from, so commissions stay auditable if the tree later changes.
+	Level    uint   `json:"level" gorm:"not null;default:0"`
+	Inviters string `json:"inviters" gorm:"size:255"`
+
 	TransactionId uint `json:"transaction_id" gorm:"not null;uniqueIndex:idx_earnings"`
 
 	Transaction Transaction `json:"-"`
 
-	AccountId uint `json:"accountId" gorm:"uniqueIndex:idx_earnings"`
+	AccountId uint `json:"accountId" gorm:"not null;uniqueIndex:idx_earnings"`
 
 	ModelTimeStamps
 }
@@ -3,12 +3,18 @@ package entities
 type EarningAccountTransaction struct {
 	ModelID
 
-	Type   string  `json:"type" gorm:"not null;size:16;"` // CREDIT / DEBIT
-	Amount float32 `json:"amount" gorm:"not null;type:decimal(12,2);"`
+	Type         string   `json:"type" gorm:"not null;size:16;"` // CREDIT / DEBIT
+	Amount       float32  `json:"amount" gorm:"not null;type:decimal(12,2);"`
+	BalanceAfter *float32 `json:"balance_after" gorm:"type:decimal(12,2);"` // Not known for entries made before balances were recorded
+	Description  string   `json:"description" gorm:"size:128"`
 
-	EarningAccountId uint `json:"earning_account_id" gorm:"not null;"`
+	EarningAccountId uint  `json:"earning_account_id" gorm:"not null;index"`
+	TransactionId    *uint `json:"transaction_id" gorm:"index"`
+	EarningId        *uint `json:"earning_id" gorm:"index"`
 
 	EarningAccount EarningAccount
+	Transaction    *Transaction `json:"-"`
+	Earning        *Earning     `json:"-"`
 
 	ModelTimeStamps
 }
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// FloatTransferPreview is the recipient and amount a merchant confirmed, its token authorizes a single transfer.
+type FloatTransferPreview struct {
+	ModelID
+
+	Token       string  `json:"token" gorm:"not null;size:64;uniqueIndex"`
+	Amount      float32 `json:"amount" gorm:"not null;type:decimal(10,2)"`
+	Charge      float32 `json:"charge" gorm:"type:decimal(10,2)"`
+	RecipientId uint    `json:"recipient_id" gorm:"not null"`
+
+	MerchantId    uint  `json:"merchant_id" gorm:"not null;index"`
+	TransactionId *uint `json:"transaction_id"`
+
+	Merchant Merchant `json:"-"`
+
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+
+	ModelTimeStamps
+}
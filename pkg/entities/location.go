@@ -11,4 +11,7 @@ type Location struct {
 	Ward        string `json:"ward" gorm:"varchar; size:32"`
 	LandmarkId  int    `json:"landmark_id" gorm:"uniqueIndex; size:64"`
 	Landmark    string `json:"landmark" gorm:"varchar; size:64"`
+
+	Latitude  *float64 `json:"latitude" gorm:"index:idx_locations_coordinates"`
+	Longitude *float64 `json:"longitude" gorm:"index:idx_locations_coordinates"`
 }
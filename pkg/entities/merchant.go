@@ -10,11 +10,20 @@ type Merchant struct {
 
 	BusinessName *string `json:"business_name" gorm:"size:128"`
 	Code         *uint   `json:"code" gorm:"unique; size:24"`
+	Status       string  `json:"status" gorm:"size:16; index"` // PENDING_KYC / PENDING_KYB / ACTIVE / SUSPENDED / CLOSED
+	Tier         string  `json:"tier" gorm:"size:16; default:STANDARD"`
 
 	AccountId      uint    `json:"account_id" gorm:"not null; uniqueIndex"`
 	FloatAccountId *uint   `json:"-" gorm:"uniqueIndex"`
 	LocationId     *uint   `json:"-"`
 	Landmark       *string `json:"-" gorm:"size:128"`
 
+	// Latitude and Longitude pin the shop when it is more precise than its landmark's coordinates
+	Latitude  *float64 `json:"latitude" gorm:"index:idx_merchants_coordinates"`
+	Longitude *float64 `json:"longitude" gorm:"index:idx_merchants_coordinates"`
+
+	OnboardingStep  string  `json:"onboarding_step" gorm:"size:32"`
+	OnboardingError *string `json:"onboarding_error" gorm:"size:255"`
+
 	ModelTimeStamps
 }
@@ -0,0 +1,18 @@
+package entities
+
+import "gorm.io/datatypes"
+
+// MerchantAudit records the fields an update changed on a merchant, as {"column": {"from": ..., "to": ...}}.
+type MerchantAudit struct {
+	ModelID
+
+	Changes datatypes.JSON `json:"changes"`
+	Actor   string         `json:"actor" gorm:"size:64"`
+	Source  string         `json:"source" gorm:"not null;size:32"`
+
+	MerchantId uint `json:"merchant_id" gorm:"not null;index"`
+
+	Merchant Merchant `json:"-"`
+
+	ModelTimeStamps
+}
@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+type MerchantCode struct {
+	ModelID
+
+	Code   uint   `json:"code" gorm:"not null;uniqueIndex"`
+	Status string `json:"status" gorm:"size:16; default:ACTIVE"` // ACTIVE / RETIRED
+	Reason string `json:"reason" gorm:"size:255"`
+	Actor  string `json:"actor" gorm:"size:64"`
+
+	MerchantId uint `json:"merchant_id" gorm:"not null;index"`
+
+	RetiredAt *time.Time `json:"retired_at"`
+
+	ModelTimeStamps
+}
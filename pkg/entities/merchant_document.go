@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+type MerchantDocument struct {
+	ModelID
+
+	Type        string  `json:"type" gorm:"not null;size:32"`
+	Path        string  `json:"-" gorm:"not null;size:255"`
+	FileName    string  `json:"file_name" gorm:"size:255"`
+	ContentType string  `json:"content_type" gorm:"size:64"`
+	Size        int64   `json:"size"`
+	Status      string  `json:"status" gorm:"size:16; default:PENDING; index"` // PENDING / APPROVED / REJECTED / SUPERSEDED
+	Reason      *string `json:"reason" gorm:"size:255"`
+	ReviewedBy  *string `json:"reviewed_by" gorm:"size:64"`
+
+	MerchantId uint `json:"merchant_id" gorm:"not null;index"`
+
+	Merchant Merchant `json:"-"`
+
+	ReviewedAt *time.Time `json:"reviewed_at"`
+
+	ModelTimeStamps
+}
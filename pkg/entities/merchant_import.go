@@ -0,0 +1,38 @@
+package entities
+
+type MerchantImport struct {
+	ModelID
+
+	FileName string `json:"file_name" gorm:"size:255"`
+	Status   string `json:"status" gorm:"size:16; default:PENDING; index"` // INVALID / PENDING / RUNNING / COMPLETED / PARTIAL
+	Actor    string `json:"actor" gorm:"size:64"`
+
+	Total   int `json:"total"`
+	Invalid int `json:"invalid"`
+	Created int `json:"created"`
+	Failed  int `json:"failed"`
+
+	ModelTimeStamps
+}
+
+type MerchantImportRow struct {
+	ModelID
+
+	Line         int     `json:"line" gorm:"not null"`
+	FirstName    string  `json:"first_name" gorm:"size:32"`
+	LastName     string  `json:"last_name" gorm:"size:32"`
+	IdNumber     string  `json:"id_number" gorm:"size:16"`
+	Phone        string  `json:"phone" gorm:"size:16"`
+	AccountId    *uint   `json:"account_id"`
+	BusinessName string  `json:"business_name" gorm:"size:128"`
+	Landmark     string  `json:"landmark" gorm:"size:128"`
+	Status       string  `json:"status" gorm:"size:16; default:VALID"` // VALID / INVALID / CREATED / FAILED
+	Error        *string `json:"error" gorm:"size:255"`
+
+	MerchantImportId uint  `json:"merchant_import_id" gorm:"not null;index"`
+	MerchantId       *uint `json:"merchant_id"`
+
+	MerchantImport MerchantImport `json:"-"`
+
+	ModelTimeStamps
+}
@@ -0,0 +1,21 @@
+package entities
+
+// MerchantOperator is an additional account that may act for a merchant, the merchant's own account is always its owner.
+type MerchantOperator struct {
+	ModelID
+
+	AccountId uint   `json:"account_id" gorm:"not null;uniqueIndex:idx_merchant_operators"`
+	Phone     string `json:"phone" gorm:"size:16"`
+	Name      string `json:"name" gorm:"size:64"`
+	Role      string `json:"role" gorm:"not null;size:16"`                 // OWNER / CASHIER / VIEWER
+	Status    string `json:"status" gorm:"size:16; default:ACTIVE; index"` // ACTIVE / REMOVED
+
+	MaxAmount   *float32 `json:"max_amount" gorm:"type:decimal(10,2)"`
+	DailyAmount *float32 `json:"daily_amount" gorm:"type:decimal(12,2)"`
+
+	MerchantId uint `json:"merchant_id" gorm:"not null;uniqueIndex:idx_merchant_operators"`
+
+	Merchant Merchant `json:"-"`
+
+	ModelTimeStamps
+}
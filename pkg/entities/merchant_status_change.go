@@ -0,0 +1,16 @@
+package entities
+
+type MerchantStatusChange struct {
+	ModelID
+
+	FromStatus string `json:"from" gorm:"size:16"`
+	ToStatus   string `json:"to" gorm:"not null;size:16"`
+	Reason     string `json:"reason" gorm:"not null;size:255"`
+	Actor      string `json:"actor" gorm:"size:64"`
+
+	MerchantId uint `json:"merchant_id" gorm:"not null;index"`
+
+	Merchant Merchant `json:"-"`
+
+	ModelTimeStamps
+}
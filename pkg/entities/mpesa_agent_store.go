@@ -0,0 +1,17 @@
+package entities
+
+import "time"
+
+// MpesaAgentStore is the directory entry for a store merchants have bought float from.
+type MpesaAgentStore struct {
+	ModelID
+
+	Agent string `json:"agent" gorm:"not null;size:16;uniqueIndex:idx_agent_store"`
+	Store string `json:"store" gorm:"not null;size:16;uniqueIndex:idx_agent_store"`
+	Name  string `json:"name" gorm:"size:64;index"`
+
+	UsageCount uint      `json:"usage_count" gorm:"not null;default:0"`
+	LastSeenAt time.Time `json:"last_seen_at" gorm:"index"`
+
+	ModelTimeStamps
+}
@@ -1,15 +1,21 @@
 package entities
 
+import "gorm.io/gorm"
+
 type MpesaAgentStoreAccount struct {
 	ModelID
 
 	Agent string `json:"agent" gorm:"not null;size:16;uniqueIndex:idx_account;index:idx_store"`
-	Store string `json:"status" gorm:"not null;size:16;uniqueIndex:idx_account;index:idx_store"`
+	Store string `json:"store" gorm:"not null;size:16;uniqueIndex:idx_account;index:idx_store"`
 	Name  string `json:"name" gorm:"size:64;index:idx_store"`
 
+	Nickname  *string `json:"nickname" gorm:"size:64"`
+	Favourite bool    `json:"favourite" gorm:"default:false"`
+
 	MerchantId uint `json:"merchant_id" gorm:"not null;uniqueIndex:idx_account"`
 
 	Merchant Merchant `json:"-"`
 
 	ModelTimeStamps
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
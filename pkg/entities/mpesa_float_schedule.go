@@ -0,0 +1,40 @@
+package entities
+
+import "time"
+
+type MpesaFloatSchedule struct {
+	ModelID
+
+	Amount float32 `json:"amount" gorm:"not null;type:decimal(10,2)"`
+	Days   string  `json:"days" gorm:"not null;size:32"` // Comma separated, MON..SUN
+	Time   string  `json:"time" gorm:"not null;size:5"`  // HH:MM
+
+	Status    string     `json:"status" gorm:"size:16;default:ACTIVE;index:idx_schedules_due"` // ACTIVE / PAUSED
+	NextRunAt *time.Time `json:"next_run_at" gorm:"index:idx_schedules_due"`
+	LastRunAt *time.Time `json:"last_run_at"`
+
+	StoreAccountId    uint  `json:"store_account_id" gorm:"not null;index"`
+	MerchantId        uint  `json:"merchant_id" gorm:"not null;index"`
+	OperatorAccountId *uint `json:"operator_account_id"`
+
+	StoreAccount MpesaAgentStoreAccount `json:"-"`
+	Merchant     Merchant               `json:"-"`
+
+	ModelTimeStamps
+}
+
+type MpesaFloatScheduleRun struct {
+	ModelID
+
+	ScheduledFor time.Time `json:"scheduled_for" gorm:"not null;uniqueIndex:idx_schedule_runs"`
+	Status       string    `json:"status" gorm:"size:16;default:PENDING"` // PENDING / SUBMITTED / FAILED / SKIPPED
+	Error        *string   `json:"error" gorm:"size:255"`
+
+	ScheduleId    uint  `json:"schedule_id" gorm:"not null;uniqueIndex:idx_schedule_runs"`
+	TransactionId *uint `json:"transaction_id" gorm:"index"`
+
+	Schedule    MpesaFloatSchedule `json:"-"`
+	Transaction *Transaction       `json:"-"`
+
+	ModelTimeStamps
+}
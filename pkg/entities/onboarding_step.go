@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+type OnboardingStep struct {
+	ModelID
+
+	Step     string  `json:"step" gorm:"not null;size:32;uniqueIndex:idx_onboarding_steps"`
+	Status   string  `json:"status" gorm:"size:16; default:PENDING"` // PENDING / COMPLETED / FAILED
+	Attempts int     `json:"attempts"`
+	Error    *string `json:"error" gorm:"size:255"`
+
+	MerchantId uint `json:"merchant_id" gorm:"not null;uniqueIndex:idx_onboarding_steps"`
+
+	Merchant Merchant `json:"-"`
+
+	CompletedAt *time.Time `json:"completed_at"`
+
+	ModelTimeStamps
+}
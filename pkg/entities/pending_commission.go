@@ -0,0 +1,28 @@
+package entities
+
+import "time"
+
+// PendingCommission is the cashback and inviter commission on a transaction, waiting to be paid to the account it was
+// earned on and shared up its referral tree. It is paid apart from completing the transaction, and retried with backoff
+// until it is PAID, or FAILED once it runs out of attempts.
+type PendingCommission struct {
+	ModelID
+
+	Amount float32 `json:"amount" gorm:"not null;type:decimal(7,2)"`
+	Saved  float32 `json:"saved" gorm:"not null;type:decimal(3,2)"` // portion of each share that is saved
+	Status string  `json:"status" gorm:"not null;size:16;default:PENDING;index:idx_pending_commissions"`
+
+	Cashback     float32 `json:"cashback" gorm:"not null;default:0;type:decimal(7,2)"`
+	CashbackType string  `json:"cashback_type" gorm:"size:16"` // earning account it is paid into, CASHBACK / COMMISSION
+
+	Attempts      int       `json:"attempts" gorm:"not null;default:0"`
+	Error         string    `json:"error" gorm:"size:255"`
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"not null;index:idx_pending_commissions"`
+
+	TransactionId uint `json:"transaction_id" gorm:"not null;uniqueIndex"`
+	AccountId     uint `json:"account_id" gorm:"not null"`
+
+	Transaction Transaction `json:"-"`
+
+	ModelTimeStamps
+}
@@ -8,9 +8,11 @@ type Transaction struct {
 	Description string  `json:"description" gorm:"size:64"`
 
 	Destination *string `json:"destination" gorm:"size:64"`
-	MerchantId  uint    `json:"merchant_id" gorm:"not null"`
+	MerchantId  uint    `json:"merchant_id" gorm:"not null;index"`
 	Product     string  `json:"product" gorm:"not null;size:32"`
 
+	OperatorAccountId *uint `json:"operator_account_id" gorm:"index"`
+
 	Merchant Merchant `json:"-"`
 	Payment  *Payment `json:"payment"`
 
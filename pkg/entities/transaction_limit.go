@@ -0,0 +1,20 @@
+package entities
+
+// TransactionLimit holds a tier's default limits for a product when MerchantId is 0, or a merchant's overrides of
+// them otherwise. A nil limit is unlimited on a tier default, and falls back to the tier on an override.
+type TransactionLimit struct {
+	ModelID
+
+	Tier       string `json:"tier" gorm:"not null;size:16;default:'';uniqueIndex:idx_transaction_limits"`
+	MerchantId uint   `json:"merchant_id" gorm:"not null;default:0;uniqueIndex:idx_transaction_limits"`
+	Product    string `json:"product" gorm:"not null;size:32;uniqueIndex:idx_transaction_limits"`
+
+	MinAmount     *float32 `json:"min_amount" gorm:"type:decimal(10,2)"`
+	MaxAmount     *float32 `json:"max_amount" gorm:"type:decimal(10,2)"`
+	DailyAmount   *float32 `json:"daily_amount" gorm:"type:decimal(12,2)"`
+	MonthlyAmount *float32 `json:"monthly_amount" gorm:"type:decimal(12,2)"`
+	DailyCount    *int     `json:"daily_count"`
+	MonthlyCount  *int     `json:"monthly_count"`
+
+	ModelTimeStamps
+}
@@ -18,4 +18,46 @@ var (
 	ErrUnauthorizedMfa = errors.New("missing 2FA")
 
 	ErrServerError = errors.New("something went wrong")
+
+	ErrMerchantNotActive = errors.New("merchant account is not active")
+
+	ErrInvalidStatusTransition = errors.New("merchant status transition is not allowed")
+
+	ErrInvalidMerchantCode = errors.New("merchant code is invalid")
+
+	ErrMerchantCodeInUse = errors.New("merchant code is linked to a float account")
+
+	ErrInvalidQRPayload = errors.New("qr payload is invalid")
+
+	ErrOnboardingStepFailed = errors.New("merchant onboarding step failed")
+
+	ErrOnboardingInputRequired = errors.New("merchant onboarding requires more details")
+
+	ErrInvalidDocument = errors.New("document is invalid")
+
+	ErrDocumentReviewed = errors.New("document has already been reviewed")
+
+	ErrLimitExceeded = errors.New("transaction limit exceeded")
+
+	ErrOperatorNotPermitted = errors.New("operator is not permitted to perform this action")
+
+	ErrAdminRequired = errors.New("this action requires an admin")
+
+	ErrInvalidPreviewToken = errors.New("transfer preview is invalid or has expired")
+
+	ErrInvalidStatementPeriod = errors.New("statement period is invalid")
+
+	ErrInvalidImportFile = errors.New("import file is invalid")
+
+	ErrImportNotResumable = errors.New("import cannot be resumed")
+
+	ErrInvalidMpesaStore = errors.New("mpesa agent store is invalid")
+
+	ErrMpesaStoreExists = errors.New("mpesa agent store is already saved")
+
+	ErrNoPreviousPurchase = errors.New("there is no previous purchase to repeat")
+
+	ErrInvalidSchedule = errors.New("purchase schedule is invalid")
+
+	ErrInvalidCommissionPlan = errors.New("commission plan is invalid")
 )
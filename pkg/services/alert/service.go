@@ -0,0 +1,262 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"log/slog"
+	"merchants.sidooh/pkg/cache"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	PaymentNotPending        Type = "PAYMENT_NOT_PENDING"
+	EarningsInvestmentFailed Type = "EARNINGS_INVESTMENT_FAILED"
+)
+
+type Severity int
+
+const (
+	INFO Severity = iota
+	WARNING
+	CRITICAL
+)
+
+func (s Severity) String() string {
+	switch s {
+	case WARNING:
+		return "WARNING"
+	case CRITICAL:
+		return "CRITICAL"
+	default:
+		return "INFO"
+	}
+}
+
+// level is the log level alerts of the severity are logged at.
+func (s Severity) level() slog.Level {
+	switch s {
+	case WARNING:
+		return slog.LevelWarn
+	case CRITICAL:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func ParseSeverity(value string, fallback Severity) Severity {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "INFO":
+		return INFO
+	case "WARNING":
+		return WARNING
+	case "CRITICAL":
+		return CRITICAL
+	default:
+		return fallback
+	}
+}
+
+const (
+	SMS     = "SMS"
+	MAIL    = "MAIL"
+	WEBHOOK = "WEBHOOK"
+)
+
+type Alert struct {
+	Type     Type
+	Severity Severity
+	Message  string
+
+	// Key identifies the incident, e.g. a payment id. Alerts with the same type and key are sent once per dedup window,
+	// an empty key groups all alerts of the type together.
+	Key string
+
+	Context map[string]interface{}
+}
+
+type Service interface {
+	Raise(alert Alert) error
+}
+
+type sender interface {
+	SendSMS(event, phone, message string) error
+	SendMail(event, email, message string) error
+	PostWebhook(url string, payload interface{}) error
+}
+
+type clientSender struct {
+	notifyApi  *clients.ApiClient
+	webhookApi *clients.ApiClient
+}
+
+func (c *clientSender) SendSMS(event, phone, message string) error {
+	return c.notifyApi.SendSMS(event, phone, message)
+}
+
+func (c *clientSender) SendMail(event, email, message string) error {
+	return c.notifyApi.SendMail(event, email, message)
+}
+
+func (c *clientSender) PostWebhook(url string, payload interface{}) error {
+	return c.webhookApi.PostWebhook(url, payload)
+}
+
+type service struct {
+	sender sender
+	sent   cache.ICache[string, time.Time]
+
+	mu     sync.Mutex
+	window map[Type][]time.Time
+}
+
+func (s *service) Raise(alert Alert) error {
+	logger.ClientLog.Log(context.Background(), alert.Severity.level(), alert.Message, "alert", alert.Type, "severity", alert.Severity.String(), "key", alert.Key, "context", alert.Context)
+
+	if reason := s.admit(alert); reason != "" {
+		logger.ClientLog.Info("alert suppressed: "+reason, "alert", alert.Type, "key", alert.Key)
+		return nil
+	}
+
+	message := fmt.Sprintf("[%s] %s", alert.Severity, alert.Message)
+
+	var errs []string
+	if alert.Severity >= minSeverity(SMS, WARNING) {
+		for _, phone := range recipients(alert.Type, SMS) {
+			if err := s.sender.SendSMS("DEFAULT", phone, message); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if alert.Severity >= minSeverity(MAIL, INFO) {
+		for _, email := range recipients(alert.Type, MAIL) {
+			if err := s.sender.SendMail("DEFAULT", email, message); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if alert.Severity >= minSeverity(WEBHOOK, INFO) {
+		for _, url := range recipients(alert.Type, WEBHOOK) {
+			err := s.sender.PostWebhook(url, map[string]interface{}{
+				"service":  viper.GetString("SERVICE"),
+				"type":     alert.Type,
+				"severity": alert.Severity.String(),
+				"key":      alert.Key,
+				"text":     message,
+				"context":  alert.Context,
+			})
+			if err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to deliver alert %s: %s", alert.Type, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// admit returns why the alert should not be delivered, or an empty string if it should. The dedup key is only recorded
+// for alerts that pass the rate limit, so an alert dropped by the limit is delivered when it is raised again.
+func (s *service) admit(alert Alert) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := string(alert.Type) + ":" + alert.Key
+	if s.sent.Get(key) != nil {
+		return "duplicate"
+	}
+	if s.isRateLimited(alert) {
+		return "rate limited"
+	}
+
+	s.sent.Set(key, time.Now(), dedupWindow())
+	return ""
+}
+
+// isRateLimited records the alert in its type's window unless the window is full, the caller holds the lock.
+func (s *service) isRateLimited(alert Alert) bool {
+	limit := viper.GetInt("ALERT_RATE_LIMIT")
+	if limit <= 0 {
+		limit = 10
+	}
+
+	cutoff := time.Now().Add(-rateWindow())
+	var recent []time.Time
+	for _, t := range s.window[alert.Type] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= limit {
+		s.window[alert.Type] = recent
+		return true
+	}
+
+	s.window[alert.Type] = append(recent, time.Now())
+	return false
+}
+
+// recipients reads ALERT_<TYPE>_<CHANNEL>_RECIPIENTS, falling back to ALERT_<CHANNEL>_RECIPIENTS.
+// Values are comma separated; the webhook channel takes urls.
+func recipients(alertType Type, channel string) []string {
+	value := viper.GetString(fmt.Sprintf("ALERT_%s_%s_RECIPIENTS", alertType, channel))
+	if value == "" {
+		value = viper.GetString(fmt.Sprintf("ALERT_%s_RECIPIENTS", channel))
+	}
+
+	var list []string
+	for _, recipient := range strings.Split(value, ",") {
+		if recipient = strings.TrimSpace(recipient); recipient != "" {
+			list = append(list, recipient)
+		}
+	}
+
+	return list
+}
+
+func minSeverity(channel string, fallback Severity) Severity {
+	return ParseSeverity(viper.GetString(fmt.Sprintf("ALERT_%s_SEVERITY", channel)), fallback)
+}
+
+func dedupWindow() time.Duration {
+	minutes := viper.GetInt("ALERT_DEDUP_WINDOW")
+	if minutes <= 0 {
+		minutes = 30
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+func rateWindow() time.Duration {
+	minutes := viper.GetInt("ALERT_RATE_WINDOW")
+	if minutes <= 0 {
+		minutes = 60
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+func newService(sender sender) *service {
+	return &service{
+		sender: sender,
+		sent:   cache.New[string, time.Time](),
+		window: map[Type][]time.Time{},
+	}
+}
+
+func NewService() Service {
+	return newService(&clientSender{notifyApi: clients.GetNotifyClient(), webhookApi: clients.GetWebhookClient()})
+}
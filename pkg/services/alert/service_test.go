@@ -0,0 +1,120 @@
+package alert
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type fakeSender struct {
+	sms      []string
+	mails    []string
+	webhooks []string
+}
+
+func (f *fakeSender) SendSMS(event, phone, message string) error {
+	f.sms = append(f.sms, phone)
+	return nil
+}
+
+func (f *fakeSender) SendMail(event, email, message string) error {
+	f.mails = append(f.mails, email)
+	return nil
+}
+
+func (f *fakeSender) PostWebhook(url string, payload interface{}) error {
+	f.webhooks = append(f.webhooks, url)
+	return nil
+}
+
+func setRecipients(t *testing.T) {
+	viper.Set("ALERT_SMS_RECIPIENTS", "0700000000, 0711111111")
+	viper.Set("ALERT_MAIL_RECIPIENTS", "ops@test.test")
+	viper.Set("ALERT_WEBHOOK_RECIPIENTS", "https://hooks.test/alert")
+	t.Cleanup(func() {
+		viper.Set("ALERT_SMS_RECIPIENTS", "")
+		viper.Set("ALERT_MAIL_RECIPIENTS", "")
+		viper.Set("ALERT_WEBHOOK_RECIPIENTS", "")
+		viper.Set("ALERT_RATE_LIMIT", 0)
+	})
+}
+
+func TestRaise_SeverityRouting(t *testing.T) {
+	setRecipients(t)
+
+	sender := &fakeSender{}
+	srv := newService(sender)
+
+	assert.NoError(t, srv.Raise(Alert{Type: PaymentNotPending, Severity: INFO, Message: "info", Key: "1"}))
+	assert.Empty(t, sender.sms, "info alerts should not be sent by sms")
+	assert.Equal(t, []string{"ops@test.test"}, sender.mails)
+	assert.Equal(t, []string{"https://hooks.test/alert"}, sender.webhooks)
+
+	assert.NoError(t, srv.Raise(Alert{Type: PaymentNotPending, Severity: CRITICAL, Message: "critical", Key: "2"}))
+	assert.Equal(t, []string{"0700000000", "0711111111"}, sender.sms)
+}
+
+func TestRaise_Deduplication(t *testing.T) {
+	setRecipients(t)
+
+	sender := &fakeSender{}
+	srv := newService(sender)
+
+	for i := 0; i < 5; i++ {
+		_ = srv.Raise(Alert{Type: PaymentNotPending, Severity: WARNING, Message: "dup", Key: "1"})
+	}
+	assert.Len(t, sender.mails, 1)
+
+	_ = srv.Raise(Alert{Type: PaymentNotPending, Severity: WARNING, Message: "other", Key: "2"})
+	assert.Len(t, sender.mails, 2)
+}
+
+func TestRaise_RateLimit(t *testing.T) {
+	setRecipients(t)
+	viper.Set("ALERT_RATE_LIMIT", 3)
+
+	sender := &fakeSender{}
+	srv := newService(sender)
+
+	for _, key := range []string{"1", "2", "3", "4", "5"} {
+		_ = srv.Raise(Alert{Type: PaymentNotPending, Severity: WARNING, Message: "incident", Key: key})
+	}
+	assert.Len(t, sender.mails, 3)
+
+	_ = srv.Raise(Alert{Type: EarningsInvestmentFailed, Severity: WARNING, Message: "incident"})
+	assert.Len(t, sender.mails, 4, "rate limit is per alert type")
+}
+
+func TestRaise_RateLimitedAlertNotDeduplicated(t *testing.T) {
+	setRecipients(t)
+	viper.Set("ALERT_RATE_LIMIT", 1)
+
+	sender := &fakeSender{}
+	srv := newService(sender)
+
+	_ = srv.Raise(Alert{Type: PaymentNotPending, Severity: WARNING, Message: "incident", Key: "1"})
+	_ = srv.Raise(Alert{Type: PaymentNotPending, Severity: WARNING, Message: "incident", Key: "2"})
+	assert.Len(t, sender.mails, 1)
+
+	// Once the rate window has passed, the alert that was dropped goes out when raised again
+	srv.window = map[Type][]time.Time{}
+	_ = srv.Raise(Alert{Type: PaymentNotPending, Severity: WARNING, Message: "incident", Key: "2"})
+	assert.Len(t, sender.mails, 2)
+}
+
+func TestSeverity_Level(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, INFO.level())
+	assert.Equal(t, slog.LevelWarn, WARNING.level())
+	assert.Equal(t, slog.LevelError, CRITICAL.level())
+}
+
+func TestRecipients_TypeOverride(t *testing.T) {
+	setRecipients(t)
+	viper.Set("ALERT_EARNINGS_INVESTMENT_FAILED_SMS_RECIPIENTS", "0722222222")
+	t.Cleanup(func() { viper.Set("ALERT_EARNINGS_INVESTMENT_FAILED_SMS_RECIPIENTS", "") })
+
+	assert.Equal(t, []string{"0722222222"}, recipients(EarningsInvestmentFailed, SMS))
+	assert.Equal(t, []string{"0700000000", "0711111111"}, recipients(PaymentNotPending, SMS))
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"io"
+	"merchants.sidooh/api/presenter"
+	"slices"
+	"strconv"
+)
+
+// WriteCSV writes a row per group with a count and amount column for every product seen in the distribution.
+func WriteCSV(w io.Writer, distribution *presenter.MerchantDistribution) error {
+	var products []string
+	for _, group := range distribution.Groups {
+		for _, product := range group.Products {
+			if !slices.Contains(products, product.Product) {
+				products = append(products, product.Product)
+			}
+		}
+	}
+	slices.Sort(products)
+
+	writer := csv.NewWriter(w)
+
+	header := []string{distribution.Level + "_id", distribution.Level, "merchants", "active_merchants", "transactions", "amount", "latitude", "longitude"}
+	for _, product := range products {
+		header = append(header, product+"_count", product+"_amount")
+	}
+	_ = writer.Write(header)
+
+	for _, group := range distribution.Groups {
+		record := []string{
+			strconv.Itoa(group.Id),
+			group.Name,
+			strconv.Itoa(group.Merchants),
+			strconv.Itoa(group.ActiveMerchants),
+			strconv.Itoa(group.Transactions),
+			amount(group.Amount),
+			coordinate(group.Latitude),
+			coordinate(group.Longitude),
+		}
+		for _, product := range products {
+			count, total := "0", "0.00"
+			for _, statistic := range group.Products {
+				if statistic.Product == product {
+					count, total = strconv.Itoa(statistic.Count), amount(statistic.Amount)
+				}
+			}
+			record = append(record, count, total)
+		}
+		_ = writer.Write(record)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// GeoJSON places each group at the centre of its landmarks, groups without coordinates have a null geometry.
+func GeoJSON(distribution *presenter.MerchantDistribution) presenter.FeatureCollection {
+	collection := presenter.FeatureCollection{Type: "FeatureCollection", Features: []presenter.Feature{}}
+
+	for _, group := range distribution.Groups {
+		feature := presenter.Feature{Type: "Feature", Properties: group}
+		if group.Latitude != nil && group.Longitude != nil {
+			// GeoJSON positions are longitude first
+			feature.Geometry = &presenter.Geometry{Type: "Point", Coordinates: []float64{*group.Longitude, *group.Latitude}}
+		}
+
+		collection.Features = append(collection.Features, feature)
+	}
+
+	return collection
+}
+
+func amount(value float32) string {
+	return strconv.FormatFloat(float64(value), 'f', 2, 32)
+}
+
+func coordinate(value *float64) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*value, 'f', 6, 64)
+}
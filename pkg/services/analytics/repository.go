@@ -0,0 +1,123 @@
+package analytics
+
+import (
+	"gorm.io/gorm"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadMerchantCounts(filters Filters) ([]GroupCount, error)
+	ReadActiveMerchantCounts(filters Filters, since time.Time) ([]GroupCount, error)
+	ReadProductTotals(filters Filters, since time.Time) ([]GroupProductTotal, error)
+	ReadCentres(filters Filters) ([]GroupCentre, error)
+}
+type repository struct {
+}
+
+// Filters picks the level merchants are grouped by, optionally within a county or sub-county.
+type Filters struct {
+	Level       string
+	CountyId    int
+	SubCountyId int
+}
+
+type GroupCount struct {
+	Id    int
+	Name  string
+	Count int
+}
+
+type GroupProductTotal struct {
+	Id      int
+	Product string
+	Count   int
+	Amount  float32
+}
+
+type GroupCentre struct {
+	Id        int
+	Latitude  float64
+	Longitude float64
+}
+
+// levels maps each level to its id and name columns, merchants without a location are grouped under id 0.
+var levels = map[string][2]string{
+	consts.LEVEL_COUNTY:     {"locations.county_id", "locations.county"},
+	consts.LEVEL_SUB_COUNTY: {"locations.sub_county_id", "locations.sub_county"},
+	consts.LEVEL_WARD:       {"locations.ward_id", "locations.ward"},
+}
+
+func (r *repository) ReadMerchantCounts(filters Filters) (counts []GroupCount, err error) {
+	columns := levels[filters.Level]
+	err = merchants(filters).
+		Select("COALESCE(" + columns[0] + ", 0) AS id, COALESCE(" + columns[1] + ", '') AS name, COUNT(merchants.id) AS count").
+		Group(columns[0]).
+		Group(columns[1]). // needed for mysql aggregation in sql_mode=only_full_group_by
+		Scan(&counts).Error
+	return
+}
+
+// ReadActiveMerchantCounts counts the merchants with completed transactions since the given time.
+func (r *repository) ReadActiveMerchantCounts(filters Filters, since time.Time) (counts []GroupCount, err error) {
+	columns := levels[filters.Level]
+	err = merchants(filters).
+		Select("COALESCE("+columns[0]+", 0) AS id, COUNT(DISTINCT merchants.id) AS count").
+		Joins("JOIN transactions ON transactions.merchant_id = merchants.id").
+		Where("transactions.status = ?", "COMPLETED").
+		Where("transactions.created_at >= ?", since).
+		Group(columns[0]).
+		Scan(&counts).Error
+	return
+}
+
+func (r *repository) ReadProductTotals(filters Filters, since time.Time) (totals []GroupProductTotal, err error) {
+	columns := levels[filters.Level]
+	err = merchants(filters).
+		Select("COALESCE("+columns[0]+", 0) AS id, transactions.product, COUNT(*) AS count, COALESCE(SUM(transactions.amount), 0) AS amount").
+		Joins("JOIN transactions ON transactions.merchant_id = merchants.id").
+		Where("transactions.status = ?", "COMPLETED").
+		Where("transactions.created_at >= ?", since).
+		Group(columns[0]).
+		Group("transactions.product").
+		Order("transactions.product").
+		Scan(&totals).Error
+	return
+}
+
+// ReadCentres averages the coordinates of each group's landmarks, groups without coordinates are left out.
+func (r *repository) ReadCentres(filters Filters) (centres []GroupCentre, err error) {
+	columns := levels[filters.Level]
+	err = scope(datastore.DB.Model(&entities.Location{}), filters).
+		Select(columns[0] + " AS id, AVG(locations.latitude) AS latitude, AVG(locations.longitude) AS longitude").
+		Where("locations.latitude IS NOT NULL AND locations.longitude IS NOT NULL").
+		Group(columns[0]).
+		Scan(&centres).Error
+	return
+}
+
+func merchants(filters Filters) *gorm.DB {
+	query := datastore.DB.Model(&entities.Merchant{}).
+		Joins("LEFT JOIN locations ON locations.id = merchants.location_id")
+
+	return scope(query, filters)
+}
+
+func scope(query *gorm.DB, filters Filters) *gorm.DB {
+	if filters.CountyId > 0 {
+		query = query.Where("locations.county_id = ?", filters.CountyId)
+	}
+	if filters.SubCountyId > 0 {
+		query = query.Where("locations.sub_county_id = ?", filters.SubCountyId)
+	}
+
+	return query
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"cmp"
+	"merchants.sidooh/api/presenter"
+	"slices"
+	"time"
+)
+
+type Service interface {
+	GetMerchantDistribution(filters Filters, days int) (*presenter.MerchantDistribution, error)
+}
+
+const unassigned = "Unassigned"
+
+type service struct {
+	repository Repository
+
+	now func() time.Time
+}
+
+// GetMerchantDistribution counts merchants by location along with those active and their volume over the last days,
+// largest groups first.
+func (s *service) GetMerchantDistribution(filters Filters, days int) (*presenter.MerchantDistribution, error) {
+	now := s.now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := today.AddDate(0, 0, 1-days)
+
+	counts, err := s.repository.ReadMerchantCounts(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[int]*presenter.DistributionGroup{}
+	for _, count := range counts {
+		name := count.Name
+		if count.Id == 0 {
+			name = unassigned
+		}
+		groups[count.Id] = &presenter.DistributionGroup{Id: count.Id, Name: name, Merchants: count.Count, Products: []presenter.ProductStatistic{}}
+	}
+
+	active, err := s.repository.ReadActiveMerchantCounts(filters, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range active {
+		if group, ok := groups[count.Id]; ok {
+			group.ActiveMerchants = count.Count
+		}
+	}
+
+	totals, err := s.repository.ReadProductTotals(filters, since)
+	if err != nil {
+		return nil, err
+	}
+	for _, total := range totals {
+		if group, ok := groups[total.Id]; ok {
+			group.Transactions += total.Count
+			group.Amount += total.Amount
+			group.Products = append(group.Products, presenter.ProductStatistic{Product: total.Product, Count: total.Count, Amount: total.Amount})
+		}
+	}
+
+	centres, err := s.repository.ReadCentres(filters)
+	if err != nil {
+		return nil, err
+	}
+	for _, centre := range centres {
+		if group, ok := groups[centre.Id]; ok {
+			group.Latitude, group.Longitude = &centre.Latitude, &centre.Longitude
+		}
+	}
+
+	distribution := &presenter.MerchantDistribution{
+		Level:       filters.Level,
+		Days:        days,
+		Since:       since,
+		Groups:      make([]presenter.DistributionGroup, 0, len(groups)),
+		GeneratedAt: now,
+	}
+	for _, group := range groups {
+		distribution.Groups = append(distribution.Groups, *group)
+	}
+	slices.SortFunc(distribution.Groups, func(a, b presenter.DistributionGroup) int {
+		if c := cmp.Compare(b.Merchants, a.Merchants); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+
+	return distribution, nil
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		repository: r,
+
+		now: time.Now,
+	}
+}
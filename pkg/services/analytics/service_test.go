@@ -0,0 +1,90 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/utils/consts"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	since time.Time
+}
+
+func (f *fakeRepository) ReadMerchantCounts(Filters) ([]GroupCount, error) {
+	return []GroupCount{{Id: 0, Count: 4}, {Id: 1, Name: "Mombasa", Count: 3}, {Id: 47, Name: "Nairobi", Count: 10}}, nil
+}
+
+func (f *fakeRepository) ReadActiveMerchantCounts(_ Filters, since time.Time) ([]GroupCount, error) {
+	f.since = since
+	return []GroupCount{{Id: 47, Count: 6}, {Id: 1, Count: 1}}, nil
+}
+
+func (f *fakeRepository) ReadProductTotals(Filters, time.Time) ([]GroupProductTotal, error) {
+	return []GroupProductTotal{
+		{Id: 47, Product: consts.CASH_WITHDRAW, Count: 20, Amount: 10000},
+		{Id: 47, Product: consts.MPESA_FLOAT, Count: 5, Amount: 25000},
+		{Id: 1, Product: consts.CASH_WITHDRAW, Count: 2, Amount: 700},
+	}, nil
+}
+
+func (f *fakeRepository) ReadCentres(Filters) ([]GroupCentre, error) {
+	return []GroupCentre{{Id: 47, Latitude: -1.28, Longitude: 36.82}}, nil
+}
+
+func newTestService(repository *fakeRepository) Service {
+	return &service{
+		repository: repository,
+		now:        func() time.Time { return time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) },
+	}
+}
+
+func TestService_GetMerchantDistribution(t *testing.T) {
+	repository := &fakeRepository{}
+	distribution, err := newTestService(repository).GetMerchantDistribution(Filters{Level: consts.LEVEL_COUNTY}, 30)
+	assert.NoError(t, err)
+
+	// The last 30 days include today
+	assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), repository.since)
+
+	assert.Len(t, distribution.Groups, 3)
+	nairobi := distribution.Groups[0]
+	assert.Equal(t, "Nairobi", nairobi.Name)
+	assert.Equal(t, 6, nairobi.ActiveMerchants)
+	assert.Equal(t, 25, nairobi.Transactions)
+	assert.Equal(t, float32(35000), nairobi.Amount)
+	assert.Len(t, nairobi.Products, 2)
+	assert.Equal(t, -1.28, *nairobi.Latitude)
+
+	assert.Equal(t, unassigned, distribution.Groups[1].Name)
+	assert.Zero(t, distribution.Groups[1].ActiveMerchants)
+	assert.Empty(t, distribution.Groups[1].Products)
+	assert.Nil(t, distribution.Groups[1].Latitude)
+}
+
+func TestWriteCSV(t *testing.T) {
+	distribution, _ := newTestService(&fakeRepository{}).GetMerchantDistribution(Filters{Level: consts.LEVEL_COUNTY}, 30)
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteCSV(&out, distribution))
+
+	records, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+	assert.Equal(t, []string{"county_id", "county", "merchants", "active_merchants", "transactions", "amount", "latitude", "longitude",
+		consts.CASH_WITHDRAW + "_count", consts.CASH_WITHDRAW + "_amount", consts.MPESA_FLOAT + "_count", consts.MPESA_FLOAT + "_amount"}, records[0])
+	assert.Equal(t, []string{"47", "Nairobi", "10", "6", "25", "35000.00", "-1.280000", "36.820000", "20", "10000.00", "5", "25000.00"}, records[1])
+	assert.Equal(t, []string{"1", "Mombasa", "3", "1", "2", "700.00", "", "", "2", "700.00", "0", "0.00"}, records[3])
+}
+
+func TestGeoJSON(t *testing.T) {
+	distribution, _ := newTestService(&fakeRepository{}).GetMerchantDistribution(Filters{Level: consts.LEVEL_COUNTY}, 30)
+
+	collection := GeoJSON(distribution)
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	assert.Len(t, collection.Features, 3)
+	assert.Equal(t, []float64{36.82, -1.28}, collection.Features[0].Geometry.Coordinates)
+	assert.Nil(t, collection.Features[1].Geometry)
+}
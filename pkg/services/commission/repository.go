@@ -0,0 +1,111 @@
+package commission
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadPlans() ([]entities.CommissionPlan, error)
+	ReadPlan(product string) (*entities.CommissionPlan, error)
+	SavePlan(plan *entities.CommissionPlan) (*entities.CommissionPlan, error)
+	ReadEarned(accountId uint, product string, since time.Time) (float32, error)
+
+	CreatePending(data *entities.PendingCommission) (*entities.PendingCommission, error)
+	ReadDuePending(before time.Time, limit int) ([]entities.PendingCommission, error)
+	ClaimPending(id uint, from, until time.Time) (bool, error)
+	UpdatePending(data *entities.PendingCommission) error
+}
+type repository struct {
+}
+
+func (r *repository) ReadPlans() (plans []entities.CommissionPlan, err error) {
+	err = datastore.DB.Order("product").Find(&plans).Error
+	return
+}
+
+func (r *repository) ReadPlan(product string) (plan *entities.CommissionPlan, err error) {
+	err = datastore.DB.Where("product", product).First(&plan).Error
+	return
+}
+
+// SavePlan inserts or replaces the product's plan.
+func (r *repository) SavePlan(plan *entities.CommissionPlan) (*entities.CommissionPlan, error) {
+	existing, err := r.ReadPlan(plan.Product)
+	if err == nil {
+		plan.Id = existing.Id
+		plan.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err = datastore.DB.Save(plan).Error; err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// ReadEarned sums the invite commissions the account has earned on transactions of a product since the given time.
+func (r *repository) ReadEarned(accountId uint, product string, since time.Time) (earned float32, err error) {
+	err = datastore.DB.Model(&entities.Earning{}).
+		Select("COALESCE(SUM(earnings.amount), 0)").
+		Joins("JOIN transactions ON transactions.id = earnings.transaction_id").
+		Where("earnings.account_id", accountId).
+		Where("earnings.type", "INVITE").
+		Where("transactions.product", product).
+		Where("earnings.created_at >= ?", since).
+		Scan(&earned).Error
+	return
+}
+
+// CreatePending records the commission on a transaction once. A retried completion leaves the existing record as is,
+// and gets nil back.
+func (r *repository) CreatePending(data *entities.PendingCommission) (*entities.PendingCommission, error) {
+	result := datastore.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&data)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	return data, nil
+}
+
+func (r *repository) ReadDuePending(before time.Time, limit int) (results []entities.PendingCommission, err error) {
+	err = datastore.DB.
+		Preload("Transaction").
+		Where("status", consts.COMMISSION_PENDING).
+		Where("next_attempt_at <= ?", before).
+		Order("next_attempt_at, id").
+		Limit(limit).
+		Find(&results).Error
+	return
+}
+
+// ClaimPending moves the commission's next attempt to until if it is still due at from, so only one worker processes
+// an attempt. A worker that dies mid-attempt leaves it to be retried at until.
+func (r *repository) ClaimPending(id uint, from, until time.Time) (bool, error) {
+	result := datastore.DB.Model(&entities.PendingCommission{}).
+		Where("id", id).
+		Where("status", consts.COMMISSION_PENDING).
+		Where("next_attempt_at = ?", from).
+		Update("next_attempt_at", until)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) UpdatePending(data *entities.PendingCommission) error {
+	return datastore.DB.Model(data).
+		Select("status", "attempts", "error", "next_attempt_at").
+		Updates(data).Error
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
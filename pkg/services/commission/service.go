@@ -0,0 +1,279 @@
+package commission
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"math"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/utils/consts"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	GetPlans() (*[]presenter.CommissionPlan, error)
+	SetPlan(plan *presenter.CommissionPlan) (*presenter.CommissionPlan, error)
+
+	Split(accountId uint, product string, commission float32) (*Split, error)
+}
+
+// Products that pay inviter commissions.
+var Products = []string{
+	consts.MPESA_FLOAT,
+	consts.CASH_WITHDRAW,
+}
+
+// defaultRates pays the full commission to each of the first two inviters, as was done before plans were configurable.
+var defaultRates = []float32{1, 1}
+
+// Split is how a commission is shared up an account's referral tree. Chain is the inviter account ids it was shared
+// from, comma separated from the direct inviter up.
+type Split struct {
+	Chain  string
+	Shares []Share
+}
+
+type Share struct {
+	AccountId uint
+	Level     uint
+	Amount    float32
+}
+
+type service struct {
+	repository Repository
+
+	inviters func(accountId uint, levels int) ([]clients.Account, error)
+	now      func() time.Time
+}
+
+// GetPlans returns the plan every commission product is paid by, including those left on the default.
+func (s *service) GetPlans() (*[]presenter.CommissionPlan, error) {
+	saved, err := s.repository.ReadPlans()
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []presenter.CommissionPlan{}
+	for _, product := range Products {
+		plan := &entities.CommissionPlan{Product: product, Rates: formatRates(defaultRates)}
+		for i := range saved {
+			if saved[i].Product == product {
+				plan = &saved[i]
+				break
+			}
+		}
+
+		result, err := toPresenter(plan)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *result)
+	}
+
+	return &plans, nil
+}
+
+func (s *service) SetPlan(plan *presenter.CommissionPlan) (*presenter.CommissionPlan, error) {
+	if len(plan.Rates) == 0 || len(plan.Rates) > maxDepth() {
+		return nil, fmt.Errorf("%w: between 1 and %d levels may be paid", pkg.ErrInvalidCommissionPlan, maxDepth())
+	}
+	for _, rate := range plan.Rates {
+		if rate <= 0 || rate > 1 {
+			return nil, fmt.Errorf("%w: rates must be more than 0 and at most 1", pkg.ErrInvalidCommissionPlan)
+		}
+	}
+
+	saved, err := s.repository.SavePlan(&entities.CommissionPlan{
+		Product:    plan.Product,
+		Rates:      formatRates(plan.Rates),
+		DailyCap:   plan.DailyCap,
+		MonthlyCap: plan.MonthlyCap,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toPresenter(saved)
+}
+
+// Split shares the commission on a product among the account's inviters by the product's plan, leaving out inviters
+// who have reached their caps. A share is rounded to cents, and shares that round to nothing are left out.
+func (s *service) Split(accountId uint, product string, commission float32) (*Split, error) {
+	split := &Split{}
+	if commission <= 0 {
+		return split, nil
+	}
+
+	plan, err := s.repository.ReadPlan(product)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		plan, err = &entities.CommissionPlan{Product: product, Rates: formatRates(defaultRates)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := parseRates(plan.Rates)
+	if err != nil {
+		return nil, err
+	}
+	if len(rates) > maxDepth() {
+		rates = rates[:maxDepth()]
+	}
+
+	ancestors, err := s.inviters(accountId, len(rates))
+	if err != nil {
+		return nil, err
+	}
+
+	inviters := chain(accountId, ancestors, len(rates))
+
+	ids := make([]string, len(inviters))
+	for i, inviter := range inviters {
+		ids[i] = strconv.Itoa(int(inviter))
+	}
+	split.Chain = strings.Join(ids, ",")
+
+	for i, inviter := range inviters {
+		amount, err := s.capped(plan, inviter, round(commission*rates[i]))
+		if err != nil {
+			return nil, err
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		split.Shares = append(split.Shares, Share{AccountId: inviter, Level: uint(i + 1), Amount: amount})
+	}
+
+	return split, nil
+}
+
+// capped reduces the amount to what the inviter may still earn on the plan's product within its caps.
+func (s *service) capped(plan *entities.CommissionPlan, accountId uint, amount float32) (float32, error) {
+	periods := []struct {
+		cap   *float32
+		since time.Time
+	}{
+		{plan.DailyCap, startOfDay(s.now())},
+		{plan.MonthlyCap, startOfMonth(s.now())},
+	}
+
+	for _, period := range periods {
+		if period.cap == nil {
+			continue
+		}
+
+		earned, err := s.repository.ReadEarned(accountId, plan.Product, period.since)
+		if err != nil {
+			return 0, err
+		}
+		amount = min(amount, round(*period.cap-earned))
+	}
+
+	return amount, nil
+}
+
+// chain walks the ancestors the accounts service returned, which start with the account itself, up to depth inviters.
+// It stops at a self-referral or a loop in the tree, so no account is paid twice on the same transaction.
+func chain(accountId uint, ancestors []clients.Account, depth int) []uint {
+	if len(ancestors) > 0 && uint(ancestors[0].Id) == accountId {
+		ancestors = ancestors[1:]
+	}
+
+	var inviters []uint
+	seen := map[uint]bool{accountId: true}
+	for _, ancestor := range ancestors {
+		if len(inviters) == depth {
+			break
+		}
+
+		id := uint(ancestor.Id)
+		if seen[id] {
+			logger.ClientLog.Warn("Inviter loop detected", "account", accountId, "inviter", id)
+			break
+		}
+
+		seen[id] = true
+		inviters = append(inviters, id)
+	}
+
+	return inviters
+}
+
+func toPresenter(plan *entities.CommissionPlan) (*presenter.CommissionPlan, error) {
+	rates, err := parseRates(plan.Rates)
+	if err != nil {
+		return nil, err
+	}
+
+	return &presenter.CommissionPlan{
+		Product:    plan.Product,
+		Rates:      rates,
+		DailyCap:   plan.DailyCap,
+		MonthlyCap: plan.MonthlyCap,
+	}, nil
+}
+
+func parseRates(value string) ([]float32, error) {
+	var rates []float32
+	for _, part := range strings.Split(value, ",") {
+		rate, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rate %q is not a number", pkg.ErrInvalidCommissionPlan, part)
+		}
+		rates = append(rates, float32(rate))
+	}
+
+	return rates, nil
+}
+
+func formatRates(rates []float32) string {
+	parts := make([]string, len(rates))
+	for i, rate := range rates {
+		parts[i] = strconv.FormatFloat(float64(rate), 'f', -1, 32)
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// maxDepth is the most levels of inviters a plan may pay.
+func maxDepth() int {
+	depth := viper.GetInt("COMMISSION_MAX_DEPTH")
+	if depth <= 0 {
+		return 5
+	}
+
+	return depth
+}
+
+func round(amount float32) float32 {
+	return float32(math.Round(float64(amount)*100) / 100)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func NewService(r Repository) Service {
+	accountsApi := clients.GetAccountClient()
+
+	return &service{
+		repository: r,
+
+		inviters: func(accountId uint, levels int) ([]clients.Account, error) {
+			return accountsApi.GetInviters(strconv.Itoa(int(accountId)), levels)
+		},
+		now: time.Now,
+	}
+}
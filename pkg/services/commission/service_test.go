@@ -0,0 +1,148 @@
+package commission
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	Repository
+
+	plans  map[string]*entities.CommissionPlan
+	earned map[uint]float32
+	since  []time.Time
+}
+
+func (f *fakeRepository) ReadPlans() (plans []entities.CommissionPlan, err error) {
+	for _, plan := range f.plans {
+		plans = append(plans, *plan)
+	}
+	return
+}
+
+func (f *fakeRepository) ReadPlan(product string) (*entities.CommissionPlan, error) {
+	plan, ok := f.plans[product]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return plan, nil
+}
+
+func (f *fakeRepository) SavePlan(plan *entities.CommissionPlan) (*entities.CommissionPlan, error) {
+	f.plans[plan.Product] = plan
+	return plan, nil
+}
+
+func (f *fakeRepository) ReadEarned(accountId uint, product string, since time.Time) (float32, error) {
+	f.since = append(f.since, since)
+	return f.earned[accountId], nil
+}
+
+func newTestService(ancestors ...int) (*service, *fakeRepository) {
+	repo := &fakeRepository{plans: map[string]*entities.CommissionPlan{}, earned: map[uint]float32{}}
+
+	return &service{
+		repository: repo,
+		inviters: func(accountId uint, levels int) ([]clients.Account, error) {
+			accounts := []clients.Account{{Id: int(accountId)}}
+			for _, id := range ancestors {
+				accounts = append(accounts, clients.Account{Id: id})
+			}
+			return accounts, nil
+		},
+		now: func() time.Time { return time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) },
+	}, repo
+}
+
+func TestService_Split_DefaultPlan(t *testing.T) {
+	s, _ := newTestService(2, 3, 4)
+
+	split, err := s.Split(1, consts.MPESA_FLOAT, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "2,3", split.Chain)
+	assert.Equal(t, []Share{{AccountId: 2, Level: 1, Amount: 3}, {AccountId: 3, Level: 2, Amount: 3}}, split.Shares)
+}
+
+func TestService_Split_PerLevelRates(t *testing.T) {
+	s, repo := newTestService(2, 3, 4, 5)
+	repo.plans[consts.CASH_WITHDRAW] = &entities.CommissionPlan{Product: consts.CASH_WITHDRAW, Rates: "0.5,0.3,0.15"}
+
+	split, err := s.Split(1, consts.CASH_WITHDRAW, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, "2,3,4", split.Chain)
+	assert.Equal(t, []Share{
+		{AccountId: 2, Level: 1, Amount: 5},
+		{AccountId: 3, Level: 2, Amount: 3},
+		{AccountId: 4, Level: 3, Amount: 1.5},
+	}, split.Shares)
+}
+
+func TestService_Split_Caps(t *testing.T) {
+	s, repo := newTestService(2, 3)
+	daily, monthly := float32(10), float32(50)
+	repo.plans[consts.MPESA_FLOAT] = &entities.CommissionPlan{Product: consts.MPESA_FLOAT, Rates: "1,1", DailyCap: &daily, MonthlyCap: &monthly}
+	repo.earned[2] = 8
+	repo.earned[3] = 10
+
+	split, err := s.Split(1, consts.MPESA_FLOAT, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "2,3", split.Chain)
+	assert.Equal(t, []Share{{AccountId: 2, Level: 1, Amount: 2}}, split.Shares)
+
+	assert.Contains(t, repo.since, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC))
+	assert.Contains(t, repo.since, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestService_Split_SelfReferralAndLoops(t *testing.T) {
+	s, repo := newTestService(2, 1, 3)
+	repo.plans[consts.MPESA_FLOAT] = &entities.CommissionPlan{Product: consts.MPESA_FLOAT, Rates: "1,1,1"}
+
+	split, err := s.Split(1, consts.MPESA_FLOAT, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "2", split.Chain)
+	assert.Len(t, split.Shares, 1)
+
+	s, repo = newTestService(2, 3, 2, 4)
+	repo.plans[consts.MPESA_FLOAT] = &entities.CommissionPlan{Product: consts.MPESA_FLOAT, Rates: "1,1,1"}
+
+	split, err = s.Split(1, consts.MPESA_FLOAT, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "2,3", split.Chain)
+	assert.Len(t, split.Shares, 2)
+
+	s, _ = newTestService()
+
+	split, err = s.Split(1, consts.MPESA_FLOAT, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "", split.Chain)
+	assert.Empty(t, split.Shares)
+}
+
+func TestService_SetPlan(t *testing.T) {
+	s, repo := newTestService()
+
+	_, err := s.SetPlan(&presenter.CommissionPlan{Product: consts.MPESA_FLOAT, Rates: []float32{1, 1, 1, 1, 1, 1}})
+	assert.ErrorIs(t, err, pkg.ErrInvalidCommissionPlan)
+
+	_, err = s.SetPlan(&presenter.CommissionPlan{Product: consts.MPESA_FLOAT, Rates: []float32{0.5, 0}})
+	assert.ErrorIs(t, err, pkg.ErrInvalidCommissionPlan)
+
+	plan, err := s.SetPlan(&presenter.CommissionPlan{Product: consts.MPESA_FLOAT, Rates: []float32{0.6, 0.25}})
+	assert.Nil(t, err)
+	assert.Equal(t, []float32{0.6, 0.25}, plan.Rates)
+	assert.Equal(t, "0.6,0.25", repo.plans[consts.MPESA_FLOAT].Rates)
+
+	plans, err := s.GetPlans()
+	assert.Nil(t, err)
+	assert.Equal(t, []presenter.CommissionPlan{
+		{Product: consts.MPESA_FLOAT, Rates: []float32{0.6, 0.25}},
+		{Product: consts.CASH_WITHDRAW, Rates: []float32{1, 1}},
+	}, *plans)
+}
@@ -0,0 +1,68 @@
+package dashboard
+
+import (
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadProductTotals(merchantId uint, since time.Time) ([]ProductTotal, error)
+	ReadEarnings(accountId uint, since time.Time) ([]EarningTotal, error)
+	ReadEarningBalances(accountId uint) ([]EarningTotal, error)
+}
+type repository struct {
+}
+
+type ProductTotal struct {
+	Product string
+	Count   int
+	Amount  float32
+}
+
+type EarningTotal struct {
+	Type   string
+	Amount float32
+}
+
+// ReadProductTotals counts and sums the merchant's completed transactions since the given time by product.
+func (r *repository) ReadProductTotals(merchantId uint, since time.Time) (totals []ProductTotal, err error) {
+	err = datastore.DB.Model(&entities.Transaction{}).
+		Select("product, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("merchant_id", merchantId).
+		Where("status", "COMPLETED").
+		Where("created_at >= ?", since).
+		Group("product").
+		Order("product").
+		Scan(&totals).Error
+	return
+}
+
+// ReadEarnings sums what was earned into the account's earning accounts since the given time by account type. Only
+// credits for earnings count, not those reversing a failed withdrawal.
+func (r *repository) ReadEarnings(accountId uint, since time.Time) (totals []EarningTotal, err error) {
+	err = datastore.DB.Table("earning_account_transactions").
+		Select("earning_accounts.type AS type, COALESCE(SUM(earning_account_transactions.amount), 0) AS amount").
+		Joins("JOIN earning_accounts ON earning_accounts.id = earning_account_transactions.earning_account_id").
+		Where("earning_accounts.account_id = ?", accountId).
+		Where("earning_account_transactions.type = ?", "CREDIT").
+		Where("earning_account_transactions.earning_id IS NOT NULL").
+		Where("earning_account_transactions.created_at >= ?", since).
+		Group("earning_accounts.type").
+		Scan(&totals).Error
+	return
+}
+
+func (r *repository) ReadEarningBalances(accountId uint) (totals []EarningTotal, err error) {
+	err = datastore.DB.Model(&entities.EarningAccount{}).
+		Select("type, amount").
+		Where("account_id", accountId).
+		Scan(&totals).Error
+	return
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
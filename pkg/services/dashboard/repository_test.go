@@ -0,0 +1,46 @@
+package dashboard
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRepository_ReadEarnings_IgnoresReversals(t *testing.T) {
+	viper.Set("APP_ENV", "TEST")
+	viper.Set("MIGRATE_DB", true)
+	datastore.Init()
+	t.Cleanup(func() { os.Remove("test.db") })
+
+	account := entities.EarningAccount{Type: "CASHBACK", AccountId: 10}
+	assert.Nil(t, datastore.DB.Create(&account).Error)
+
+	earningId, txId := uint(1), uint(5)
+	entry := func(txType string, amount float32, earningId *uint) entities.EarningAccountTransaction {
+		return entities.EarningAccountTransaction{Type: txType, Amount: amount, EarningAccountId: account.Id, TransactionId: &txId, EarningId: earningId}
+	}
+	entries := []entities.EarningAccountTransaction{
+		entry("CREDIT", 6, &earningId),
+		entry("DEBIT", 4.8, &earningId),
+	}
+	assert.Nil(t, datastore.DB.Create(&entries).Error)
+
+	r := NewRepo()
+	since := time.Now().Add(-time.Hour)
+	earned, err := r.ReadEarnings(10, since)
+	assert.Nil(t, err)
+	assert.Equal(t, []EarningTotal{{Type: "CASHBACK", Amount: 6}}, earned)
+
+	// A withdrawal that failed and was reversed
+	withdrawal := []entities.EarningAccountTransaction{entry("DEBIT", 1.2, nil), entry("CREDIT", 1.2, nil)}
+	withdrawal[1].Description = "Reversal - Earnings Withdrawal"
+	assert.Nil(t, datastore.DB.Create(&withdrawal).Error)
+
+	earned, err = r.ReadEarnings(10, since)
+	assert.Nil(t, err)
+	assert.Equal(t, []EarningTotal{{Type: "CASHBACK", Amount: 6}}, earned)
+}
@@ -0,0 +1,131 @@
+package dashboard
+
+import (
+	"github.com/spf13/viper"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/cache"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/merchant"
+	"strconv"
+	"time"
+)
+
+type Service interface {
+	GetDashboard(merchantId uint) (*presenter.Dashboard, error)
+}
+
+// floatAccounts is the part of the payments api the dashboard reads balances from.
+type floatAccounts interface {
+	FetchFloatAccount(id string) (*clients.FloatAccount, error)
+}
+
+type service struct {
+	repository         Repository
+	merchantRepository merchant.Repository
+
+	paymentsApi floatAccounts
+	cache       cache.ICache[uint, presenter.Dashboard]
+
+	now func() time.Time
+}
+
+// GetDashboard computes the merchant's figures, results are cached briefly since apps poll them on every visit.
+func (s *service) GetDashboard(merchantId uint) (*presenter.Dashboard, error) {
+	if cached := s.cache.Get(merchantId); cached != nil {
+		return cached, nil
+	}
+
+	merchant, err := s.merchantRepository.ReadMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	dashboard := presenter.Dashboard{EarningBalances: map[string]float32{}, GeneratedAt: now}
+
+	dashboard.Today, err = s.period(merchant.Id, merchant.AccountId, startOfDay(now))
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard.Month, err = s.period(merchant.Id, merchant.AccountId, startOfMonth(now))
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.repository.ReadEarningBalances(merchant.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range balances {
+		dashboard.EarningBalances[balance.Type] = balance.Amount
+	}
+
+	if merchant.FloatAccountId != 0 {
+		float, err := s.paymentsApi.FetchFloatAccount(strconv.Itoa(int(merchant.FloatAccountId)))
+		if err != nil || float == nil {
+			// The rest of the dashboard is still useful, it is just not cached so the balance is retried
+			logger.ClientLog.Error("failed to fetch dashboard float balance", "merchant", merchant.Id, "err", err)
+			return &dashboard, nil
+		}
+
+		balance := float32(float.Balance)
+		dashboard.FloatBalance = &balance
+	}
+
+	return s.cache.Set(merchantId, dashboard, cacheTTL()), nil
+}
+
+func (s *service) period(merchantId, accountId uint, since time.Time) (period presenter.DashboardPeriod, err error) {
+	period = presenter.DashboardPeriod{Since: since, Products: []presenter.ProductStatistic{}, Earnings: map[string]float32{}}
+
+	totals, err := s.repository.ReadProductTotals(merchantId, since)
+	if err != nil {
+		return
+	}
+	for _, total := range totals {
+		period.Count += total.Count
+		period.Amount += total.Amount
+		period.Products = append(period.Products, presenter.ProductStatistic{Product: total.Product, Count: total.Count, Amount: total.Amount})
+	}
+
+	earnings, err := s.repository.ReadEarnings(accountId, since)
+	if err != nil {
+		return
+	}
+	for _, earning := range earnings {
+		period.Earnings[earning.Type] = earning.Amount
+	}
+
+	return
+}
+
+func cacheTTL() time.Duration {
+	ttl := viper.GetDuration("DASHBOARD_CACHE_TTL")
+	if ttl <= 0 {
+		return 30 * time.Second
+	}
+
+	return ttl
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func NewService(r Repository, merchantRepo merchant.Repository) Service {
+	return &service{
+		repository:         r,
+		merchantRepository: merchantRepo,
+
+		paymentsApi: clients.GetPaymentClient(),
+		cache:       cache.New[uint, presenter.Dashboard](),
+
+		now: time.Now,
+	}
+}
@@ -0,0 +1,107 @@
+package dashboard
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/cache"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	reads int
+}
+
+func (f *fakeRepository) ReadProductTotals(_ uint, since time.Time) ([]ProductTotal, error) {
+	f.reads++
+	if since.Day() == 1 {
+		return []ProductTotal{
+			{Product: consts.CASH_WITHDRAW, Count: 10, Amount: 5000},
+			{Product: consts.MPESA_FLOAT, Count: 2, Amount: 3000},
+		}, nil
+	}
+	return []ProductTotal{{Product: consts.CASH_WITHDRAW, Count: 1, Amount: 500}}, nil
+}
+
+func (f *fakeRepository) ReadEarnings(_ uint, since time.Time) ([]EarningTotal, error) {
+	if since.Day() == 1 {
+		return []EarningTotal{{Type: "CASHBACK", Amount: 30}, {Type: "COMMISSION", Amount: 12}}, nil
+	}
+	return []EarningTotal{{Type: "CASHBACK", Amount: 3}}, nil
+}
+
+func (f *fakeRepository) ReadEarningBalances(uint) ([]EarningTotal, error) {
+	return []EarningTotal{{Type: "CASHBACK", Amount: 80}, {Type: "COMMISSION", Amount: 20}}, nil
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+}
+
+func (f *fakeMerchantRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	return &presenter.Merchant{Id: id, AccountId: 10, FloatAccountId: 7}, nil
+}
+
+type fakeFloatAccounts struct {
+	err error
+}
+
+func (f *fakeFloatAccounts) FetchFloatAccount(string) (*clients.FloatAccount, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &clients.FloatAccount{Balance: 1500}, nil
+}
+
+func newTestService(repository *fakeRepository, floats *fakeFloatAccounts) Service {
+	return &service{
+		repository:         repository,
+		merchantRepository: &fakeMerchantRepository{},
+		paymentsApi:        floats,
+		cache:              cache.New[uint, presenter.Dashboard](),
+		now:                func() time.Time { return time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC) },
+	}
+}
+
+func TestService_GetDashboard(t *testing.T) {
+	repository := &fakeRepository{}
+	s := newTestService(repository, &fakeFloatAccounts{})
+
+	dashboard, err := s.GetDashboard(1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, float32(1500), *dashboard.FloatBalance)
+	assert.Equal(t, map[string]float32{"CASHBACK": 80, "COMMISSION": 20}, dashboard.EarningBalances)
+
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), dashboard.Today.Since)
+	assert.Equal(t, 1, dashboard.Today.Count)
+	assert.Equal(t, float32(3), dashboard.Today.Earnings["CASHBACK"])
+
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), dashboard.Month.Since)
+	assert.Equal(t, 12, dashboard.Month.Count)
+	assert.Equal(t, float32(8000), dashboard.Month.Amount)
+	assert.Len(t, dashboard.Month.Products, 2)
+
+	// Served from cache
+	_, err = s.GetDashboard(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, repository.reads)
+}
+
+func TestService_GetDashboard_FloatUnavailable(t *testing.T) {
+	repository := &fakeRepository{}
+	s := newTestService(repository, &fakeFloatAccounts{err: errors.New("timeout")})
+
+	dashboard, err := s.GetDashboard(1)
+	assert.NoError(t, err)
+	assert.Nil(t, dashboard.FloatBalance)
+	assert.Equal(t, 12, dashboard.Month.Count)
+
+	// Not cached, so the balance is retried
+	_, _ = s.GetDashboard(1)
+	assert.Equal(t, 4, repository.reads)
+}
@@ -0,0 +1,95 @@
+package document
+
+import (
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	CreateDocument(document *entities.MerchantDocument) (*entities.MerchantDocument, error)
+	ReadDocument(id uint) (*entities.MerchantDocument, error)
+	ReadMerchantDocuments(merchantId uint) (*[]presenter.MerchantDocument, error)
+	ReadDocumentsByStatus(status string, pagination datastore.Pagination) (*[]presenter.MerchantDocument, int64, error)
+	ReadApprovedTypes(merchantId uint) ([]string, error)
+	UpdateDocument(document *entities.MerchantDocument) (*presenter.MerchantDocument, error)
+	SupersedeDocuments(merchantId uint, documentType string) error
+}
+type repository struct {
+}
+
+func (r *repository) CreateDocument(document *entities.MerchantDocument) (*entities.MerchantDocument, error) {
+	result := datastore.DB.Create(&document)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return document, nil
+}
+
+func (r *repository) ReadDocument(id uint) (document *entities.MerchantDocument, err error) {
+	err = datastore.DB.First(&document, id).Error
+	return
+}
+
+func (r *repository) ReadMerchantDocuments(merchantId uint) (documents *[]presenter.MerchantDocument, err error) {
+	err = datastore.DB.Model(&entities.MerchantDocument{}).
+		Where("merchant_id", merchantId).
+		Order("id desc").
+		Find(&documents).
+		Error
+	return
+}
+
+// ReadDocumentsByStatus returns the oldest documents first, so reviewers work through the queue in upload order.
+func (r *repository) ReadDocumentsByStatus(status string, pagination datastore.Pagination) (documents *[]presenter.MerchantDocument, total int64, err error) {
+	query := datastore.DB.Model(&entities.MerchantDocument{}).Where("status", status)
+
+	err = query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Order("id").Scopes(datastore.Paginate(pagination)).Find(&documents).Error
+
+	return
+}
+
+func (r *repository) ReadApprovedTypes(merchantId uint) (types []string, err error) {
+	err = datastore.DB.Model(&entities.MerchantDocument{}).
+		Where("merchant_id", merchantId).
+		Where("status", consts.DOCUMENT_APPROVED).
+		Distinct().
+		Pluck("type", &types).
+		Error
+	return
+}
+
+func (r *repository) UpdateDocument(document *entities.MerchantDocument) (*presenter.MerchantDocument, error) {
+	result := datastore.DB.Updates(document)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var updated *presenter.MerchantDocument
+	err := datastore.DB.Model(&entities.MerchantDocument{}).First(&updated, document.Id).Error
+
+	return updated, err
+}
+
+// SupersedeDocuments retires the merchant's pending and approved documents of a type once a replacement is uploaded.
+func (r *repository) SupersedeDocuments(merchantId uint, documentType string) error {
+	return datastore.DB.Model(&entities.MerchantDocument{}).
+		Where("merchant_id", merchantId).
+		Where("type", documentType).
+		Where("status IN ?", []string{consts.DOCUMENT_PENDING, consts.DOCUMENT_APPROVED}).
+		Update("status", consts.DOCUMENT_SUPERSEDED).
+		Error
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
@@ -0,0 +1,269 @@
+package document
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/storage"
+	"merchants.sidooh/utils/consts"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Upload struct {
+	Type     string
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+type Service interface {
+	UploadDocument(merchantId uint, upload Upload) (*entities.MerchantDocument, error)
+	GetMerchantDocuments(merchantId uint) (*[]presenter.MerchantDocument, error)
+	GetReviewQueue(status string, pagination datastore.Pagination) (*presenter.Paginated, error)
+	OpenDocument(id uint) (*entities.MerchantDocument, io.ReadCloser, error)
+	ApproveDocument(id uint, actor string) (*presenter.MerchantDocument, error)
+	RejectDocument(id uint, reason, actor string) (*presenter.MerchantDocument, error)
+}
+
+// onboarder continues a merchant's onboarding once their documents have been approved.
+type onboarder interface {
+	Resume(merchantId uint) (*presenter.Merchant, error)
+}
+
+var contentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+type service struct {
+	repository         Repository
+	merchantRepository merchant.Repository
+	storage            storage.Storage
+	onboarding         onboarder
+
+	notifyApi smsApi
+}
+
+type smsApi interface {
+	SendSMS(event, phone, message string) error
+}
+
+func (s *service) UploadDocument(merchantId uint, upload Upload) (*entities.MerchantDocument, error) {
+	if !slices.Contains(Types(), upload.Type) {
+		return nil, fmt.Errorf("%w: type %s is not supported", pkg.ErrInvalidDocument, upload.Type)
+	}
+	if upload.Size <= 0 || upload.Size > maxSize() {
+		return nil, fmt.Errorf("%w: size must be at most %d bytes", pkg.ErrInvalidDocument, maxSize())
+	}
+
+	merchant, err := s.merchantRepository.ReadMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status == consts.MERCHANT_SUSPENDED || merchant.Status == consts.MERCHANT_CLOSED {
+		return nil, pkg.ErrMerchantNotActive
+	}
+
+	// Trust the content rather than the file name or the client's content type header
+	reader := bufio.NewReader(upload.Content)
+	head, _ := reader.Peek(512)
+	contentType := strings.Split(http.DetectContentType(head), ";")[0]
+	extension, ok := contentTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: only pdf, jpeg and png files are accepted", pkg.ErrInvalidDocument)
+	}
+
+	key := fmt.Sprintf("merchants/%d/documents/%s-%d%s", merchantId, strings.ToLower(upload.Type), time.Now().UnixNano(), extension)
+	if err = s.storage.Put(key, reader); err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.SupersedeDocuments(merchantId, upload.Type); err != nil {
+		return nil, err
+	}
+
+	return s.repository.CreateDocument(&entities.MerchantDocument{
+		Type:        upload.Type,
+		Path:        key,
+		FileName:    filepath.Base(upload.FileName),
+		ContentType: contentType,
+		Size:        upload.Size,
+		Status:      consts.DOCUMENT_PENDING,
+		MerchantId:  merchantId,
+	})
+}
+
+func (s *service) GetMerchantDocuments(merchantId uint) (*[]presenter.MerchantDocument, error) {
+	return s.repository.ReadMerchantDocuments(merchantId)
+}
+
+func (s *service) GetReviewQueue(status string, pagination datastore.Pagination) (*presenter.Paginated, error) {
+	if status == "" {
+		status = consts.DOCUMENT_PENDING
+	}
+
+	documents, total, err := s.repository.ReadDocumentsByStatus(status, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination = pagination.Normalize()
+
+	return &presenter.Paginated{
+		Data:     documents,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	}, nil
+}
+
+func (s *service) OpenDocument(id uint) (*entities.MerchantDocument, io.ReadCloser, error) {
+	document, err := s.repository.ReadDocument(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := s.storage.Get(document.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return document, file, nil
+}
+
+func (s *service) ApproveDocument(id uint, actor string) (*presenter.MerchantDocument, error) {
+	document, err := s.review(id, consts.DOCUMENT_APPROVED, nil, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant, err := s.merchantRepository.ReadMerchant(document.MerchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant.OnboardingStep == consts.ONBOARDING_DOCUMENTS {
+		missing, err := MissingDocuments(s.repository, merchant.Id)
+		if err == nil && len(missing) == 0 {
+			// Failures are persisted on the onboarding steps and can be retried from there
+			if _, err = s.onboarding.Resume(merchant.Id); err != nil {
+				logger.ClientLog.Error("failed to resume onboarding after document approval", "merchant", merchant.Id, "err", err)
+			}
+		}
+	}
+
+	return document, nil
+}
+
+func (s *service) RejectDocument(id uint, reason, actor string) (*presenter.MerchantDocument, error) {
+	document, err := s.review(id, consts.DOCUMENT_REJECTED, &reason, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant, err := s.merchantRepository.ReadMerchant(document.MerchantId)
+	if err == nil {
+		message := fmt.Sprintf("Your %s document was rejected: %s. Please upload it again.", strings.ReplaceAll(strings.ToLower(document.Type), "_", " "), reason)
+		go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, message)
+	}
+
+	return document, nil
+}
+
+func (s *service) review(id uint, status string, reason *string, actor string) (*presenter.MerchantDocument, error) {
+	document, err := s.repository.ReadDocument(id)
+	if err != nil {
+		return nil, err
+	}
+	if document.Status != consts.DOCUMENT_PENDING {
+		return nil, pkg.ErrDocumentReviewed
+	}
+
+	now := time.Now()
+
+	return s.repository.UpdateDocument(&entities.MerchantDocument{
+		ModelID:    entities.ModelID{Id: document.Id},
+		Status:     status,
+		Reason:     reason,
+		ReviewedBy: &actor,
+		ReviewedAt: &now,
+	})
+}
+
+// Types lists the documents a merchant may upload.
+func Types() []string {
+	return []string{
+		consts.DOCUMENT_BUSINESS_PERMIT,
+		consts.DOCUMENT_KRA_PIN,
+		consts.DOCUMENT_ID_FRONT,
+		consts.DOCUMENT_ID_BACK,
+	}
+}
+
+// RequiredTypes reads KYB_REQUIRED_DOCUMENTS, a comma separated list of document types that have to be approved
+// before a merchant is activated. All types are required by default, an explicit NONE requires none.
+func RequiredTypes() []string {
+	value := viper.GetString("KYB_REQUIRED_DOCUMENTS")
+	if value == "" {
+		return Types()
+	}
+
+	var types []string
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); slices.Contains(Types(), t) {
+			types = append(types, t)
+		}
+	}
+
+	return types
+}
+
+// MissingDocuments returns the required document types the merchant does not yet have an approved document for.
+func MissingDocuments(r Repository, merchantId uint) ([]string, error) {
+	approved, err := r.ReadApprovedTypes(merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, t := range RequiredTypes() {
+		if !slices.Contains(approved, t) {
+			missing = append(missing, t)
+		}
+	}
+
+	return missing, nil
+}
+
+func maxSize() int64 {
+	size := viper.GetInt64("DOCUMENT_MAX_SIZE")
+	if size <= 0 {
+		size = 4 << 20
+	}
+
+	return size
+}
+
+func NewService(r Repository, merchantRepo merchant.Repository, store storage.Storage, onboarding onboarder) Service {
+	return &service{
+		repository:         r,
+		merchantRepository: merchantRepo,
+		storage:            store,
+		onboarding:         onboarding,
+
+		notifyApi: clients.GetNotifyClient(),
+	}
+}
@@ -0,0 +1,234 @@
+package document
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"io"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/storage"
+	"merchants.sidooh/utils/consts"
+	"slices"
+	"testing"
+)
+
+var pdf = []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")
+
+type fakeRepository struct {
+	Repository
+
+	documents []*entities.MerchantDocument
+}
+
+func (f *fakeRepository) CreateDocument(document *entities.MerchantDocument) (*entities.MerchantDocument, error) {
+	document.Id = uint(len(f.documents) + 1)
+	f.documents = append(f.documents, document)
+	return document, nil
+}
+
+func (f *fakeRepository) ReadDocument(id uint) (*entities.MerchantDocument, error) {
+	if id == 0 || int(id) > len(f.documents) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *f.documents[id-1]
+	return &copied, nil
+}
+
+func (f *fakeRepository) UpdateDocument(document *entities.MerchantDocument) (*presenter.MerchantDocument, error) {
+	saved := f.documents[document.Id-1]
+	saved.Status, saved.Reason, saved.ReviewedBy, saved.ReviewedAt = document.Status, document.Reason, document.ReviewedBy, document.ReviewedAt
+
+	return &presenter.MerchantDocument{Id: saved.Id, MerchantId: saved.MerchantId, Type: saved.Type, Status: saved.Status}, nil
+}
+
+func (f *fakeRepository) SupersedeDocuments(merchantId uint, documentType string) error {
+	for _, d := range f.documents {
+		if d.MerchantId == merchantId && d.Type == documentType && (d.Status == consts.DOCUMENT_PENDING || d.Status == consts.DOCUMENT_APPROVED) {
+			d.Status = consts.DOCUMENT_SUPERSEDED
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) ReadApprovedTypes(merchantId uint) (types []string, err error) {
+	for _, d := range f.documents {
+		if d.MerchantId == merchantId && d.Status == consts.DOCUMENT_APPROVED && !slices.Contains(types, d.Type) {
+			types = append(types, d.Type)
+		}
+	}
+	return
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+
+	merchants map[uint]*presenter.Merchant
+}
+
+func (f *fakeMerchantRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	m, ok := f.merchants[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return m, nil
+}
+
+type fakeOnboarder struct {
+	resumed []uint
+}
+
+func (f *fakeOnboarder) Resume(merchantId uint) (*presenter.Merchant, error) {
+	f.resumed = append(f.resumed, merchantId)
+	return nil, nil
+}
+
+type fakeNotifyApi struct {
+	sent chan string
+}
+
+func (f *fakeNotifyApi) SendSMS(event, phone, message string) error {
+	f.sent <- message
+	return nil
+}
+
+func newTestService(t *testing.T) (*service, *fakeRepository, *fakeOnboarder, *fakeNotifyApi) {
+	repo := &fakeRepository{}
+	onboarding := &fakeOnboarder{}
+	notify := &fakeNotifyApi{sent: make(chan string, 1)}
+
+	return &service{
+		repository: repo,
+		merchantRepository: &fakeMerchantRepository{merchants: map[uint]*presenter.Merchant{
+			1: {Id: 1, Phone: "254700000001", Status: consts.MERCHANT_PENDING_KYB, OnboardingStep: consts.ONBOARDING_DOCUMENTS},
+			2: {Id: 2, Status: consts.MERCHANT_SUSPENDED},
+			3: {Id: 3, Status: consts.MERCHANT_ACTIVE, OnboardingStep: consts.ONBOARDING_COMPLETED},
+		}},
+		storage:    storage.NewLocal(t.TempDir()),
+		onboarding: onboarding,
+		notifyApi:  notify,
+	}, repo, onboarding, notify
+}
+
+func upload(documentType string, content []byte) Upload {
+	return Upload{Type: documentType, FileName: "../permit.pdf", Size: int64(len(content)), Content: bytes.NewReader(content)}
+}
+
+func TestService_UploadDocument_Validation(t *testing.T) {
+	s, repo, _, _ := newTestService(t)
+
+	_, err := s.UploadDocument(1, upload("PASSPORT", pdf))
+	assert.ErrorIs(t, err, pkg.ErrInvalidDocument)
+
+	tooLarge := upload(consts.DOCUMENT_KRA_PIN, pdf)
+	tooLarge.Size = maxSize() + 1
+	_, err = s.UploadDocument(1, tooLarge)
+	assert.ErrorIs(t, err, pkg.ErrInvalidDocument)
+
+	empty := upload(consts.DOCUMENT_KRA_PIN, nil)
+	_, err = s.UploadDocument(1, empty)
+	assert.ErrorIs(t, err, pkg.ErrInvalidDocument)
+
+	// The content decides the type, not the file name
+	_, err = s.UploadDocument(1, upload(consts.DOCUMENT_KRA_PIN, []byte("#!/bin/sh\nrm -rf /\n")))
+	assert.ErrorIs(t, err, pkg.ErrInvalidDocument)
+
+	_, err = s.UploadDocument(2, upload(consts.DOCUMENT_KRA_PIN, pdf))
+	assert.ErrorIs(t, err, pkg.ErrMerchantNotActive)
+
+	_, err = s.UploadDocument(99, upload(consts.DOCUMENT_KRA_PIN, pdf))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Empty(t, repo.documents)
+
+	document, err := s.UploadDocument(1, upload(consts.DOCUMENT_KRA_PIN, pdf))
+	assert.NoError(t, err)
+	assert.Equal(t, "application/pdf", document.ContentType)
+	assert.Equal(t, "permit.pdf", document.FileName)
+	assert.Equal(t, consts.DOCUMENT_PENDING, document.Status)
+	assert.Regexp(t, `^merchants/1/documents/kra_pin-\d+\.pdf$`, document.Path)
+
+	_, file, err := s.OpenDocument(document.Id)
+	assert.NoError(t, err)
+	stored, _ := io.ReadAll(file)
+	file.Close()
+	assert.Equal(t, pdf, stored)
+}
+
+func TestService_UploadDocument_Replaces(t *testing.T) {
+	s, repo, _, _ := newTestService(t)
+
+	first, err := s.UploadDocument(1, upload(consts.DOCUMENT_KRA_PIN, pdf))
+	assert.NoError(t, err)
+	permit, err := s.UploadDocument(1, upload(consts.DOCUMENT_BUSINESS_PERMIT, pdf))
+	assert.NoError(t, err)
+	_, err = s.ApproveDocument(first.Id, "9")
+	assert.NoError(t, err)
+
+	second, err := s.UploadDocument(1, upload(consts.DOCUMENT_KRA_PIN, pdf))
+	assert.NoError(t, err)
+
+	assert.Equal(t, consts.DOCUMENT_SUPERSEDED, repo.documents[first.Id-1].Status)
+	assert.Equal(t, consts.DOCUMENT_PENDING, repo.documents[permit.Id-1].Status)
+	assert.Equal(t, consts.DOCUMENT_PENDING, repo.documents[second.Id-1].Status)
+
+	approved, _ := repo.ReadApprovedTypes(1)
+	assert.Empty(t, approved)
+}
+
+func TestService_ReviewDocument(t *testing.T) {
+	s, repo, onboarding, notify := newTestService(t)
+
+	permit, _ := s.UploadDocument(1, upload(consts.DOCUMENT_BUSINESS_PERMIT, pdf))
+	kraPin, _ := s.UploadDocument(1, upload(consts.DOCUMENT_KRA_PIN, pdf))
+
+	document, err := s.ApproveDocument(permit.Id, "9")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.DOCUMENT_APPROVED, document.Status)
+	assert.Equal(t, "9", *repo.documents[permit.Id-1].ReviewedBy)
+	assert.NotNil(t, repo.documents[permit.Id-1].ReviewedAt)
+
+	document, err = s.RejectDocument(kraPin.Id, "blurry", "9")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.DOCUMENT_REJECTED, document.Status)
+	assert.Equal(t, "blurry", *repo.documents[kraPin.Id-1].Reason)
+	assert.Equal(t, "Your kra pin document was rejected: blurry. Please upload it again.", <-notify.sent)
+
+	// Reviews are final
+	_, err = s.ApproveDocument(kraPin.Id, "9")
+	assert.ErrorIs(t, err, pkg.ErrDocumentReviewed)
+	_, err = s.RejectDocument(permit.Id, "changed my mind", "9")
+	assert.ErrorIs(t, err, pkg.ErrDocumentReviewed)
+
+	_, err = s.ApproveDocument(99, "9")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.Empty(t, onboarding.resumed)
+}
+
+func TestService_ApproveDocument_ResumesOnboarding(t *testing.T) {
+	s, _, onboarding, _ := newTestService(t)
+
+	var ids []uint
+	for _, documentType := range RequiredTypes() {
+		document, err := s.UploadDocument(1, upload(documentType, pdf))
+		assert.NoError(t, err)
+		ids = append(ids, document.Id)
+	}
+
+	for i, id := range ids {
+		_, err := s.ApproveDocument(id, "9")
+		assert.NoError(t, err)
+
+		if i < len(ids)-1 {
+			assert.Empty(t, onboarding.resumed, "resumed with %d of %d documents approved", i+1, len(ids))
+		}
+	}
+	assert.Equal(t, []uint{1}, onboarding.resumed)
+
+	// Documents uploaded after onboarding don't resume it
+	document, _ := s.UploadDocument(3, upload(consts.DOCUMENT_KRA_PIN, pdf))
+	_, err := s.ApproveDocument(document.Id, "9")
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{1}, onboarding.resumed)
+}
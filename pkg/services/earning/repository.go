@@ -1,6 +1,7 @@
 package earning
 
 import (
+	"gorm.io/gorm"
 	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
 )
@@ -8,9 +9,11 @@ import (
 // Repository interface allows us to access the CRUD Operations here.
 type Repository interface {
 	CreateEarning(data *entities.Earning) (*entities.Earning, error)
+	CreateEarnings(data []entities.Earning) ([]entities.Earning, error)
 	UpdateEarning(data *entities.Earning) (*entities.Earning, error)
 	ReadEarnings() (*[]entities.Earning, error)
 	ReadPendingEarnings() (*[]entities.Earning, error)
+	ReadTransactionEarnings(transactionId uint) ([]entities.Earning, error)
 }
 type repository struct {
 }
@@ -24,6 +27,18 @@ func (r *repository) CreateEarning(data *entities.Earning) (*entities.Earning, e
 	return data, nil
 }
 
+// CreateEarnings records the earnings in one db transaction, so either all of them are recorded or none are.
+func (r *repository) CreateEarnings(data []entities.Earning) ([]entities.Earning, error) {
+	err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(&data).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
 func (r *repository) ReadEarnings() (results *[]entities.Earning, err error) {
 	err = datastore.DB.Find(&results).Error
 	return
@@ -34,6 +49,11 @@ func (r *repository) ReadPendingEarnings() (results *[]entities.Earning, err err
 	return
 }
 
+func (r *repository) ReadTransactionEarnings(transactionId uint) (results []entities.Earning, err error) {
+	err = datastore.DB.Where("transaction_id", transactionId).Find(&results).Error
+	return
+}
+
 func (r *repository) UpdateEarning(data *entities.Earning) (*entities.Earning, error) {
 	result := datastore.DB.Updates(&data)
 	if result.Error != nil {
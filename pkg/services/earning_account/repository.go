@@ -1,7 +1,10 @@
 package earning_account
 
 import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
 	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
 )
@@ -15,6 +18,7 @@ type Repository interface {
 	ReadAccountByAccountIdAndType(accountId uint, accType string) (*entities.EarningAccount, error)
 	UpdateAccount(data *entities.EarningAccount) (*entities.EarningAccount, error)
 	UpdateColumn(data *entities.EarningAccount, column string, value interface{}) (*entities.EarningAccount, error)
+	ApplyTransaction(data *entities.EarningAccountTransaction) (*entities.EarningAccount, *entities.EarningAccountTransaction, error)
 }
 type repository struct {
 }
@@ -72,6 +76,49 @@ func (r *repository) UpdateColumn(data *entities.EarningAccount, column string,
 	return r.ReadAccount(data.Id)
 }
 
+// ApplyTransaction credits or debits the account and records the entry with the balance it left, in one db transaction
+// with the account locked so concurrent entries each see the balance the previous one left. An entry for an earning is
+// only made once, so retrying one already applied returns the existing entry and leaves the balance as it is.
+func (r *repository) ApplyTransaction(data *entities.EarningAccountTransaction) (*entities.EarningAccount, *entities.EarningAccountTransaction, error) {
+	account := &entities.EarningAccount{}
+
+	err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(account, data.EarningAccountId).Error; err != nil {
+			return err
+		}
+
+		if data.EarningId != nil {
+			applied := tx.Where("earning_id", *data.EarningId).Where("type", data.Type).Limit(1).Find(data)
+			if applied.Error != nil || applied.RowsAffected > 0 {
+				return applied.Error
+			}
+		}
+
+		change := gorm.Expr("amount + ?", data.Amount)
+		if data.Type == "DEBIT" {
+			if account.Amount < data.Amount {
+				return pkg.ErrInsufficientBalance
+			}
+			change = gorm.Expr("amount - ?", data.Amount)
+		}
+
+		if err := tx.Model(account).Update("amount", change).Error; err != nil {
+			return err
+		}
+		if err := tx.First(account, account.Id).Error; err != nil {
+			return err
+		}
+
+		data.BalanceAfter = &account.Amount
+		return tx.Create(&data).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return account, data, nil
+}
+
 // NewRepo is the single instance repo that is being created.
 func NewRepo() Repository {
 	return &repository{}
@@ -0,0 +1,48 @@
+package earning_account
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"os"
+	"testing"
+)
+
+func TestRepository_ApplyTransaction_OncePerEarning(t *testing.T) {
+	viper.Set("APP_ENV", "TEST")
+	viper.Set("MIGRATE_DB", true)
+	datastore.Init()
+	t.Cleanup(func() { os.Remove("test.db") })
+
+	r := NewRepo()
+	account, err := r.CreateAccount(&entities.EarningAccount{Type: "CASHBACK", AccountId: 10})
+	assert.Nil(t, err)
+
+	earningId := uint(4)
+	entry := func(txType string, amount float32, earningId *uint) *entities.EarningAccountTransaction {
+		return &entities.EarningAccountTransaction{Type: txType, Amount: amount, EarningAccountId: account.Id, EarningId: earningId}
+	}
+
+	_, credited, err := r.ApplyTransaction(entry("CREDIT", 6, &earningId))
+	assert.Nil(t, err)
+	_, _, err = r.ApplyTransaction(entry("DEBIT", 4.8, &earningId))
+	assert.Nil(t, err)
+
+	// A retry doesn't credit the earning again
+	updated, retried, err := r.ApplyTransaction(entry("CREDIT", 6, &earningId))
+	assert.Nil(t, err)
+	assert.Equal(t, credited.Id, retried.Id)
+	assert.InDelta(t, 1.2, updated.Amount, .001)
+
+	// Entries without an earning are all made
+	_, _, err = r.ApplyTransaction(entry("CREDIT", 10, nil))
+	assert.Nil(t, err)
+	updated, _, err = r.ApplyTransaction(entry("CREDIT", 10, nil))
+	assert.Nil(t, err)
+	assert.InDelta(t, 21.2, updated.Amount, .001)
+
+	var count int64
+	datastore.DB.Model(&entities.EarningAccountTransaction{}).Where("earning_account_id", account.Id).Count(&count)
+	assert.Equal(t, int64(4), count)
+}
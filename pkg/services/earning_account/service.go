@@ -1,8 +1,9 @@
 package earning_account
 
 import (
+	"gorm.io/gorm"
 	"merchants.sidooh/api/presenter"
-	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
 	"merchants.sidooh/pkg/services/earning_account_transaction"
 	"merchants.sidooh/utils"
@@ -13,8 +14,17 @@ type Service interface {
 	FetchAccountsByMerchant(merchantId uint) ([]presenter.EarningAccount, error)
 	CreateAccount(data *entities.EarningAccount) (*entities.EarningAccount, error)
 
-	CreditAccount(accountId uint, amount float32) (*entities.EarningAccount, error)
-	DebitAccount(accountId uint, amount float32) (*entities.EarningAccount, *entities.EarningAccountTransaction, error)
+	CreditAccount(accountId uint, amount float32, entry Entry) (*entities.EarningAccount, error)
+	DebitAccount(accountId uint, amount float32, entry Entry) (*entities.EarningAccount, *entities.EarningAccountTransaction, error)
+
+	GetTransactions(id, ownerAccountId uint, pagination datastore.Pagination) (*presenter.Paginated, error)
+}
+
+// Entry describes why an earning account changed, and the transaction and earning that caused it if any.
+type Entry struct {
+	Description   string
+	TransactionId *uint
+	EarningId     *uint
 }
 
 type service struct {
@@ -36,59 +46,49 @@ func (s *service) FetchAccountsByMerchant(merchantId uint) (results []presenter.
 	return
 }
 
-func (s *service) CreditAccount(accountId uint, amount float32) (*entities.EarningAccount, error) {
-	// TODO: use db tx
-	account, err := s.repository.ReadAccount(accountId)
-	if err != nil {
-		return nil, err
-	}
+func (s *service) CreditAccount(accountId uint, amount float32, entry Entry) (*entities.EarningAccount, error) {
+	account, _, err := s.repository.ApplyTransaction(transaction("CREDIT", accountId, amount, entry))
 
-	// create tx //TODO: add descriptions too
-	s.earningAccTxRepository.CreateTransaction(&entities.EarningAccountTransaction{
-		Type:             "CREDIT",
-		Amount:           amount,
-		EarningAccountId: accountId,
-	})
+	return account, err
+}
 
-	// update acc
-	account.Amount += amount
-	account, err = s.repository.UpdateAccount(account)
+// DebitAccount fails with ErrInsufficientBalance when the account has less than the amount.
+func (s *service) DebitAccount(accountId uint, amount float32, entry Entry) (*entities.EarningAccount, *entities.EarningAccountTransaction, error) {
+	return s.repository.ApplyTransaction(transaction("DEBIT", accountId, amount, entry))
+}
+
+// GetTransactions lists the entries of the owner's earning account, most recent first, with the balance each one left.
+// Another owner's account is reported as not found.
+func (s *service) GetTransactions(id, ownerAccountId uint, pagination datastore.Pagination) (*presenter.Paginated, error) {
+	account, err := s.repository.ReadAccount(id)
 	if err != nil {
 		return nil, err
 	}
+	if account.AccountId != ownerAccountId {
+		return nil, gorm.ErrRecordNotFound
+	}
 
-	return account, nil
-}
-
-func (s *service) DebitAccount(accountId uint, amount float32) (*entities.EarningAccount, *entities.EarningAccountTransaction, error) {
-	// TODO: use db tx
-	account, err := s.repository.ReadAccount(accountId)
+	pagination = pagination.Normalize()
+	transactions, total, err := s.earningAccTxRepository.ReadTransactions(id, pagination)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-
-	if account.Amount < amount {
-		return nil, nil, pkg.ErrInsufficientBalance
+	if transactions == nil {
+		transactions = []presenter.EarningAccountTransaction{}
 	}
 
-	// create tx //TODO: add descriptions too
-	tx, err := s.earningAccTxRepository.CreateTransaction(&entities.EarningAccountTransaction{
-		Type:             "DEBIT",
+	return &presenter.Paginated{Data: transactions, Page: pagination.Page, PageSize: pagination.PageSize, Total: total}, nil
+}
+
+func transaction(txType string, accountId uint, amount float32, entry Entry) *entities.EarningAccountTransaction {
+	return &entities.EarningAccountTransaction{
+		Type:             txType,
 		Amount:           amount,
+		Description:      entry.Description,
 		EarningAccountId: accountId,
-	})
-	if err != nil {
-		return nil, nil, err
+		TransactionId:    entry.TransactionId,
+		EarningId:        entry.EarningId,
 	}
-
-	// update acc
-	account.Amount -= amount
-	account, err = s.repository.UpdateColumn(account, "amount", account.Amount)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	return account, tx, nil
 }
 
 func (s *service) CreateAccount(data *entities.EarningAccount) (*entities.EarningAccount, error) {
@@ -0,0 +1,146 @@
+package earning_account
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"testing"
+)
+
+type fakeRepository struct {
+	Repository
+
+	accounts map[uint]*entities.EarningAccount
+	entries  []*entities.EarningAccountTransaction
+}
+
+func (f *fakeRepository) ReadAccount(id uint) (*entities.EarningAccount, error) {
+	account, ok := f.accounts[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return account, nil
+}
+
+func (f *fakeRepository) ApplyTransaction(data *entities.EarningAccountTransaction) (*entities.EarningAccount, *entities.EarningAccountTransaction, error) {
+	account, err := f.ReadAccount(data.EarningAccountId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if data.Type == "DEBIT" {
+		if account.Amount < data.Amount {
+			return nil, nil, pkg.ErrInsufficientBalance
+		}
+		account.Amount -= data.Amount
+	} else {
+		account.Amount += data.Amount
+	}
+
+	balance := account.Amount
+	data.BalanceAfter = &balance
+	data.Id = uint(len(f.entries) + 1)
+	f.entries = append(f.entries, data)
+	return account, data, nil
+}
+
+type fakeTransactionRepository struct {
+	repository *fakeRepository
+}
+
+func (f *fakeTransactionRepository) CreateTransaction(data *entities.EarningAccountTransaction) (*entities.EarningAccountTransaction, error) {
+	return data, nil
+}
+
+func (f *fakeTransactionRepository) ReadTransactions(earningAccountId uint, pagination datastore.Pagination) (results []presenter.EarningAccountTransaction, total int64, err error) {
+	for i := len(f.repository.entries) - 1; i >= 0; i-- {
+		e := f.repository.entries[i]
+		if e.EarningAccountId != earningAccountId {
+			continue
+		}
+		total++
+		if total > int64((pagination.Page-1)*pagination.PageSize) && len(results) < pagination.PageSize {
+			results = append(results, presenter.EarningAccountTransaction{
+				Id: e.Id, Type: e.Type, Amount: e.Amount, BalanceAfter: e.BalanceAfter, Description: e.Description,
+				TransactionId: e.TransactionId, EarningId: e.EarningId,
+			})
+		}
+	}
+	return
+}
+
+func newTestService() (*service, *fakeRepository) {
+	repo := &fakeRepository{accounts: map[uint]*entities.EarningAccount{
+		1: {ModelID: entities.ModelID{Id: 1}, Type: "CASHBACK", AccountId: 10, Amount: 0},
+		2: {ModelID: entities.ModelID{Id: 2}, Type: "COMMISSION", AccountId: 10, Amount: 0},
+	}}
+	return &service{repository: repo, earningAccTxRepository: &fakeTransactionRepository{repository: repo}}, repo
+}
+
+func TestService_CreditAndDebitAccount(t *testing.T) {
+	s, repo := newTestService()
+	txId, earningId := uint(7), uint(3)
+
+	account, err := s.CreditAccount(1, 50, Entry{Description: "Cashback on Airtime", TransactionId: &txId, EarningId: &earningId})
+	assert.Nil(t, err)
+	assert.Equal(t, float32(50), account.Amount)
+
+	account, entry, err := s.DebitAccount(1, 20, Entry{Description: "Withdrawal", TransactionId: &txId})
+	assert.Nil(t, err)
+	assert.Equal(t, float32(30), account.Amount)
+	assert.Equal(t, "DEBIT", entry.Type)
+	assert.Equal(t, float32(30), *entry.BalanceAfter)
+	assert.Nil(t, entry.EarningId)
+
+	assert.Len(t, repo.entries, 2)
+	assert.Equal(t, "CREDIT", repo.entries[0].Type)
+	assert.Equal(t, "Cashback on Airtime", repo.entries[0].Description)
+	assert.Equal(t, float32(50), *repo.entries[0].BalanceAfter)
+	assert.Equal(t, &earningId, repo.entries[0].EarningId)
+}
+
+func TestService_DebitAccount_InsufficientBalance(t *testing.T) {
+	s, repo := newTestService()
+
+	_, err := s.CreditAccount(1, 10, Entry{Description: "Cashback on Airtime"})
+	assert.Nil(t, err)
+
+	_, _, err = s.DebitAccount(1, 11, Entry{Description: "Withdrawal"})
+	assert.ErrorIs(t, err, pkg.ErrInsufficientBalance)
+	assert.Equal(t, float32(10), repo.accounts[1].Amount)
+	assert.Len(t, repo.entries, 1)
+}
+
+func TestService_GetTransactions(t *testing.T) {
+	s, _ := newTestService()
+
+	for i := 1; i <= 3; i++ {
+		_, err := s.CreditAccount(1, float32(i*10), Entry{Description: "Cashback on Airtime"})
+		assert.Nil(t, err)
+	}
+	_, err := s.CreditAccount(2, 5, Entry{Description: "Invite commission on Airtime"})
+	assert.Nil(t, err)
+
+	result, err := s.GetTransactions(1, 10, datastore.Pagination{Page: 1, PageSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), result.Total)
+
+	transactions := result.Data.([]presenter.EarningAccountTransaction)
+	assert.Len(t, transactions, 2)
+	assert.Equal(t, float32(60), *transactions[0].BalanceAfter)
+	assert.Equal(t, float32(30), *transactions[1].BalanceAfter)
+
+	result, err = s.GetTransactions(2, 10, datastore.Pagination{Page: 2, PageSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []presenter.EarningAccountTransaction{}, result.Data)
+
+	_, err = s.GetTransactions(99, 10, datastore.Pagination{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Another account's entries are not exposed
+	_, err = s.GetTransactions(1, 11, datastore.Pagination{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
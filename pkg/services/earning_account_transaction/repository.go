@@ -1,6 +1,7 @@
 package earning_account_transaction
 
 import (
+	"merchants.sidooh/api/presenter"
 	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
 )
@@ -8,6 +9,7 @@ import (
 // Repository interface allows us to access the CRUD Operations here.
 type Repository interface {
 	CreateTransaction(data *entities.EarningAccountTransaction) (*entities.EarningAccountTransaction, error)
+	ReadTransactions(earningAccountId uint, pagination datastore.Pagination) ([]presenter.EarningAccountTransaction, int64, error)
 }
 type repository struct {
 }
@@ -21,6 +23,20 @@ func (r *repository) CreateTransaction(data *entities.EarningAccountTransaction)
 	return data, nil
 }
 
+// ReadTransactions lists the account's entries, most recent first.
+func (r *repository) ReadTransactions(earningAccountId uint, pagination datastore.Pagination) (results []presenter.EarningAccountTransaction, total int64, err error) {
+	query := datastore.DB.Model(&entities.EarningAccountTransaction{}).Where("earning_account_id", earningAccountId)
+
+	if err = query.Count(&total).Error; err != nil {
+		return
+	}
+
+	err = query.Order("id desc").
+		Scopes(datastore.Paginate(pagination)).
+		Find(&results).Error
+	return
+}
+
 // NewRepo is the single instance repo that is being created.
 func NewRepo() Repository {
 	return &repository{}
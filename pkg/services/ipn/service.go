@@ -5,7 +5,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"merchants.sidooh/pkg/clients"
 	"merchants.sidooh/pkg/entities"
-	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/alert"
 	"merchants.sidooh/pkg/services/earning"
 	"merchants.sidooh/pkg/services/earning_account"
 	"merchants.sidooh/pkg/services/merchant"
@@ -14,6 +14,7 @@ import (
 	"merchants.sidooh/pkg/services/savings"
 	"merchants.sidooh/pkg/services/transaction"
 	"merchants.sidooh/utils"
+	"strconv"
 	"strings"
 )
 
@@ -35,6 +36,7 @@ type service struct {
 	transactionService       transaction.Service
 	earningAccountService    earning_account.Service
 	earningService           earning.Service
+	alertService             alert.Service
 }
 
 func (s *service) HandlePaymentIpn(data *utils.Payment) error {
@@ -44,12 +46,13 @@ func (s *service) HandlePaymentIpn(data *utils.Payment) error {
 	}
 
 	if payment.Status != "PENDING" {
-		go func() {
-			message := fmt.Sprintf("Merchant Payment is not pending, check %v", payment.Id)
-			logger.ClientLog.Error(message, "payment", payment)
-
-			s.notifyApi.SendSMS("DEFAULT", "0780611696", message)
-		}()
+		go s.alertService.Raise(alert.Alert{
+			Type:     alert.PaymentNotPending,
+			Severity: alert.WARNING,
+			Message:  fmt.Sprintf("Merchant Payment is not pending, check %v", payment.Id),
+			Key:      strconv.Itoa(int(payment.Id)),
+			Context:  map[string]interface{}{"payment": payment, "ipn": data},
+		})
 
 		return nil
 	}
@@ -104,7 +107,7 @@ func (s *service) HandleSavingsIpn(data utils.SavingsIPN) error {
 	return nil
 }
 
-func NewService(r payment.Repository, savingsRep savings.Repository, transactionRep transaction.Repository, merchantRep merchant.Repository, mpesaStoreRep mpesa_store.Repository, earningAccRep earning_account.Repository, earningRep earning.Repository, transactionSrv transaction.Service, earningAccSrv earning_account.Service, earningSrv earning.Service) Service {
+func NewService(r payment.Repository, savingsRep savings.Repository, transactionRep transaction.Repository, merchantRep merchant.Repository, mpesaStoreRep mpesa_store.Repository, earningAccRep earning_account.Repository, earningRep earning.Repository, transactionSrv transaction.Service, earningAccSrv earning_account.Service, earningSrv earning.Service, alertSrv alert.Service) Service {
 	return &service{
 		paymentRepository:        r,
 		savingsRepository:        savingsRep,
@@ -116,6 +119,7 @@ func NewService(r payment.Repository, savingsRep savings.Repository, transaction
 		transactionService:       transactionSrv,
 		earningAccountService:    earningAccSrv,
 		earningService:           earningSrv,
+		alertService:             alertSrv,
 		notifyApi:                clients.GetNotifyClient(),
 		accountApi:               clients.GetAccountClient(),
 	}
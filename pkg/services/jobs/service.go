@@ -1,10 +1,11 @@
 package jobs
 
 import (
-	"fmt"
 	"merchants.sidooh/pkg/clients"
 	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/alert"
 	"merchants.sidooh/pkg/services/earning"
+	"merchants.sidooh/pkg/services/mpesa_float"
 	"merchants.sidooh/pkg/services/payment"
 	"merchants.sidooh/pkg/services/transaction"
 	"strconv"
@@ -13,25 +14,30 @@ import (
 type Service interface {
 	EarningsInvestments() error
 	QueryPaymentsStatus() error
+	RunScheduledPurchases() error
+	ProcessCommissions() error
 }
 
 type service struct {
 	earningService     earning.Service
 	paymentService     payment.Service
 	transactionService transaction.Service
+	alertService       alert.Service
+	mpesaFloatService  mpesa_float.Service
 
 	paymentsApi *clients.ApiClient
-	notifyApi   *clients.ApiClient
 }
 
 func (s *service) EarningsInvestments() error {
 	go func() {
 		err := s.earningService.SaveEarnings()
 		if err != nil {
-			message := fmt.Sprintf("Failed to save process merchant earnings")
-			logger.ClientLog.Error(message, err, err)
-
-			_ = s.notifyApi.SendSMS("DEFAULT", "0780611696", message)
+			_ = s.alertService.Raise(alert.Alert{
+				Type:     alert.EarningsInvestmentFailed,
+				Severity: alert.CRITICAL,
+				Message:  "Failed to save process merchant earnings",
+				Context:  map[string]interface{}{"err": err.Error()},
+			})
 		}
 
 	}()
@@ -49,14 +55,14 @@ func (s *service) QueryPaymentsStatus() error {
 		for _, payment := range *payments {
 			paymentData, err := s.paymentsApi.Find(strconv.Itoa(int(payment.PaymentId)))
 			if err != nil {
-				logger.ClientLog.Error("failed to fetch payment", err)
+				logger.ClientLog.Error("failed to fetch payment", "err", err)
 			}
 
 			if paymentData != nil && paymentData.Status != "PENDING" {
 
 				err := s.transactionService.CompleteTransaction(&payment, paymentData)
 				if err != nil {
-					logger.ClientLog.Error("failed to complete transaction", err)
+					logger.ClientLog.Error("failed to complete transaction", "err", err)
 				}
 
 			}
@@ -66,13 +72,42 @@ func (s *service) QueryPaymentsStatus() error {
 	return nil
 }
 
-func NewService(earningSrv earning.Service, paymentSrv payment.Service, transactionSrv transaction.Service) Service {
+func (s *service) RunScheduledPurchases() error {
+	go func() {
+		count, err := s.mpesaFloatService.RunDueSchedules()
+		if err != nil {
+			logger.ClientLog.Error("failed to run scheduled purchases", "err", err)
+			return
+		}
+
+		logger.ClientLog.Info("ran scheduled purchases", "count", count)
+	}()
+
+	return nil
+}
+
+func (s *service) ProcessCommissions() error {
+	go func() {
+		count, err := s.transactionService.ProcessCommissions()
+		if err != nil {
+			logger.ClientLog.Error("failed to process commissions", "err", err)
+			return
+		}
+
+		logger.ClientLog.Info("processed commissions", "count", count)
+	}()
+
+	return nil
+}
+
+func NewService(earningSrv earning.Service, paymentSrv payment.Service, transactionSrv transaction.Service, alertSrv alert.Service, mpesaFloatSrv mpesa_float.Service) Service {
 	return &service{
 		earningService:     earningSrv,
 		paymentService:     paymentSrv,
 		transactionService: transactionSrv,
+		alertService:       alertSrv,
+		mpesaFloatService:  mpesaFloatSrv,
 
 		paymentsApi: clients.GetPaymentClient(),
-		notifyApi:   clients.GetNotifyClient(),
 	}
 }
@@ -0,0 +1,43 @@
+package landmark_moderation
+
+import (
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadUnmatchedLandmarks() ([]UnmatchedLandmark, error)
+	ReadMerchantsByLandmarks(landmarks []string) ([]uint, error)
+}
+type repository struct {
+}
+
+type UnmatchedLandmark struct {
+	Landmark  string
+	Merchants int
+}
+
+// ReadUnmatchedLandmarks counts the merchants of each free-text landmark that was not matched to a location.
+func (r *repository) ReadUnmatchedLandmarks() (landmarks []UnmatchedLandmark, err error) {
+	err = datastore.DB.Model(&entities.Merchant{}).
+		Select("landmark, COUNT(*) AS merchants").
+		Where("location_id IS NULL AND landmark IS NOT NULL AND landmark <> ''").
+		Group("landmark").
+		Order("landmark").
+		Scan(&landmarks).Error
+	return
+}
+
+func (r *repository) ReadMerchantsByLandmarks(landmarks []string) (ids []uint, err error) {
+	err = datastore.DB.Model(&entities.Merchant{}).
+		Where("location_id IS NULL AND landmark IN ?", landmarks).
+		Order("id").
+		Pluck("id", &ids).Error
+	return
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
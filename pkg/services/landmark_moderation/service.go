@@ -0,0 +1,198 @@
+package landmark_moderation
+
+import (
+	"cmp"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/location"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Service interface {
+	GetQueue(pagination datastore.Pagination) (*presenter.Paginated, error)
+	Resolve(resolution Resolution, actor string) (*presenter.LandmarkResolution, error)
+}
+
+// Resolution maps free-text landmarks to an existing landmark, or to a new one named Name under WardId.
+type Resolution struct {
+	Landmarks  []string
+	LandmarkId int
+	WardId     int
+	Name       string
+}
+
+// fillers are words merchants add around a landmark's name that say nothing about which landmark it is.
+var fillers = map[string]bool{
+	"near": true, "opposite": true, "opp": true, "next": true, "to": true, "behind": true,
+	"along": true, "off": true, "at": true, "the": true, "of": true, "besides": true, "beside": true,
+}
+
+const suggestions = 3
+
+type service struct {
+	repository         Repository
+	locationRepository location.Repository
+	locationService    location.Service
+	merchantRepository merchant.Repository
+}
+
+// GetQueue groups the unmatched landmarks by similarity, largest groups first, with the locations they most likely are.
+func (s *service) GetQueue(pagination datastore.Pagination) (*presenter.Paginated, error) {
+	landmarks, err := s.repository.ReadUnmatchedLandmarks()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := group(landmarks)
+
+	pagination = pagination.Normalize()
+	start := min((pagination.Page-1)*pagination.PageSize, len(groups))
+	page := groups[start:min(start+pagination.PageSize, len(groups))]
+
+	for i := range page {
+		page[i].Suggestions, err = s.locationService.SearchLocations(normalize(page[i].Landmark), suggestions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &presenter.Paginated{
+		Data:     page,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    int64(len(groups)),
+	}, nil
+}
+
+// Resolve points every unmatched merchant with one of the landmarks at the location, each change is audited.
+// Merchants that fail to update are reported and stay in the queue to be resolved again.
+func (s *service) Resolve(resolution Resolution, actor string) (*presenter.LandmarkResolution, error) {
+	var resolved *entities.Location
+	var err error
+	if resolution.LandmarkId != 0 {
+		resolved, err = s.locationRepository.GetLandmark(strconv.Itoa(resolution.LandmarkId))
+	} else {
+		resolved, err = s.locationService.CreateLandmark(resolution.WardId, resolution.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := s.repository.ReadMerchantsByLandmarks(resolution.Landmarks)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &presenter.LandmarkResolution{Location: location.SearchResult(*resolved, 0), Merchants: []uint{}, Failed: []uint{}}
+	audit := merchant.Audit{Actor: actor, Source: consts.AUDIT_API}
+	for _, id := range ids {
+		if _, err = s.merchantRepository.UpdateMerchantColumn(id, "location_id", resolved.Id, audit); err != nil {
+			logger.ClientLog.Error("failed to backfill merchant location", "merchant", id, "location", resolved.Id, "err", err)
+			result.Failed = append(result.Failed, id)
+			continue
+		}
+
+		result.Merchants = append(result.Merchants, id)
+	}
+
+	return result, nil
+}
+
+// group clusters landmarks whose normalized names are a typo apart or share most of their words.
+func group(landmarks []UnmatchedLandmark) []presenter.LandmarkGroup {
+	// The most used spellings come first so they name their groups
+	slices.SortStableFunc(landmarks, func(a, b UnmatchedLandmark) int {
+		return cmp.Compare(b.Merchants, a.Merchants)
+	})
+
+	groups := []presenter.LandmarkGroup{}
+	keys := []string{}
+	for _, landmark := range landmarks {
+		key := normalize(landmark.Landmark)
+		variant := presenter.LandmarkVariant{Landmark: landmark.Landmark, Merchants: landmark.Merchants}
+
+		matched := false
+		for i := range groups {
+			if similar(keys[i], key) {
+				groups[i].Variants = append(groups[i].Variants, variant)
+				groups[i].Merchants += landmark.Merchants
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			keys = append(keys, key)
+			groups = append(groups, presenter.LandmarkGroup{
+				Landmark:  landmark.Landmark,
+				Merchants: landmark.Merchants,
+				Variants:  []presenter.LandmarkVariant{variant},
+			})
+		}
+	}
+
+	slices.SortStableFunc(groups, func(a, b presenter.LandmarkGroup) int {
+		return cmp.Compare(b.Merchants, a.Merchants)
+	})
+
+	return groups
+}
+
+// normalize lowercases the landmark and drops punctuation and filler words, "Opp. the Sarit Centre" becomes "sarit centre".
+func normalize(landmark string) string {
+	words := strings.FieldsFunc(strings.ToLower(landmark), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	kept := words[:0]
+	for _, word := range words {
+		if !fillers[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		// A landmark made only of filler words is kept as typed
+		return strings.Join(words, " ")
+	}
+
+	return strings.Join(kept, " ")
+}
+
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest > 0 && float64(utils.Levenshtein(a, b))/float64(longest) <= 0.2 {
+		return true
+	}
+
+	// Word sets that mostly overlap, e.g. "sarit centre westlands" and "westlands sarit centre"
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	shared := 0
+	for _, word := range wordsA {
+		if slices.Contains(wordsB, word) {
+			shared++
+		}
+	}
+	union := len(wordsA) + len(wordsB) - shared
+
+	return union > 0 && float64(shared)/float64(union) >= 0.67
+}
+
+func NewService(r Repository, locationRepo location.Repository, locationSrv location.Service, merchantRepo merchant.Repository) Service {
+	return &service{
+		repository:         r,
+		locationRepository: locationRepo,
+		locationService:    locationSrv,
+		merchantRepository: merchantRepo,
+	}
+}
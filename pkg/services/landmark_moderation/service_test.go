@@ -0,0 +1,142 @@
+package landmark_moderation
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/location"
+	"merchants.sidooh/pkg/services/merchant"
+	"slices"
+	"testing"
+)
+
+type fakeRepository struct {
+	merchants map[uint]string
+}
+
+func (f *fakeRepository) ReadUnmatchedLandmarks() (landmarks []UnmatchedLandmark, err error) {
+	counts := map[string]int{}
+	for _, landmark := range f.merchants {
+		counts[landmark]++
+	}
+	for landmark, count := range counts {
+		landmarks = append(landmarks, UnmatchedLandmark{Landmark: landmark, Merchants: count})
+	}
+	slices.SortFunc(landmarks, func(a, b UnmatchedLandmark) int {
+		if a.Landmark < b.Landmark {
+			return -1
+		}
+		return 1
+	})
+	return
+}
+
+func (f *fakeRepository) ReadMerchantsByLandmarks(landmarks []string) (ids []uint, err error) {
+	for id, landmark := range f.merchants {
+		if slices.Contains(landmarks, landmark) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return
+}
+
+type fakeLocationRepository struct {
+	location.Repository
+}
+
+func (f *fakeLocationRepository) GetLandmark(string) (*entities.Location, error) {
+	return &entities.Location{ModelID: entities.ModelID{Id: 5}, Ward: "Parklands", Landmark: "Sarit Centre"}, nil
+}
+
+type fakeLocationService struct {
+	location.Service
+
+	created string
+}
+
+func (f *fakeLocationService) SearchLocations(query string, _ int) ([]presenter.LocationSearchResult, error) {
+	return []presenter.LocationSearchResult{{Path: query}}, nil
+}
+
+func (f *fakeLocationService) CreateLandmark(ward int, name string) (*entities.Location, error) {
+	f.created = name
+	return &entities.Location{ModelID: entities.ModelID{Id: 6}, WardId: ward, Landmark: name}, nil
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+
+	updated map[uint]interface{}
+}
+
+func (f *fakeMerchantRepository) UpdateMerchantColumn(id uint, column string, value interface{}, audit merchant.Audit) (*presenter.Merchant, error) {
+	if id == 3 {
+		return nil, errors.New("deadlock")
+	}
+	f.updated[id] = value
+	return &presenter.Merchant{Id: id}, nil
+}
+
+func newTestService(merchantRepository *fakeMerchantRepository, locationService *fakeLocationService) Service {
+	return NewService(&fakeRepository{merchants: map[uint]string{
+		1: "Sarit Centre",
+		2: "Opp. Sarit centre",
+		3: "near sarit center",
+		4: "Kangemi Market",
+		5: "Market, Kangemi",
+		6: "Sarit Centre",
+		7: "Majengo",
+	}}, &fakeLocationRepository{}, locationService, merchantRepository)
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "sarit centre", normalize("Opp. the Sarit Centre"))
+	assert.Equal(t, "stage 2", normalize("Next to stage #2"))
+	assert.Equal(t, "near", normalize(" Near "))
+}
+
+func TestService_GetQueue(t *testing.T) {
+	paginated, err := newTestService(&fakeMerchantRepository{}, &fakeLocationService{}).GetQueue(datastore.Pagination{Page: 1, PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), paginated.Total)
+
+	groups := paginated.Data.([]presenter.LandmarkGroup)
+	assert.Len(t, groups, 2)
+
+	assert.Equal(t, "Sarit Centre", groups[0].Landmark)
+	assert.Equal(t, 4, groups[0].Merchants)
+	assert.Len(t, groups[0].Variants, 3)
+	assert.Equal(t, "sarit centre", groups[0].Suggestions[0].Path)
+
+	assert.Equal(t, 2, groups[1].Merchants)
+	assert.Equal(t, []presenter.LandmarkVariant{{Landmark: "Kangemi Market", Merchants: 1}, {Landmark: "Market, Kangemi", Merchants: 1}}, groups[1].Variants)
+}
+
+func TestService_Resolve(t *testing.T) {
+	merchantRepository := &fakeMerchantRepository{updated: map[uint]interface{}{}}
+	s := newTestService(merchantRepository, &fakeLocationService{})
+
+	resolution, err := s.Resolve(Resolution{Landmarks: []string{"Sarit Centre", "Opp. Sarit centre", "near sarit center"}, LandmarkId: 100}, "admin")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []uint{1, 2, 6}, resolution.Merchants)
+	assert.Equal(t, []uint{3}, resolution.Failed)
+	assert.Equal(t, uint(5), merchantRepository.updated[1])
+	assert.Equal(t, "Sarit Centre", resolution.Location.Landmark.Landmark)
+}
+
+func TestService_Resolve_NewLandmark(t *testing.T) {
+	merchantRepository := &fakeMerchantRepository{updated: map[uint]interface{}{}}
+	locationService := &fakeLocationService{}
+	s := newTestService(merchantRepository, locationService)
+
+	resolution, err := s.Resolve(Resolution{Landmarks: []string{"Majengo"}, WardId: 13, Name: "Majengo Stage"}, "admin")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Majengo Stage", locationService.created)
+	assert.Equal(t, []uint{7}, resolution.Merchants)
+	assert.Equal(t, uint(6), merchantRepository.updated[7])
+}
@@ -0,0 +1,81 @@
+package limit
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadTierLimits(tier string) ([]entities.TransactionLimit, error)
+	ReadMerchantLimits(merchantId uint) ([]entities.TransactionLimit, error)
+	SaveLimit(limit *entities.TransactionLimit) (*entities.TransactionLimit, error)
+	DeleteMerchantLimit(merchantId uint, product string) error
+	ReadUsage(merchantId uint, product string, since time.Time) (*Usage, error)
+}
+type repository struct {
+}
+
+type Usage struct {
+	Amount float32
+	Count  int
+}
+
+func (r *repository) ReadTierLimits(tier string) (limits []entities.TransactionLimit, err error) {
+	err = datastore.DB.Where("tier", tier).Where("merchant_id", 0).Order("product").Find(&limits).Error
+	return
+}
+
+func (r *repository) ReadMerchantLimits(merchantId uint) (limits []entities.TransactionLimit, err error) {
+	err = datastore.DB.Where("merchant_id", merchantId).Order("product").Find(&limits).Error
+	return
+}
+
+// SaveLimit inserts or replaces the limit row for the tier or merchant and product.
+func (r *repository) SaveLimit(limit *entities.TransactionLimit) (*entities.TransactionLimit, error) {
+	var existing entities.TransactionLimit
+	err := datastore.DB.
+		Where("tier", limit.Tier).
+		Where("merchant_id", limit.MerchantId).
+		Where("product", limit.Product).
+		First(&existing).Error
+	if err == nil {
+		limit.Id = existing.Id
+		limit.CreatedAt = existing.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err = datastore.DB.Save(limit).Error; err != nil {
+		return nil, err
+	}
+
+	return limit, nil
+}
+
+func (r *repository) DeleteMerchantLimit(merchantId uint, product string) error {
+	return datastore.DB.
+		Where("merchant_id", merchantId).
+		Where("product", product).
+		Delete(&entities.TransactionLimit{}).Error
+}
+
+// ReadUsage sums the merchant's transactions of a product since the given time, failed ones don't count.
+func (r *repository) ReadUsage(merchantId uint, product string, since time.Time) (usage *Usage, err error) {
+	err = datastore.DB.Model(&entities.Transaction{}).
+		Select("COALESCE(SUM(amount), 0) AS amount, COUNT(*) AS count").
+		Where("merchant_id", merchantId).
+		Where("product", product).
+		Where("status <> ?", "FAILED").
+		Where("created_at >= ?", since).
+		Scan(&usage).Error
+	return
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
@@ -0,0 +1,269 @@
+package limit
+
+import (
+	"fmt"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"time"
+)
+
+type Service interface {
+	Check(merchant *presenter.Merchant, product string, amount float32) error
+	GetRemainingLimits(merchantId uint) (*[]presenter.TransactionLimit, error)
+
+	GetTierLimits(tier string) (*[]entities.TransactionLimit, error)
+	SetTierLimit(limit *entities.TransactionLimit) (*entities.TransactionLimit, error)
+	GetMerchantLimits(merchantId uint) (*[]entities.TransactionLimit, error)
+	SetMerchantLimit(limit *entities.TransactionLimit) (*entities.TransactionLimit, error)
+	RemoveMerchantLimit(merchantId uint, product string) error
+	SetMerchantTier(merchantId uint, tier, actor string) (*presenter.Merchant, error)
+}
+
+// Products that limits can be set on.
+var Products = []string{
+	consts.MPESA_FLOAT,
+	consts.CASH_WITHDRAW,
+	consts.FLOAT_PURCHASE,
+	consts.FLOAT_TRANSFER,
+	consts.FLOAT_WITHDRAW,
+	consts.EARNINGS_WITHDRAW,
+	consts.SAVINGS_WITHDRAW,
+}
+
+type service struct {
+	repository         Repository
+	merchantRepository merchant.Repository
+
+	now func() time.Time
+}
+
+// Check returns ErrLimitExceeded if the transaction would break any of the merchant's limits on the product.
+// Transactions are checked again as they are recorded, with the merchant locked, so concurrent ones can't together go
+// over the daily and monthly limits.
+func (s *service) Check(merchant *presenter.Merchant, product string, amount float32) error {
+	limit, err := s.effectiveLimit(merchant, product)
+	if err != nil {
+		return err
+	}
+
+	if limit.MinAmount != nil && amount < *limit.MinAmount {
+		return fmt.Errorf("%w: minimum amount is KES%v", pkg.ErrLimitExceeded, *limit.MinAmount)
+	}
+	if limit.MaxAmount != nil && amount > *limit.MaxAmount {
+		return fmt.Errorf("%w: maximum amount is KES%v", pkg.ErrLimitExceeded, *limit.MaxAmount)
+	}
+
+	if limit.DailyAmount != nil || limit.DailyCount != nil {
+		usage, err := s.repository.ReadUsage(merchant.Id, product, startOfDay(s.now()))
+		if err != nil {
+			return err
+		}
+		if err = checkUsage("daily", usage, limit.DailyAmount, limit.DailyCount, amount); err != nil {
+			return err
+		}
+	}
+
+	if limit.MonthlyAmount != nil || limit.MonthlyCount != nil {
+		usage, err := s.repository.ReadUsage(merchant.Id, product, startOfMonth(s.now()))
+		if err != nil {
+			return err
+		}
+		if err = checkUsage("monthly", usage, limit.MonthlyAmount, limit.MonthlyCount, amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) GetRemainingLimits(merchantId uint) (*[]presenter.TransactionLimit, error) {
+	merchant, err := s.merchantRepository.ReadMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	tierLimits, err := s.repository.ReadTierLimits(tierOf(merchant))
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.repository.ReadMerchantLimits(merchant.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits []presenter.TransactionLimit
+	for _, product := range Products {
+		limit := merge(find(tierLimits, product), find(overrides, product))
+
+		daily, err := s.repository.ReadUsage(merchant.Id, product, startOfDay(s.now()))
+		if err != nil {
+			return nil, err
+		}
+		monthly, err := s.repository.ReadUsage(merchant.Id, product, startOfMonth(s.now()))
+		if err != nil {
+			return nil, err
+		}
+
+		limits = append(limits, presenter.TransactionLimit{
+			Product:   product,
+			MinAmount: limit.MinAmount,
+			MaxAmount: limit.MaxAmount,
+			Daily:     usageOf(daily, limit.DailyAmount, limit.DailyCount),
+			Monthly:   usageOf(monthly, limit.MonthlyAmount, limit.MonthlyCount),
+		})
+	}
+
+	return &limits, nil
+}
+
+func (s *service) GetTierLimits(tier string) (*[]entities.TransactionLimit, error) {
+	limits, err := s.repository.ReadTierLimits(tier)
+	return &limits, err
+}
+
+func (s *service) SetTierLimit(limit *entities.TransactionLimit) (*entities.TransactionLimit, error) {
+	limit.MerchantId = 0
+
+	return s.repository.SaveLimit(limit)
+}
+
+func (s *service) GetMerchantLimits(merchantId uint) (*[]entities.TransactionLimit, error) {
+	limits, err := s.repository.ReadMerchantLimits(merchantId)
+	return &limits, err
+}
+
+func (s *service) SetMerchantLimit(limit *entities.TransactionLimit) (*entities.TransactionLimit, error) {
+	if _, err := s.merchantRepository.ReadMerchant(limit.MerchantId); err != nil {
+		return nil, err
+	}
+	limit.Tier = ""
+
+	return s.repository.SaveLimit(limit)
+}
+
+func (s *service) RemoveMerchantLimit(merchantId uint, product string) error {
+	return s.repository.DeleteMerchantLimit(merchantId, product)
+}
+
+func (s *service) SetMerchantTier(merchantId uint, tier, actor string) (*presenter.Merchant, error) {
+	if _, err := s.merchantRepository.ReadMerchant(merchantId); err != nil {
+		return nil, err
+	}
+
+	return s.merchantRepository.UpdateMerchantColumn(merchantId, "tier", tier, merchant.Audit{Actor: actor, Source: consts.AUDIT_API})
+}
+
+// effectiveLimit merges the merchant's overrides over their tier's limits on the product. No tier limits are seeded, so
+// on a fresh install products are unlimited until an admin sets each tier's limits with PUT /limits/tiers/:tier/:product.
+func (s *service) effectiveLimit(merchant *presenter.Merchant, product string) (*entities.TransactionLimit, error) {
+	tierLimits, err := s.repository.ReadTierLimits(tierOf(merchant))
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.repository.ReadMerchantLimits(merchant.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return merge(find(tierLimits, product), find(overrides, product)), nil
+}
+
+func checkUsage(period string, usage *Usage, amountLimit *float32, countLimit *int, amount float32) error {
+	if amountLimit != nil && usage.Amount+amount > *amountLimit {
+		return fmt.Errorf("%w: %s limit of KES%v, KES%v remaining", pkg.ErrLimitExceeded, period, *amountLimit, max(*amountLimit-usage.Amount, 0))
+	}
+	if countLimit != nil && usage.Count+1 > *countLimit {
+		return fmt.Errorf("%w: %s limit of %d transactions", pkg.ErrLimitExceeded, period, *countLimit)
+	}
+
+	return nil
+}
+
+func usageOf(usage *Usage, amountLimit *float32, countLimit *int) presenter.LimitUsage {
+	result := presenter.LimitUsage{
+		Amount:     amountLimit,
+		AmountUsed: usage.Amount,
+		Count:      countLimit,
+		CountUsed:  usage.Count,
+	}
+	if amountLimit != nil {
+		remaining := max(*amountLimit-usage.Amount, 0)
+		result.AmountRemaining = &remaining
+	}
+	if countLimit != nil {
+		remaining := max(*countLimit-usage.Count, 0)
+		result.CountRemaining = &remaining
+	}
+
+	return result
+}
+
+func find(limits []entities.TransactionLimit, product string) *entities.TransactionLimit {
+	for _, limit := range limits {
+		if limit.Product == product {
+			return &limit
+		}
+	}
+
+	return nil
+}
+
+// merge applies the limits set on the override over the tier defaults.
+func merge(tier, override *entities.TransactionLimit) *entities.TransactionLimit {
+	limit := &entities.TransactionLimit{}
+	if tier != nil {
+		*limit = *tier
+	}
+	if override == nil {
+		return limit
+	}
+
+	if override.MinAmount != nil {
+		limit.MinAmount = override.MinAmount
+	}
+	if override.MaxAmount != nil {
+		limit.MaxAmount = override.MaxAmount
+	}
+	if override.DailyAmount != nil {
+		limit.DailyAmount = override.DailyAmount
+	}
+	if override.MonthlyAmount != nil {
+		limit.MonthlyAmount = override.MonthlyAmount
+	}
+	if override.DailyCount != nil {
+		limit.DailyCount = override.DailyCount
+	}
+	if override.MonthlyCount != nil {
+		limit.MonthlyCount = override.MonthlyCount
+	}
+
+	return limit
+}
+
+func tierOf(merchant *presenter.Merchant) string {
+	if merchant.Tier == "" {
+		return consts.TIER_STANDARD
+	}
+
+	return merchant.Tier
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func NewService(r Repository, merchantRepo merchant.Repository) Service {
+	return &service{
+		repository:         r,
+		merchantRepository: merchantRepo,
+
+		now: time.Now,
+	}
+}
@@ -0,0 +1,122 @@
+package limit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	limits []entities.TransactionLimit
+	daily  Usage
+	month  Usage
+	now    time.Time
+}
+
+func (f *fakeRepository) ReadTierLimits(tier string) (limits []entities.TransactionLimit, err error) {
+	for _, limit := range f.limits {
+		if limit.Tier == tier && limit.MerchantId == 0 {
+			limits = append(limits, limit)
+		}
+	}
+	return
+}
+
+func (f *fakeRepository) ReadMerchantLimits(merchantId uint) (limits []entities.TransactionLimit, err error) {
+	for _, limit := range f.limits {
+		if limit.MerchantId == merchantId {
+			limits = append(limits, limit)
+		}
+	}
+	return
+}
+
+func (f *fakeRepository) SaveLimit(limit *entities.TransactionLimit) (*entities.TransactionLimit, error) {
+	f.limits = append(f.limits, *limit)
+	return limit, nil
+}
+
+func (f *fakeRepository) DeleteMerchantLimit(uint, string) error {
+	return nil
+}
+
+func (f *fakeRepository) ReadUsage(_ uint, _ string, since time.Time) (*Usage, error) {
+	if since.Equal(startOfDay(f.now)) {
+		return &f.daily, nil
+	}
+	return &f.month, nil
+}
+
+func float(v float32) *float32 {
+	return &v
+}
+
+func count(v int) *int {
+	return &v
+}
+
+func newTestService(repository *fakeRepository) *service {
+	repository.now = time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	return &service{repository: repository, now: func() time.Time { return repository.now }}
+}
+
+func TestCheck_PerTransaction(t *testing.T) {
+	s := newTestService(&fakeRepository{limits: []entities.TransactionLimit{
+		{Tier: consts.TIER_STANDARD, Product: consts.FLOAT_TRANSFER, MinAmount: float(10), MaxAmount: float(1000)},
+	}})
+	merchant := &presenter.Merchant{Id: 1, Tier: consts.TIER_STANDARD}
+
+	assert.ErrorIs(t, s.Check(merchant, consts.FLOAT_TRANSFER, 5), pkg.ErrLimitExceeded)
+	assert.ErrorIs(t, s.Check(merchant, consts.FLOAT_TRANSFER, 1001), pkg.ErrLimitExceeded)
+	assert.Nil(t, s.Check(merchant, consts.FLOAT_TRANSFER, 1000))
+
+	// products without limits are unlimited
+	assert.Nil(t, s.Check(merchant, consts.FLOAT_WITHDRAW, 1000000))
+}
+
+func TestCheck_Usage(t *testing.T) {
+	repository := &fakeRepository{
+		limits: []entities.TransactionLimit{
+			{Tier: consts.TIER_STANDARD, Product: consts.MPESA_FLOAT, DailyAmount: float(5000), MonthlyCount: count(3)},
+		},
+		daily: Usage{Amount: 4000, Count: 1},
+		month: Usage{Amount: 4000, Count: 2},
+	}
+	s := newTestService(repository)
+	merchant := &presenter.Merchant{Id: 1}
+
+	assert.Nil(t, s.Check(merchant, consts.MPESA_FLOAT, 1000))
+	assert.ErrorIs(t, s.Check(merchant, consts.MPESA_FLOAT, 1001), pkg.ErrLimitExceeded)
+
+	repository.month.Count = 3
+	assert.ErrorIs(t, s.Check(merchant, consts.MPESA_FLOAT, 10), pkg.ErrLimitExceeded)
+}
+
+func TestCheck_MerchantOverride(t *testing.T) {
+	s := newTestService(&fakeRepository{limits: []entities.TransactionLimit{
+		{Tier: consts.TIER_STANDARD, Product: consts.FLOAT_TRANSFER, MinAmount: float(10), MaxAmount: float(1000)},
+		{MerchantId: 2, Product: consts.FLOAT_TRANSFER, MaxAmount: float(50000)},
+	}})
+
+	assert.ErrorIs(t, s.Check(&presenter.Merchant{Id: 1}, consts.FLOAT_TRANSFER, 2000), pkg.ErrLimitExceeded)
+	assert.Nil(t, s.Check(&presenter.Merchant{Id: 2}, consts.FLOAT_TRANSFER, 2000))
+
+	// unset override fields fall back to the tier
+	assert.ErrorIs(t, s.Check(&presenter.Merchant{Id: 2}, consts.FLOAT_TRANSFER, 5), pkg.ErrLimitExceeded)
+}
+
+func TestUsageOf(t *testing.T) {
+	usage := usageOf(&Usage{Amount: 700, Count: 4}, float(500), count(10))
+
+	assert.Equal(t, float32(0), *usage.AmountRemaining)
+	assert.Equal(t, 6, *usage.CountRemaining)
+
+	usage = usageOf(&Usage{}, nil, nil)
+	assert.Nil(t, usage.AmountRemaining)
+	assert.Nil(t, usage.CountRemaining)
+}
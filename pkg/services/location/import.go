@@ -0,0 +1,330 @@
+package location
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/utils/consts"
+	"strconv"
+	"strings"
+)
+
+var locationColumns = []string{"county_id", "county", "sub_county_id", "sub_county", "ward_id", "ward", "landmark_id", "landmark"}
+
+// record is a parsed location along with where it came from in the file.
+type record struct {
+	position int
+	location entities.Location
+	problems []string
+}
+
+// jsonCounty is the nested form of the dataset, shaped like the /counties endpoints.
+type jsonCounty struct {
+	Id          int    `json:"id"`
+	County      string `json:"county"`
+	SubCounties []struct {
+		Id        int    `json:"id"`
+		SubCounty string `json:"sub_county"`
+		Wards     []struct {
+			Id        int    `json:"id"`
+			Ward      string `json:"ward"`
+			Landmarks []struct {
+				Id        int      `json:"id"`
+				Landmark  string   `json:"landmark"`
+				Latitude  *float64 `json:"latitude"`
+				Longitude *float64 `json:"longitude"`
+			} `json:"landmarks"`
+		} `json:"wards"`
+	} `json:"sub_counties"`
+}
+
+// ImportLocations upserts the dataset on landmark ids, the latitude and longitude columns are optional. Nothing is saved when any record is invalid or on a dry run,
+// the report lists the errors and what was or would be added and changed.
+func (s *service) ImportLocations(file io.Reader, format string, dryRun bool) (*presenter.LocationImport, error) {
+	var records []record
+	var err error
+	switch format {
+	case consts.LOCATION_FORMAT_CSV:
+		records, err = parseCSV(file)
+	case consts.LOCATION_FORMAT_JSON:
+		records, err = parseJSON(file)
+	default:
+		err = fmt.Errorf("%w: unsupported format %q", pkg.ErrInvalidImportFile, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: no locations", pkg.ErrInvalidImportFile)
+	}
+
+	existing, err := s.locationRepository.ReadLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &presenter.LocationImport{Total: len(records), Changes: []presenter.LocationChange{}, Errors: validate(records, existing)}
+
+	var upserts []entities.Location
+	current := map[int]entities.Location{}
+	for _, location := range existing {
+		current[location.LandmarkId] = location
+	}
+	for _, r := range records {
+		before, ok := current[r.location.LandmarkId]
+		if ok && r.location.Latitude == nil && r.location.Longitude == nil {
+			// Datasets without coordinates keep the ones already set
+			r.location.Latitude, r.location.Longitude = before.Latitude, before.Longitude
+		}
+		if !ok {
+			report.Added++
+			report.Changes = append(report.Changes, presenter.LocationChange{LandmarkId: r.location.LandmarkId, Action: consts.LOCATION_ADDED})
+			upserts = append(upserts, r.location)
+			continue
+		}
+
+		fields := diff(before, r.location)
+		if len(fields) == 0 {
+			report.Unchanged++
+			continue
+		}
+
+		report.Changed++
+		report.Changes = append(report.Changes, presenter.LocationChange{LandmarkId: r.location.LandmarkId, Action: consts.LOCATION_CHANGED, Fields: fields})
+		upserts = append(upserts, r.location)
+	}
+
+	if dryRun || len(report.Errors) > 0 || len(upserts) == 0 {
+		return report, nil
+	}
+
+	if err = s.locationRepository.UpsertLocations(upserts); err != nil {
+		return nil, err
+	}
+	report.Applied = true
+
+	// Searches should see the import right away
+	if _, err = s.locations(true); err != nil {
+		logger.ClientLog.Error("failed to refresh cached locations", "err", err)
+	}
+
+	return report, nil
+}
+
+// validate checks each record and that, once merged with the existing locations, every id keeps one name and one parent.
+func validate(records []record, existing []entities.Location) (errs []presenter.LocationImportError) {
+	errs = []presenter.LocationImportError{}
+	fail := func(r record, format string, args ...interface{}) {
+		errs = append(errs, presenter.LocationImportError{Record: r.position, LandmarkId: r.location.LandmarkId, Error: fmt.Sprintf(format, args...)})
+	}
+
+	imported := map[int]bool{}
+	for _, r := range records {
+		for _, problem := range r.problems {
+			fail(r, "%s", problem)
+		}
+
+		l := r.location
+		if l.CountyId <= 0 || l.SubCountyId <= 0 || l.WardId <= 0 || l.LandmarkId <= 0 {
+			fail(r, "ids must be positive numbers")
+		}
+		if l.County == "" || l.SubCounty == "" || l.Ward == "" || l.Landmark == "" {
+			fail(r, "names are required")
+		}
+		if len(l.County) > 32 || len(l.SubCounty) > 32 || len(l.Ward) > 32 || len(l.Landmark) > 64 {
+			fail(r, "names must be at most 32 characters, or 64 for landmarks")
+		}
+		if (l.Latitude == nil) != (l.Longitude == nil) {
+			fail(r, "latitude and longitude must be set together")
+		}
+		if l.Latitude != nil && l.Longitude != nil && (*l.Latitude < -90 || *l.Latitude > 90 || *l.Longitude < -180 || *l.Longitude > 180) {
+			fail(r, "coordinates are out of range")
+		}
+		if imported[l.LandmarkId] {
+			fail(r, "landmark %d is repeated", l.LandmarkId)
+		}
+		imported[l.LandmarkId] = true
+	}
+
+	// node is what an id at one level of the hierarchy must consistently resolve to
+	type node struct {
+		name   string
+		parent int
+	}
+	levels := []struct {
+		name  string
+		nodes map[int]node
+		of    func(entities.Location) (int, node)
+	}{
+		{"county", map[int]node{}, func(l entities.Location) (int, node) { return l.CountyId, node{l.County, 0} }},
+		{"sub-county", map[int]node{}, func(l entities.Location) (int, node) { return l.SubCountyId, node{l.SubCounty, l.CountyId} }},
+		{"ward", map[int]node{}, func(l entities.Location) (int, node) { return l.WardId, node{l.Ward, l.SubCountyId} }},
+	}
+
+	// Existing locations the file does not replace come first, so the file is checked against them
+	for _, l := range existing {
+		if imported[l.LandmarkId] {
+			continue
+		}
+		for _, level := range levels {
+			id, n := level.of(l)
+			level.nodes[id] = n
+		}
+	}
+
+	for _, r := range records {
+		for _, level := range levels {
+			id, n := level.of(r.location)
+			known, ok := level.nodes[id]
+			if !ok {
+				level.nodes[id] = n
+				continue
+			}
+			if known.name != n.name {
+				fail(r, "%s %d is named %q elsewhere, not %q", level.name, id, known.name, n.name)
+			}
+			if known.parent != n.parent {
+				fail(r, "%s %d belongs to %d elsewhere, not %d", level.name, id, known.parent, n.parent)
+			}
+		}
+	}
+
+	return
+}
+
+func diff(before, after entities.Location) map[string]presenter.LocationFieldChange {
+	fields := map[string]presenter.LocationFieldChange{}
+	compare := func(name string, from, to interface{}) {
+		if from != to {
+			fields[name] = presenter.LocationFieldChange{From: from, To: to}
+		}
+	}
+
+	compare("county_id", before.CountyId, after.CountyId)
+	compare("county", before.County, after.County)
+	compare("sub_county_id", before.SubCountyId, after.SubCountyId)
+	compare("sub_county", before.SubCounty, after.SubCounty)
+	compare("ward_id", before.WardId, after.WardId)
+	compare("ward", before.Ward, after.Ward)
+	compare("landmark", before.Landmark, after.Landmark)
+	compare("latitude", coordinateValue(before.Latitude), coordinateValue(after.Latitude))
+	compare("longitude", coordinateValue(before.Longitude), coordinateValue(after.Longitude))
+
+	return fields
+}
+
+// coordinateValue dereferences optional coordinates so they compare by value.
+func coordinateValue(coordinate *float64) interface{} {
+	if coordinate == nil {
+		return nil
+	}
+
+	return *coordinate
+}
+
+func parseCSV(file io.Reader) ([]record, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header", pkg.ErrInvalidImportFile)
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+	for _, column := range locationColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("%w: missing %s column", pkg.ErrInvalidImportFile, column)
+		}
+	}
+
+	var records []record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", pkg.ErrInvalidImportFile, err)
+		}
+
+		value := func(column string) string {
+			if i, ok := index[column]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		number := func(column string) int {
+			// Invalid ids are left as 0 and reported by validate
+			id, _ := strconv.Atoi(value(column))
+			return id
+		}
+		var problems []string
+		coordinate := func(column string) *float64 {
+			if value(column) == "" {
+				return nil
+			}
+			parsed, err := strconv.ParseFloat(value(column), 64)
+			if err != nil {
+				problems = append(problems, column+" is not a number")
+				return nil
+			}
+			return &parsed
+		}
+
+		records = append(records, record{position: line, location: entities.Location{
+			CountyId:    number("county_id"),
+			County:      value("county"),
+			SubCountyId: number("sub_county_id"),
+			SubCounty:   value("sub_county"),
+			WardId:      number("ward_id"),
+			Ward:        value("ward"),
+			LandmarkId:  number("landmark_id"),
+			Landmark:    value("landmark"),
+			Latitude:    coordinate("latitude"),
+			Longitude:   coordinate("longitude"),
+		}, problems: problems})
+	}
+
+	return records, nil
+}
+
+func parseJSON(file io.Reader) ([]record, error) {
+	var counties []jsonCounty
+	if err := json.NewDecoder(file).Decode(&counties); err != nil {
+		return nil, fmt.Errorf("%w: %s", pkg.ErrInvalidImportFile, err)
+	}
+
+	var records []record
+	for _, county := range counties {
+		for _, subCounty := range county.SubCounties {
+			for _, ward := range subCounty.Wards {
+				for _, landmark := range ward.Landmarks {
+					records = append(records, record{position: len(records) + 1, location: entities.Location{
+						CountyId:    county.Id,
+						County:      strings.TrimSpace(county.County),
+						SubCountyId: subCounty.Id,
+						SubCounty:   strings.TrimSpace(subCounty.SubCounty),
+						WardId:      ward.Id,
+						Ward:        strings.TrimSpace(ward.Ward),
+						LandmarkId:  landmark.Id,
+						Landmark:    strings.TrimSpace(landmark.Landmark),
+						Latitude:    landmark.Latitude,
+						Longitude:   landmark.Longitude,
+					}})
+				}
+			}
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,143 @@
+package location
+
+import (
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"strings"
+	"testing"
+)
+
+type fakeRepository struct {
+	Repository
+
+	locations []entities.Location
+	upserted  []entities.Location
+}
+
+func (f *fakeRepository) ReadLocations() ([]entities.Location, error) {
+	return f.locations, nil
+}
+
+func (f *fakeRepository) UpsertLocations(locations []entities.Location) error {
+	f.upserted = locations
+	return nil
+}
+
+func newTestRepository() *fakeRepository {
+	return &fakeRepository{locations: []entities.Location{
+		{CountyId: 47, County: "Nairobi", SubCountyId: 1, SubCounty: "Westlands", WardId: 10, Ward: "Parklands", LandmarkId: 100, Landmark: "Sarit Centre"},
+		{CountyId: 47, County: "Nairobi", SubCountyId: 1, SubCounty: "Westlands", WardId: 10, Ward: "Parklands", LandmarkId: 101, Landmark: "Aga Khan"},
+	}}
+}
+
+const locationsCSV = `county_id,county,sub_county_id,sub_county,ward_id,ward,landmark_id,landmark
+47,Nairobi,1,Westlands,10,Parklands,100,Sarit Centre
+47,Nairobi,1,Westlands,10,Parklands,101,Aga Khan Hospital
+47,Nairobi,1,Westlands,11,Kangemi,102,Kangemi Market
+`
+
+func TestService_ImportLocations_CSV(t *testing.T) {
+	repository := newTestRepository()
+	s := NewService(repository)
+
+	report, err := s.ImportLocations(strings.NewReader(locationsCSV), consts.LOCATION_FORMAT_CSV, true)
+	assert.NoError(t, err)
+	assert.False(t, report.Applied)
+	assert.Nil(t, repository.upserted)
+
+	report, err = s.ImportLocations(strings.NewReader(locationsCSV), consts.LOCATION_FORMAT_CSV, false)
+	assert.NoError(t, err)
+	assert.True(t, report.Applied)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 1, report.Added)
+	assert.Equal(t, 1, report.Changed)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Len(t, repository.upserted, 2)
+
+	assert.Equal(t, consts.LOCATION_CHANGED, report.Changes[0].Action)
+	assert.Equal(t, "Aga Khan Hospital", report.Changes[0].Fields["landmark"].To)
+	assert.Equal(t, 102, report.Changes[1].LandmarkId)
+}
+
+func TestService_ImportLocations_JSON(t *testing.T) {
+	repository := newTestRepository()
+	file := `[{"id": 47, "county": "Nairobi", "sub_counties": [
+		{"id": 1, "sub_county": "Westlands", "wards": [{"id": 10, "ward": "Parklands", "landmarks": [{"id": 100, "landmark": "Sarit Centre"}]}]}
+	]}]`
+
+	report, err := NewService(repository).ImportLocations(strings.NewReader(file), consts.LOCATION_FORMAT_JSON, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.False(t, report.Applied)
+	assert.Nil(t, repository.upserted)
+}
+
+func TestService_ImportLocations_Inconsistent(t *testing.T) {
+	repository := newTestRepository()
+	file := `county_id,county,sub_county_id,sub_county,ward_id,ward,landmark_id,landmark
+47,Nairobi City,1,Westlands,10,Parklands,102,Westgate
+1,Mombasa,1,Westlands,12,Kilindini,103,Likoni Ferry
+1,Mombasa,2,Mvita,13,Mji wa Kale,103,Fort Jesus
+1,Mombasa,2,Mvita,13,Mji wa Kale,x,
+`
+
+	report, err := NewService(repository).ImportLocations(strings.NewReader(file), consts.LOCATION_FORMAT_CSV, false)
+	assert.NoError(t, err)
+	assert.False(t, report.Applied)
+	assert.Nil(t, repository.upserted)
+
+	var errs []string
+	for _, e := range report.Errors {
+		errs = append(errs, e.Error)
+	}
+	assert.Equal(t, []string{
+		"landmark 103 is repeated",
+		"ids must be positive numbers",
+		"names are required",
+		`county 47 is named "Nairobi" elsewhere, not "Nairobi City"`,
+		"sub-county 1 belongs to 47 elsewhere, not 1",
+	}, errs)
+	assert.Equal(t, 4, report.Errors[0].Record)
+}
+
+func TestService_ImportLocations_InvalidFile(t *testing.T) {
+	s := NewService(newTestRepository())
+
+	_, err := s.ImportLocations(strings.NewReader("county_id,county\n"), consts.LOCATION_FORMAT_CSV, false)
+	assert.ErrorIs(t, err, pkg.ErrInvalidImportFile)
+
+	_, err = s.ImportLocations(strings.NewReader("{}"), consts.LOCATION_FORMAT_JSON, false)
+	assert.ErrorIs(t, err, pkg.ErrInvalidImportFile)
+
+	_, err = s.ImportLocations(strings.NewReader(locationsCSV), "xml", false)
+	assert.ErrorIs(t, err, pkg.ErrInvalidImportFile)
+}
+
+func TestService_ImportLocations_Coordinates(t *testing.T) {
+	repository := newTestRepository()
+	latitude, longitude := -1.2614, 36.8025
+	repository.locations[1].Latitude, repository.locations[1].Longitude = &latitude, &longitude
+
+	file := `county_id,county,sub_county_id,sub_county,ward_id,ward,landmark_id,landmark,latitude,longitude
+47,Nairobi,1,Westlands,10,Parklands,100,Sarit Centre,-1.2606,36.8024
+47,Nairobi,1,Westlands,10,Parklands,101,Aga Khan,,
+`
+	report, err := NewService(repository).ImportLocations(strings.NewReader(file), consts.LOCATION_FORMAT_CSV, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Changed)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Equal(t, -1.2606, report.Changes[0].Fields["latitude"].To)
+	assert.Nil(t, report.Changes[0].Fields["latitude"].From)
+
+	file = `county_id,county,sub_county_id,sub_county,ward_id,ward,landmark_id,landmark,latitude,longitude
+47,Nairobi,1,Westlands,10,Parklands,100,Sarit Centre,-1.2606,
+47,Nairobi,1,Westlands,10,Parklands,101,Aga Khan,north,36.8
+`
+	report, err = NewService(repository).ImportLocations(strings.NewReader(file), consts.LOCATION_FORMAT_CSV, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "latitude and longitude must be set together", report.Errors[0].Error)
+	assert.Equal(t, "latitude is not a number", report.Errors[1].Error)
+}
@@ -1,6 +1,8 @@
 package location
 
 import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"merchants.sidooh/api/presenter"
 	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
@@ -13,6 +15,10 @@ type Repository interface {
 	ReadWards(subCounty int) (*[]presenter.Ward, error)
 	ReadLandmarks(ward int) (*[]presenter.Landmark, error)
 	GetLandmark(landmarkId string) (*entities.Location, error)
+	ReadLocations() ([]entities.Location, error)
+	UpsertLocations(locations []entities.Location) error
+	ReadWardLandmarks(ward int) ([]entities.Location, error)
+	CreateLandmark(location *entities.Location) (*entities.Location, error)
 }
 type repository struct {
 }
@@ -68,6 +74,44 @@ func (r *repository) GetLandmark(landmarkId string) (landmark *entities.Location
 	return
 }
 
+func (r *repository) ReadLocations() (locations []entities.Location, err error) {
+	err = datastore.DB.Order("landmark_id").Find(&locations).Error
+	return
+}
+
+// UpsertLocations inserts the locations or updates the hierarchy of those whose landmark id exists, all or none are saved.
+func (r *repository) UpsertLocations(locations []entities.Location) error {
+	return datastore.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "landmark_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"county_id", "county", "sub_county_id", "sub_county", "ward_id", "ward", "landmark", "latitude", "longitude"}),
+		}).CreateInBatches(&locations, 200).Error
+	})
+}
+
+func (r *repository) ReadWardLandmarks(ward int) (locations []entities.Location, err error) {
+	err = datastore.DB.Where("ward_id", ward).Order("landmark_id").Find(&locations).Error
+	return
+}
+
+// CreateLandmark saves the location under the next free landmark id.
+func (r *repository) CreateLandmark(location *entities.Location) (*entities.Location, error) {
+	err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+		var last int
+		if err := tx.Model(&entities.Location{}).Select("COALESCE(MAX(landmark_id), 0)").Scan(&last).Error; err != nil {
+			return err
+		}
+
+		location.LandmarkId = last + 1
+		return tx.Create(&location).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
 // NewRepo is the single instance repo that is being created.
 func NewRepo() Repository {
 	return &repository{}
@@ -0,0 +1,157 @@
+package location
+
+import (
+	"github.com/spf13/viper"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+const locationsKey = "locations"
+
+// Match qualities of a query term against a word, exact matches rank highest and typos lowest
+const (
+	matchExact     = 1.0
+	matchPrefix    = 0.8
+	matchSubstring = 0.6
+	matchFuzzy     = 0.4
+)
+
+// searchLevel weighs matches on more specific names higher, a landmark match beats a county match.
+type searchLevel struct {
+	weight float64
+	name   func(entities.Location) string
+}
+
+var searchLevels = []searchLevel{
+	{4, func(l entities.Location) string { return l.Landmark }},
+	{3, func(l entities.Location) string { return l.Ward }},
+	{2, func(l entities.Location) string { return l.SubCounty }},
+	{1, func(l entities.Location) string { return l.County }},
+}
+
+// SearchLocations ranks landmarks by how well every term in the query matches their names along the hierarchy.
+// The dataset is small and rarely changes, so it is matched in memory from a cached copy.
+func (s *service) SearchLocations(query string, limit int) ([]presenter.LocationSearchResult, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	results := []presenter.LocationSearchResult{}
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	locations, err := s.locations(false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, location := range locations {
+		if score := scoreLocation(location, terms); score > 0 {
+			results = append(results, SearchResult(location, score))
+		}
+	}
+
+	slices.SortStableFunc(results, func(a, b presenter.LocationSearchResult) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		if len(a.Landmark.Landmark) != len(b.Landmark.Landmark) {
+			return len(a.Landmark.Landmark) - len(b.Landmark.Landmark)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// locations returns the cached dataset, reading it again when it expired or refresh is set.
+func (s *service) locations(refresh bool) ([]entities.Location, error) {
+	if cached := s.cache.Get(locationsKey); cached != nil && !refresh {
+		return *cached, nil
+	}
+
+	locations, err := s.locationRepository.ReadLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	return *s.cache.Set(locationsKey, locations, searchCacheTTL()), nil
+}
+
+// scoreLocation sums each term's best weighted match, locations missing any of the terms score 0.
+func scoreLocation(location entities.Location, terms []string) (score float64) {
+	for _, term := range terms {
+		best := 0.0
+		for _, level := range searchLevels {
+			for _, word := range strings.Fields(strings.ToLower(level.name(location))) {
+				best = max(best, level.weight*matchWord(term, word))
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+
+		score += best
+	}
+
+	return
+}
+
+func matchWord(term, word string) float64 {
+	switch {
+	case term == word:
+		return matchExact
+	case strings.HasPrefix(word, term):
+		return matchPrefix
+	case len(term) >= 3 && strings.Contains(word, term):
+		return matchSubstring
+	}
+
+	// Typos are tolerated on longer terms, against the whole word or the start of it while the term is being typed
+	allowed := 0
+	switch {
+	case len(term) >= 7:
+		allowed = 2
+	case len(term) >= 4:
+		allowed = 1
+	}
+	if allowed == 0 {
+		return 0
+	}
+
+	if utils.Levenshtein(term, word) <= allowed || (len(word) > len(term) && utils.Levenshtein(term, word[:len(term)]) <= allowed) {
+		return matchFuzzy
+	}
+
+	return 0
+}
+
+// SearchResult presents the location with its path up the hierarchy.
+func SearchResult(location entities.Location, score float64) presenter.LocationSearchResult {
+	return presenter.LocationSearchResult{
+		County:    presenter.County{CountyId: uint(location.CountyId), County: location.County},
+		SubCounty: presenter.SubCounty{SubCountyId: uint(location.SubCountyId), SubCounty: location.SubCounty},
+		Ward:      presenter.Ward{WardId: uint(location.WardId), Ward: location.Ward},
+		Landmark:  presenter.Landmark{LandmarkId: uint(location.LandmarkId), Landmark: location.Landmark},
+		Path:      strings.Join([]string{location.Landmark, location.Ward, location.SubCounty, location.County}, ", "),
+		Score:     score,
+	}
+}
+
+func searchCacheTTL() time.Duration {
+	ttl := viper.GetDuration("LOCATION_CACHE_TTL")
+	if ttl <= 0 {
+		return 15 * time.Minute
+	}
+
+	return ttl
+}
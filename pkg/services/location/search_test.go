@@ -0,0 +1,58 @@
+package location
+
+import (
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/pkg/entities"
+	"testing"
+)
+
+func newSearchRepository() *fakeRepository {
+	return &fakeRepository{locations: []entities.Location{
+		{CountyId: 47, County: "Nairobi", SubCountyId: 1, SubCounty: "Westlands", WardId: 10, Ward: "Parklands", LandmarkId: 100, Landmark: "Sarit Centre"},
+		{CountyId: 47, County: "Nairobi", SubCountyId: 1, SubCounty: "Westlands", WardId: 11, Ward: "Kangemi", LandmarkId: 101, Landmark: "Kangemi Market"},
+		{CountyId: 47, County: "Nairobi", SubCountyId: 2, SubCounty: "Starehe", WardId: 12, Ward: "Nairobi Central", LandmarkId: 102, Landmark: "Kencom"},
+		{CountyId: 1, County: "Mombasa", SubCountyId: 3, SubCounty: "Mvita", WardId: 13, Ward: "Majengo", LandmarkId: 103, Landmark: "Majengo Market"},
+	}}
+}
+
+func landmarks(t *testing.T, s Service, query string) (ids []uint) {
+	results, err := s.SearchLocations(query, 10)
+	assert.NoError(t, err)
+	for _, result := range results {
+		ids = append(ids, result.Landmark.LandmarkId)
+	}
+	return
+}
+
+func TestService_SearchLocations(t *testing.T) {
+	s := NewService(newSearchRepository())
+
+	// Landmark matches rank above ward matches
+	assert.Equal(t, []uint{101, 103}, landmarks(t, s, "market"))
+	assert.Equal(t, []uint{101}, landmarks(t, s, "kangemi"))
+
+	// Prefixes and every term have to match, "mark" is a typo away from Parklands
+	assert.Equal(t, []uint{101, 100}, landmarks(t, s, "mark westl"))
+	assert.Equal(t, []uint{103}, landmarks(t, s, "market mombasa"))
+
+	// Typos
+	assert.Equal(t, []uint{100}, landmarks(t, s, "sarrit"))
+	assert.Equal(t, []uint{103}, landmarks(t, s, "majngo mark"))
+
+	// County names match all their landmarks, below landmark matches
+	assert.Equal(t, []uint{102, 100, 101}, landmarks(t, s, "nairobi"))
+
+	assert.Empty(t, landmarks(t, s, "kisumu"))
+	assert.Empty(t, landmarks(t, s, " "))
+}
+
+func TestService_SearchLocations_Result(t *testing.T) {
+	s := NewService(newSearchRepository())
+
+	results, err := s.SearchLocations("kencom", 1)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Kencom, Nairobi Central, Starehe, Nairobi", results[0].Path)
+	assert.Equal(t, uint(12), results[0].Ward.WardId)
+	assert.Equal(t, uint(47), results[0].County.CountyId)
+}
@@ -1,16 +1,29 @@
 package location
 
-import "merchants.sidooh/api/presenter"
+import (
+	"gorm.io/gorm"
+	"io"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/cache"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"strings"
+)
 
 type Service interface {
 	GetCounties() (*[]presenter.County, error)
 	GetSubCounties(county int) (*[]presenter.SubCounty, error)
 	GetWards(subCounty int) (*[]presenter.Ward, error)
 	GetLandmarks(ward int) (*[]presenter.Landmark, error)
+	ImportLocations(file io.Reader, format string, dryRun bool) (*presenter.LocationImport, error)
+	SearchLocations(query string, limit int) ([]presenter.LocationSearchResult, error)
+	CreateLandmark(ward int, name string) (*entities.Location, error)
 }
 
 type service struct {
 	locationRepository Repository
+
+	cache cache.ICache[string, []entities.Location]
 }
 
 func (s *service) GetCounties() (*[]presenter.County, error) {
@@ -29,8 +42,48 @@ func (s *service) GetLandmarks(ward int) (*[]presenter.Landmark, error) {
 	return s.locationRepository.ReadLandmarks(ward)
 }
 
+// CreateLandmark adds a landmark under the ward, a landmark of the same name in the ward is returned instead.
+func (s *service) CreateLandmark(ward int, name string) (*entities.Location, error) {
+	landmarks, err := s.locationRepository.ReadWardLandmarks(ward)
+	if err != nil {
+		return nil, err
+	}
+	if len(landmarks) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	name = strings.TrimSpace(name)
+	for _, landmark := range landmarks {
+		if strings.EqualFold(landmark.Landmark, name) {
+			return &landmark, nil
+		}
+	}
+
+	parent := landmarks[0]
+	landmark, err := s.locationRepository.CreateLandmark(&entities.Location{
+		CountyId:    parent.CountyId,
+		County:      parent.County,
+		SubCountyId: parent.SubCountyId,
+		SubCounty:   parent.SubCounty,
+		WardId:      parent.WardId,
+		Ward:        parent.Ward,
+		Landmark:    name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.locations(true); err != nil {
+		logger.ClientLog.Error("failed to refresh cached locations", "err", err)
+	}
+
+	return landmark, nil
+}
+
 func NewService(location Repository) Service {
 	return &service{
 		locationRepository: location,
+
+		cache: cache.New[string, []entities.Location](),
 	}
 }
@@ -0,0 +1,57 @@
+package location
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/pkg/entities"
+	"testing"
+)
+
+type fakeLandmarkRepository struct {
+	*fakeRepository
+
+	created *entities.Location
+}
+
+func (f *fakeLandmarkRepository) ReadWardLandmarks(ward int) (landmarks []entities.Location, err error) {
+	for _, location := range f.locations {
+		if location.WardId == ward {
+			landmarks = append(landmarks, location)
+		}
+	}
+	return
+}
+
+func (f *fakeLandmarkRepository) CreateLandmark(location *entities.Location) (*entities.Location, error) {
+	location.LandmarkId = 200
+	f.created = location
+	f.locations = append(f.locations, *location)
+	return location, nil
+}
+
+func TestService_CreateLandmark(t *testing.T) {
+	repository := &fakeLandmarkRepository{fakeRepository: newSearchRepository()}
+	s := NewService(repository)
+
+	landmark, err := s.CreateLandmark(10, " Westgate Mall ")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, landmark.LandmarkId)
+	assert.Equal(t, "Westgate Mall", landmark.Landmark)
+	assert.Equal(t, "Parklands", landmark.Ward)
+	assert.Equal(t, 47, landmark.CountyId)
+
+	// Searchable right away
+	results, err := s.SearchLocations("westgate", 5)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// Existing names are reused
+	repository.created = nil
+	landmark, err = s.CreateLandmark(10, "sarit centre")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, landmark.LandmarkId)
+	assert.Nil(t, repository.created)
+
+	_, err = s.CreateLandmark(99, "Nowhere")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
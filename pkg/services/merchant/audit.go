@@ -0,0 +1,71 @@
+package merchant
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm/schema"
+	"merchants.sidooh/pkg/entities"
+	"reflect"
+)
+
+// Audit identifies who made a change to a merchant and through which part of the service.
+type Audit struct {
+	Actor  string
+	Source string
+}
+
+// Change is a field's value before and after an update, nil values are nulls.
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+var naming = schema.NamingStrategy{}
+
+// Diff compares the merchant's own columns, keyed by column name, timestamps are left out.
+func Diff(before, after *entities.Merchant) map[string]Change {
+	changes := map[string]Change{}
+
+	b, a := reflect.ValueOf(*before), reflect.ValueOf(*after)
+	for i := 0; i < b.NumField(); i++ {
+		field := b.Type().Field(i)
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+
+		from, to := value(b.Field(i)), value(a.Field(i))
+		if fmt.Sprint(from) != fmt.Sprint(to) {
+			changes[naming.ColumnName("", field.Name)] = Change{From: from, To: to}
+		}
+	}
+
+	return changes
+}
+
+// auditOf serializes the changes between the two states, it returns nil when nothing changed.
+func auditOf(before, after *entities.Merchant, audit Audit) *entities.MerchantAudit {
+	changes := Diff(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	data, _ := json.Marshal(changes)
+
+	return &entities.MerchantAudit{
+		Changes:    data,
+		Actor:      audit.Actor,
+		Source:     audit.Source,
+		MerchantId: after.Id,
+	}
+}
+
+func value(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,54 @@
+package merchant
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	name, renamed := "Mama Mboga", "Mama Mboga Shop"
+	location := uint(4)
+
+	before := &entities.Merchant{
+		ModelID:      entities.ModelID{Id: 1},
+		FirstName:    "Jane",
+		BusinessName: &name,
+		Status:       consts.MERCHANT_PENDING_KYB,
+	}
+	after := &entities.Merchant{
+		ModelID:      entities.ModelID{Id: 1},
+		FirstName:    "Jane",
+		BusinessName: &renamed,
+		Status:       consts.MERCHANT_PENDING_KYB,
+		LocationId:   &location,
+
+		ModelTimeStamps: entities.ModelTimeStamps{UpdatedAt: time.Now()},
+	}
+
+	assert.Equal(t, map[string]Change{
+		"business_name": {From: name, To: renamed},
+		"location_id":   {From: nil, To: location},
+	}, Diff(before, after))
+
+	assert.Empty(t, Diff(before, before))
+}
+
+func TestAuditOf(t *testing.T) {
+	before := &entities.Merchant{ModelID: entities.ModelID{Id: 1}, Tier: consts.TIER_STANDARD}
+	after := &entities.Merchant{ModelID: entities.ModelID{Id: 1}, Tier: "GOLD"}
+
+	audit := auditOf(before, after, Audit{Actor: "7", Source: consts.AUDIT_API})
+	assert.Equal(t, uint(1), audit.MerchantId)
+	assert.Equal(t, "7", audit.Actor)
+	assert.Equal(t, consts.AUDIT_API, audit.Source)
+
+	var changes map[string]Change
+	assert.NoError(t, json.Unmarshal(audit.Changes, &changes))
+	assert.Equal(t, Change{From: consts.TIER_STANDARD, To: "GOLD"}, changes["tier"])
+
+	assert.Nil(t, auditOf(before, before, Audit{}))
+}
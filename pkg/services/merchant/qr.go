@@ -0,0 +1,123 @@
+package merchant
+
+import (
+	"errors"
+	"github.com/skip2/go-qrcode"
+	"github.com/spf13/viper"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/utils"
+	"strings"
+	"unicode"
+)
+
+// EMVCo merchant presented QR data objects
+const (
+	emvPayloadFormat     = "00"
+	emvInitiationMethod  = "01"
+	emvMerchantAccount   = "26"
+	emvMerchantCategory  = "52"
+	emvCurrency          = "53"
+	emvCountry           = "58"
+	emvMerchantName      = "59"
+	emvMerchantCity      = "60"
+	emvAccountGUID       = "00"
+	emvAccountCode       = "01"
+	emvStaticInitiation  = "11"
+	emvCurrencyKES       = "404"
+	emvMerchantNameLimit = 25
+	emvMerchantCityLimit = 15
+
+	qrImageSize = 256
+)
+
+// GetMerchantQR returns the merchant's static QR payload, which identifies them by code under our GUID.
+func (s *service) GetMerchantQR(id uint) (string, error) {
+	merchant, err := s.repository.ReadMerchant(id)
+	if err != nil {
+		return "", err
+	}
+	if merchant.Code == "" {
+		return "", pkg.ErrInvalidMerchantCode
+	}
+
+	name := merchant.BusinessName
+	if name == "" {
+		name = merchant.FirstName + " " + merchant.LastName
+	}
+
+	account, err := utils.EncodeEMVFields(
+		utils.EMVField{Id: emvAccountGUID, Value: qrGUID()},
+		utils.EMVField{Id: emvAccountCode, Value: merchant.Code},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.EncodeEMVPayload(
+		utils.EMVField{Id: emvPayloadFormat, Value: "01"},
+		utils.EMVField{Id: emvInitiationMethod, Value: emvStaticInitiation},
+		utils.EMVField{Id: emvMerchantAccount, Value: account},
+		utils.EMVField{Id: emvMerchantCategory, Value: qrConfig("QR_MERCHANT_CATEGORY", "6012")},
+		utils.EMVField{Id: emvCurrency, Value: emvCurrencyKES},
+		utils.EMVField{Id: emvCountry, Value: "KE"},
+		utils.EMVField{Id: emvMerchantName, Value: emvText(name, emvMerchantNameLimit)},
+		utils.EMVField{Id: emvMerchantCity, Value: emvText(qrConfig("QR_MERCHANT_CITY", "Nairobi"), emvMerchantCityLimit)},
+	)
+}
+
+// GetMerchantQRImage renders the merchant's QR payload as a PNG of size pixels square, 256 if size is 0.
+func (s *service) GetMerchantQRImage(id uint, size int) ([]byte, error) {
+	payload, err := s.GetMerchantQR(id)
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		size = qrImageSize
+	}
+
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
+
+// ResolveQR returns the merchant a scanned payload was generated for.
+func (s *service) ResolveQR(payload string) (*presenter.Merchant, error) {
+	fields, err := utils.ParseEMVPayload(strings.TrimSpace(payload))
+	if err != nil {
+		return nil, errors.Join(pkg.ErrInvalidQRPayload, err)
+	}
+
+	account, err := utils.ParseEMVFields(fields[emvMerchantAccount])
+	if err != nil || account[emvAccountGUID] != qrGUID() || account[emvAccountCode] == "" {
+		return nil, pkg.ErrInvalidQRPayload
+	}
+
+	return s.GetMerchantByCode(account[emvAccountCode])
+}
+
+func qrGUID() string {
+	return qrConfig("QR_GUID", "ke.co.sidooh")
+}
+
+func qrConfig(key, fallback string) string {
+	if value := viper.GetString(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+// emvText keeps the printable ascii characters EMVCo allows in names and truncates to the field's limit.
+func emvText(value string, limit int) string {
+	value = strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+
+	if len(value) > limit {
+		value = strings.TrimSpace(value[:limit])
+	}
+
+	return value
+}
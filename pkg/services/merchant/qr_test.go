@@ -0,0 +1,122 @@
+package merchant
+
+import (
+	"bytes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"image/png"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/utils"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func (f *fakeRepository) ReadMerchantByCode(code uint) (*presenter.Merchant, error) {
+	for _, merchant := range f.records {
+		if merchant.Code == strconv.Itoa(int(code)) {
+			return f.ReadMerchant(merchant.Id)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func newQRTestService() *service {
+	s, _ := newTransitionTestService(
+		presenter.Merchant{Id: 1, Code: "123455", BusinessName: "Mama Mboga's Kiosk & Café"},
+		presenter.Merchant{Id: 2, FirstName: "John", LastName: "Doe"},
+	)
+	return s
+}
+
+func TestService_GetMerchantQR_RoundTrip(t *testing.T) {
+	s := newQRTestService()
+
+	payload, err := s.GetMerchantQR(1)
+	assert.NoError(t, err)
+
+	fields, err := utils.ParseEMVPayload(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "Mama Mboga's Kiosk & Caf", fields[emvMerchantName])
+	assert.Equal(t, emvCurrencyKES, fields[emvCurrency])
+
+	merchant, err := s.ResolveQR(" " + payload + "\n")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), merchant.Id)
+
+	_, err = s.GetMerchantQR(2)
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchantCode)
+	_, err = s.GetMerchantQR(99)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_ResolveQR_Invalid(t *testing.T) {
+	s := newQRTestService()
+
+	payload, err := s.GetMerchantQR(1)
+	assert.NoError(t, err)
+
+	// A corrupted checksum
+	last := "0"
+	if strings.HasSuffix(payload, "0") {
+		last = "1"
+	}
+	_, err = s.ResolveQR(payload[:len(payload)-1] + last)
+	assert.ErrorIs(t, err, pkg.ErrInvalidQRPayload)
+
+	// Another scheme's payload for the same code
+	foreign, err := emvPayload(emvMerchantAccount, "ke.co.other", "123455")
+	assert.NoError(t, err)
+	_, err = s.ResolveQR(foreign)
+	assert.ErrorIs(t, err, pkg.ErrInvalidQRPayload)
+
+	// Our scheme with a code failing its check digit
+	mistyped, err := emvPayload(emvMerchantAccount, qrGUID(), "123456")
+	assert.NoError(t, err)
+	_, err = s.ResolveQR(mistyped)
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchantCode)
+}
+
+// emvPayload encodes a payload with only the merchant account field, of the GUID and code.
+func emvPayload(id, guid, code string) (string, error) {
+	account, err := utils.EncodeEMVFields(
+		utils.EMVField{Id: emvAccountGUID, Value: guid},
+		utils.EMVField{Id: emvAccountCode, Value: code},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.EncodeEMVPayload(utils.EMVField{Id: emvPayloadFormat, Value: "01"}, utils.EMVField{Id: id, Value: account})
+}
+
+func TestService_GetMerchantQR_TooLong(t *testing.T) {
+	s := newQRTestService()
+
+	viper.Set("QR_GUID", strings.Repeat("ke.co.sidooh.", 8))
+	t.Cleanup(func() { viper.Set("QR_GUID", "") })
+
+	_, err := s.GetMerchantQR(1)
+	assert.ErrorIs(t, err, utils.ErrInvalidEMVPayload)
+}
+
+func TestService_GetMerchantQRImage(t *testing.T) {
+	s := newQRTestService()
+
+	image, err := s.GetMerchantQRImage(1, 0)
+	assert.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(image))
+	assert.NoError(t, err)
+	assert.Equal(t, qrImageSize, decoded.Bounds().Dx())
+
+	image, err = s.GetMerchantQRImage(1, 512)
+	assert.NoError(t, err)
+	decoded, err = png.Decode(bytes.NewReader(image))
+	assert.NoError(t, err)
+	assert.Equal(t, 512, decoded.Bounds().Dx())
+
+	_, err = s.GetMerchantQRImage(2, 0)
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchantCode)
+}
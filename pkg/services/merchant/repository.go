@@ -1,21 +1,40 @@
 package merchant
 
 import (
+	"gorm.io/gorm"
 	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
 	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Repository interface allows us to access the CRUD Operations here.
 type Repository interface {
 	CreateMerchant(merchant *entities.Merchant) (*entities.Merchant, error)
 	ReadMerchants(filters Filters) (*[]presenter.Merchant, error)
+	SearchMerchants(filters SearchFilters) (*[]presenter.Merchant, int64, error)
+	ReadMerchantsWithin(minLat, maxLat, minLng, maxLng float64) ([]presenter.NearbyMerchant, error)
 	ReadMerchant(id uint) (*presenter.Merchant, error)
 	ReadMerchantByAccount(accountId uint) (*presenter.Merchant, error)
 	ReadMerchantByCode(code uint) (*presenter.Merchant, error)
+	ReadMerchantByPhone(phone string) (*presenter.Merchant, error)
 	ReadMerchantByIdNumber(idNumber string) (*presenter.Merchant, error)
-	UpdateMerchant(merchant *entities.Merchant) (*presenter.Merchant, error)
-	GetMerchantCodes() []uint
+	UpdateMerchant(merchant *entities.Merchant, audit Audit) (*presenter.Merchant, error)
+	UpdateMerchantColumn(id uint, column string, value interface{}, audit Audit) (*presenter.Merchant, error)
+
+	ReserveCode(code *entities.MerchantCode) (*entities.MerchantCode, error)
+	RetireCode(code uint, reason, actor string) error
+
+	CreateStatusChange(change *entities.MerchantStatusChange) (*entities.MerchantStatusChange, error)
+	TransitionStatus(change *entities.MerchantStatusChange, audit Audit) (*presenter.Merchant, error)
+	ReadStatusChanges(merchantId uint) (*[]presenter.MerchantStatusChange, error)
+	ReadLastStatusChangeTo(merchantId uint, status string) (*entities.MerchantStatusChange, error)
+
+	ReadAudits(merchantId uint, pagination datastore.Pagination) (*[]presenter.MerchantAudit, int64, error)
 }
 type repository struct {
 }
@@ -26,6 +45,30 @@ type Filters struct {
 	Accounts []string
 }
 
+type SearchFilters struct {
+	datastore.Pagination
+
+	Query       string
+	Status      string
+	CountyId    int
+	SubCountyId int
+	WardId      int
+	From        *time.Time
+	To          *time.Time
+
+	// Sort is a column name, prefixed with "-" for descending order
+	Sort string
+}
+
+var sortableColumns = map[string]string{
+	"id":            "merchants.id",
+	"created_at":    "merchants.created_at",
+	"business_name": "merchants.business_name",
+	"first_name":    "merchants.first_name",
+	"last_name":     "merchants.last_name",
+	"code":          "merchants.code",
+}
+
 func (r *repository) CreateMerchant(merchant *entities.Merchant) (*entities.Merchant, error) {
 	result := datastore.DB.Create(&merchant)
 	if result.Error != nil {
@@ -49,6 +92,80 @@ func (r *repository) ReadMerchants(filters Filters) (merchants *[]presenter.Merc
 	return
 }
 
+func (r *repository) SearchMerchants(filters SearchFilters) (merchants *[]presenter.Merchant, total int64, err error) {
+	query := datastore.DB.Model(&entities.Merchant{})
+
+	if filters.CountyId > 0 || filters.SubCountyId > 0 || filters.WardId > 0 {
+		query = query.Joins("JOIN locations ON locations.id = merchants.location_id")
+		if filters.CountyId > 0 {
+			query = query.Where("locations.county_id", filters.CountyId)
+		}
+		if filters.SubCountyId > 0 {
+			query = query.Where("locations.sub_county_id", filters.SubCountyId)
+		}
+		if filters.WardId > 0 {
+			query = query.Where("locations.ward_id", filters.WardId)
+		}
+	}
+
+	// Every term has to match at least one of the searchable columns, so "john kiosk" narrows down results
+	for _, term := range strings.Fields(filters.Query) {
+		like := "%" + term + "%"
+		condition := datastore.DB.
+			Where("merchants.first_name LIKE ?", like).
+			Or("merchants.last_name LIKE ?", like).
+			Or("merchants.business_name LIKE ?", like).
+			Or("merchants.phone LIKE ?", like).
+			Or("merchants.id_number LIKE ?", term+"%")
+		if code, err := strconv.Atoi(term); err == nil {
+			condition = condition.Or("merchants.code = ?", code)
+		}
+
+		query = query.Where(condition)
+	}
+
+	if filters.Status != "" {
+		query = query.Where("merchants.status", filters.Status)
+	}
+	if filters.From != nil {
+		query = query.Where("merchants.created_at >= ?", filters.From)
+	}
+	if filters.To != nil {
+		query = query.Where("merchants.created_at < ?", filters.To)
+	}
+
+	err = query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order := "merchants.id desc"
+	if column, ok := sortableColumns[strings.TrimPrefix(filters.Sort, "-")]; ok {
+		order = column
+		if strings.HasPrefix(filters.Sort, "-") {
+			order += " desc"
+		}
+	}
+
+	err = query.Select("merchants.*").Order(order).Scopes(datastore.Paginate(filters.Pagination)).Find(&merchants).Error
+
+	return
+}
+
+// ReadMerchantsWithin reads active merchants with a float account whose own coordinates, or else their landmark's, fall in the box.
+func (r *repository) ReadMerchantsWithin(minLat, maxLat, minLng, maxLng float64) (merchants []presenter.NearbyMerchant, err error) {
+	err = datastore.DB.Table("merchants").
+		Select("merchants.id, merchants.business_name, merchants.code, locations.landmark, locations.ward, "+
+			"COALESCE(merchants.latitude, locations.latitude) AS latitude, COALESCE(merchants.longitude, locations.longitude) AS longitude").
+		Joins("LEFT JOIN locations ON locations.id = merchants.location_id").
+		Where("merchants.status = ? AND merchants.float_account_id IS NOT NULL", consts.MERCHANT_ACTIVE).
+		Where(datastore.DB.
+			Where("merchants.latitude BETWEEN ? AND ? AND merchants.longitude BETWEEN ? AND ?", minLat, maxLat, minLng, maxLng).
+			Or("merchants.latitude IS NULL AND locations.latitude BETWEEN ? AND ? AND locations.longitude BETWEEN ? AND ?", minLat, maxLat, minLng, maxLng)).
+		Scan(&merchants).Error
+	return
+}
+
 func (r *repository) ReadMerchant(id uint) (merchant *presenter.Merchant, err error) {
 	err = datastore.DB.First(&merchant, id).Error
 	return
@@ -64,22 +181,137 @@ func (r *repository) ReadMerchantByCode(code uint) (merchant *presenter.Merchant
 	return
 }
 
+func (r *repository) ReadMerchantByPhone(phone string) (merchant *presenter.Merchant, err error) {
+	err = datastore.DB.Where("phone", phone).First(&merchant).Error
+	return
+}
+
 func (r *repository) ReadMerchantByIdNumber(idNumber string) (merchant *presenter.Merchant, err error) {
 	err = datastore.DB.Where("id_number", idNumber).First(&merchant).Error
 	return
 }
 
-func (r *repository) UpdateMerchant(merchant *entities.Merchant) (*presenter.Merchant, error) {
-	result := datastore.DB.Updates(merchant)
+func (r *repository) UpdateMerchant(merchant *entities.Merchant, audit Audit) (*presenter.Merchant, error) {
+	err := r.audited(merchant.Id, audit, func(tx *gorm.DB) error {
+		return tx.Updates(merchant).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ReadMerchant(merchant.Id)
+}
+
+func (r *repository) UpdateMerchantColumn(id uint, column string, value interface{}, audit Audit) (*presenter.Merchant, error) {
+	err := r.audited(id, audit, func(tx *gorm.DB) error {
+		return tx.Model(&entities.Merchant{ModelID: entities.ModelID{Id: id}}).Update(column, value).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ReadMerchant(id)
+}
+
+// audited runs the update in a transaction along with recording the fields it changed, if any.
+func (r *repository) audited(id uint, audit Audit, update func(tx *gorm.DB) error) error {
+	return datastore.DB.Transaction(func(tx *gorm.DB) error {
+		var before, after entities.Merchant
+		if err := tx.First(&before, id).Error; err != nil {
+			return err
+		}
+
+		if err := update(tx); err != nil {
+			return err
+		}
+
+		if err := tx.First(&after, id).Error; err != nil {
+			return err
+		}
+
+		if record := auditOf(&before, &after, audit); record != nil {
+			return tx.Create(record).Error
+		}
+
+		return nil
+	})
+}
+
+// ReserveCode relies on the unique index on merchant_codes.code, so concurrent reservations of a code fail for all but one.
+func (r *repository) ReserveCode(code *entities.MerchantCode) (*entities.MerchantCode, error) {
+	result := datastore.DB.Create(&code)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
-	return r.ReadMerchant(merchant.Id)
+	return code, nil
+}
+
+func (r *repository) RetireCode(code uint, reason, actor string) error {
+	now := time.Now()
+
+	return datastore.DB.Model(&entities.MerchantCode{}).
+		Where("code", code).
+		Updates(entities.MerchantCode{Status: consts.CODE_RETIRED, Reason: reason, Actor: actor, RetiredAt: &now}).
+		Error
+}
+
+func (r *repository) CreateStatusChange(change *entities.MerchantStatusChange) (*entities.MerchantStatusChange, error) {
+	result := datastore.DB.Create(&change)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return change, nil
+}
+
+// TransitionStatus moves the merchant to the change's status and records the change in one transaction. It fails with
+// ErrInvalidStatusTransition if the merchant is no longer in the status the change is from.
+func (r *repository) TransitionStatus(change *entities.MerchantStatusChange, audit Audit) (*presenter.Merchant, error) {
+	err := r.audited(change.MerchantId, audit, func(tx *gorm.DB) error {
+		result := tx.Model(&entities.Merchant{ModelID: entities.ModelID{Id: change.MerchantId}}).
+			Where("status", change.FromStatus).
+			Update("status", change.ToStatus)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return pkg.ErrInvalidStatusTransition
+		}
+
+		return tx.Create(change).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ReadMerchant(change.MerchantId)
 }
 
-func (r *repository) GetMerchantCodes() (codes []uint) {
-	_ = datastore.DB.Select("code").Order("code").Find(&codes).Error
+func (r *repository) ReadStatusChanges(merchantId uint) (changes *[]presenter.MerchantStatusChange, err error) {
+	err = datastore.DB.Model(&entities.MerchantStatusChange{}).
+		Where("merchant_id", merchantId).
+		Order("id desc").
+		Find(&changes).
+		Error
+	return
+}
+
+func (r *repository) ReadLastStatusChangeTo(merchantId uint, status string) (change *entities.MerchantStatusChange, err error) {
+	err = datastore.DB.Where("merchant_id", merchantId).Where("to_status", status).Order("id desc").First(&change).Error
+	return
+}
+
+// ReadAudits returns the latest changes first.
+func (r *repository) ReadAudits(merchantId uint, pagination datastore.Pagination) (audits *[]presenter.MerchantAudit, total int64, err error) {
+	query := datastore.DB.Model(&entities.MerchantAudit{}).Where("merchant_id", merchantId)
+
+	err = query.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = query.Order("id desc").Scopes(datastore.Paginate(pagination)).Find(&audits).Error
 	return
 }
 
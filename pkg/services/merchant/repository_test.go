@@ -0,0 +1,99 @@
+package merchant
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"os"
+	"testing"
+	"time"
+)
+
+func setUpSearchMerchants(t *testing.T) {
+	viper.Set("APP_ENV", "TEST")
+	viper.Set("MIGRATE_DB", true)
+	datastore.Init()
+	t.Cleanup(func() { os.Remove("test.db") })
+
+	kiosk, shop, store := "John's Kiosk", "Mama Shop", "Corner Store"
+	westlands, kilimani := uint(1), uint(2)
+	code := uint(123458)
+	assert.Nil(t, datastore.DB.Create(&[]entities.Location{
+		{ModelID: entities.ModelID{Id: westlands}, CountyId: 47, SubCountyId: 1, WardId: 10, LandmarkId: 1},
+		{ModelID: entities.ModelID{Id: kilimani}, CountyId: 47, SubCountyId: 2, WardId: 20, LandmarkId: 2},
+	}).Error)
+
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.Nil(t, datastore.DB.Create(&[]entities.Merchant{
+		{FirstName: "John", LastName: "Doe", IdNumber: "1001", Phone: "254700000001", BusinessName: &kiosk, Code: &code,
+			Status: consts.MERCHANT_ACTIVE, AccountId: 1, LocationId: &westlands, ModelTimeStamps: entities.ModelTimeStamps{CreatedAt: created}},
+		{FirstName: "Jane", LastName: "Doe", IdNumber: "1002", Phone: "254700000002", BusinessName: &shop,
+			Status: consts.MERCHANT_SUSPENDED, AccountId: 2, LocationId: &kilimani, ModelTimeStamps: entities.ModelTimeStamps{CreatedAt: created.AddDate(0, 0, 1)}},
+		{FirstName: "Adam", LastName: "Smith", IdNumber: "2001", Phone: "254700000003", BusinessName: &store,
+			Status: consts.MERCHANT_ACTIVE, AccountId: 3, LocationId: &westlands, ModelTimeStamps: entities.ModelTimeStamps{CreatedAt: created.AddDate(0, 0, 2)}},
+	}).Error)
+}
+
+func searchedIds(t *testing.T, filters SearchFilters) ([]uint, int64) {
+	merchants, total, err := NewRepo().SearchMerchants(filters)
+	assert.Nil(t, err)
+
+	ids := []uint{}
+	for _, m := range *merchants {
+		ids = append(ids, m.Id)
+	}
+	return ids, total
+}
+
+func TestRepository_SearchMerchants_Filters(t *testing.T) {
+	setUpSearchMerchants(t)
+
+	ids, total := searchedIds(t, SearchFilters{})
+	assert.Equal(t, []uint{3, 2, 1}, ids)
+	assert.Equal(t, int64(3), total)
+
+	ids, _ = searchedIds(t, SearchFilters{Query: "doe"})
+	assert.Equal(t, []uint{2, 1}, ids)
+
+	ids, _ = searchedIds(t, SearchFilters{Query: "john kiosk"})
+	assert.Equal(t, []uint{1}, ids)
+
+	ids, _ = searchedIds(t, SearchFilters{Query: "123458"})
+	assert.Equal(t, []uint{1}, ids)
+
+	ids, _ = searchedIds(t, SearchFilters{Query: "200"})
+	assert.Equal(t, []uint{3}, ids)
+
+	ids, _ = searchedIds(t, SearchFilters{Status: consts.MERCHANT_ACTIVE})
+	assert.Equal(t, []uint{3, 1}, ids)
+
+	ids, _ = searchedIds(t, SearchFilters{CountyId: 47, WardId: 10})
+	assert.Equal(t, []uint{3, 1}, ids)
+
+	ids, _ = searchedIds(t, SearchFilters{SubCountyId: 2})
+	assert.Equal(t, []uint{2}, ids)
+
+	from, to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	ids, _ = searchedIds(t, SearchFilters{From: &from, To: &to})
+	assert.Equal(t, []uint{2}, ids)
+}
+
+func TestRepository_SearchMerchants_SortAndPaging(t *testing.T) {
+	setUpSearchMerchants(t)
+
+	ids, _ := searchedIds(t, SearchFilters{Sort: "business_name"})
+	assert.Equal(t, []uint{3, 1, 2}, ids)
+
+	ids, _ = searchedIds(t, SearchFilters{Sort: "-first_name"})
+	assert.Equal(t, []uint{1, 2, 3}, ids)
+
+	// Unknown columns fall back to the default order rather than reaching the query
+	ids, _ = searchedIds(t, SearchFilters{Sort: "phone; drop table merchants"})
+	assert.Equal(t, []uint{3, 2, 1}, ids)
+
+	ids, total := searchedIds(t, SearchFilters{Pagination: datastore.Pagination{Page: 2, PageSize: 2}, Sort: "created_at"})
+	assert.Equal(t, []uint{3}, ids)
+	assert.Equal(t, int64(3), total)
+}
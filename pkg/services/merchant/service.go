@@ -1,29 +1,67 @@
 package merchant
 
 import (
+	"cmp"
+	"fmt"
+	"math"
 	"merchants.sidooh/api/presenter"
 	"merchants.sidooh/pkg"
 	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
 	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
 	"slices"
 	"strconv"
 )
 
 type Service interface {
 	FetchMerchants(accounts []string) (*[]presenter.Merchant, error)
+	SearchMerchants(filters SearchFilters) (*presenter.Paginated, error)
+	GetNearbyMerchants(lat, lng, radius float64, limit int) ([]presenter.NearbyMerchant, error)
 	GetMerchant(id uint) (*presenter.Merchant, error)
 	GetMerchantByAccount(accountId uint) (*presenter.Merchant, error)
 	GetMerchantByIdNumber(idNumber string) (*presenter.Merchant, error)
-	CreateMerchant(merchant *entities.Merchant) (*entities.Merchant, error)
-	UpdateMerchantKYB(merchant *entities.Merchant) (*presenter.Merchant, error)
+	GetMerchantByCode(code string) (*presenter.Merchant, error)
+
+	SuspendMerchant(id uint, reason, actor string) (*presenter.Merchant, error)
+	ReactivateMerchant(id uint, reason, actor string) (*presenter.Merchant, error)
+	CloseMerchant(id uint, reason, actor string) (*presenter.Merchant, error)
+	GetStatusHistory(id uint) (*[]presenter.MerchantStatusChange, error)
+	GetAuditLog(id uint, pagination datastore.Pagination) (*presenter.Paginated, error)
+
+	RegenerateMerchantCode(id uint, reason, actor string) (*presenter.Merchant, error)
+	RetireMerchantCode(id uint, reason, actor string) (*presenter.Merchant, error)
+
+	GetMerchantQR(id uint) (string, error)
+	GetMerchantQRImage(id uint, size int) ([]byte, error)
+	ResolveQR(payload string) (*presenter.Merchant, error)
+
+	AssignCode(id uint) (*presenter.Merchant, error)
+	SetSystemStatus(id uint, status, reason string) (*presenter.Merchant, error)
+}
+
+const (
+	// Codes are a random 5 digit payload followed by a Luhn check digit.
+	// Codes issued before check digits were introduced are 5 digits long and are looked up as is.
+	codePayloadMin         = 10000
+	codePayloadMax         = 99999
+	legacyCodeLength       = 5
+	codeAllocationAttempts = 10
+)
+
+// statusTransitions lists the statuses a merchant may move to from each status.
+var statusTransitions = map[string][]string{
+	consts.MERCHANT_PENDING_KYC: {consts.MERCHANT_PENDING_KYB, consts.MERCHANT_SUSPENDED, consts.MERCHANT_CLOSED},
+	consts.MERCHANT_PENDING_KYB: {consts.MERCHANT_ACTIVE, consts.MERCHANT_SUSPENDED, consts.MERCHANT_CLOSED},
+	consts.MERCHANT_ACTIVE:      {consts.MERCHANT_SUSPENDED, consts.MERCHANT_CLOSED},
+	consts.MERCHANT_SUSPENDED:   {consts.MERCHANT_PENDING_KYC, consts.MERCHANT_PENDING_KYB, consts.MERCHANT_ACTIVE, consts.MERCHANT_CLOSED},
 }
 
 type service struct {
-	paymentsApi *clients.ApiClient
-	notifyApi   *clients.ApiClient
-	accountApi  *clients.ApiClient
-	repository  Repository
+	notifyApi  *clients.ApiClient
+	repository Repository
 }
 
 func (s *service) FetchMerchants(accounts []string) (*[]presenter.Merchant, error) {
@@ -32,6 +70,50 @@ func (s *service) FetchMerchants(accounts []string) (*[]presenter.Merchant, erro
 	})
 }
 
+func (s *service) SearchMerchants(filters SearchFilters) (*presenter.Paginated, error) {
+	merchants, total, err := s.repository.SearchMerchants(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	pagination := filters.Pagination.Normalize()
+
+	return &presenter.Paginated{
+		Data:     merchants,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	}, nil
+}
+
+// GetNearbyMerchants returns the closest merchants within radius kilometres. Candidates are read within the
+// bounding box, then filtered and sorted on their exact distance since SQLite has no trigonometric functions.
+func (s *service) GetNearbyMerchants(lat, lng, radius float64, limit int) ([]presenter.NearbyMerchant, error) {
+	minLat, maxLat, minLng, maxLng := utils.BoundingBox(lat, lng, radius)
+	candidates, err := s.repository.ReadMerchantsWithin(minLat, maxLat, minLng, maxLng)
+	if err != nil {
+		return nil, err
+	}
+
+	merchants := []presenter.NearbyMerchant{}
+	for _, merchant := range candidates {
+		merchant.Distance = utils.Haversine(lat, lng, merchant.Latitude, merchant.Longitude)
+		if merchant.Distance <= radius {
+			merchant.Distance = math.Round(merchant.Distance*1000) / 1000
+			merchants = append(merchants, merchant)
+		}
+	}
+
+	slices.SortStableFunc(merchants, func(a, b presenter.NearbyMerchant) int {
+		return cmp.Compare(a.Distance, b.Distance)
+	})
+	if len(merchants) > limit {
+		merchants = merchants[:limit]
+	}
+
+	return merchants, nil
+}
+
 func (s *service) GetMerchant(id uint) (*presenter.Merchant, error) {
 	return s.repository.ReadMerchant(id)
 }
@@ -44,58 +126,224 @@ func (s *service) GetMerchantByIdNumber(idNumber string) (*presenter.Merchant, e
 	return s.repository.ReadMerchantByIdNumber(idNumber)
 }
 
-func (s *service) CreateMerchant(data *entities.Merchant) (merchant *entities.Merchant, err error) {
-	account, err := s.accountApi.GetAccountById(strconv.Itoa(int(data.AccountId)))
+func (s *service) GetMerchantByCode(code string) (*presenter.Merchant, error) {
+	value, err := strconv.Atoi(code)
+	if err != nil || value <= 0 {
+		return nil, pkg.ErrInvalidMerchantCode
+	}
+
+	if len(code) > legacyCodeLength && !utils.ValidLuhn(code) {
+		return nil, pkg.ErrInvalidMerchantCode
+	}
+
+	return s.repository.ReadMerchantByCode(uint(value))
+}
+
+func (s *service) SuspendMerchant(id uint, reason, actor string) (*presenter.Merchant, error) {
+	merchant, err := s.transitionStatus(id, consts.MERCHANT_SUSPENDED, reason, Audit{Actor: actor, Source: consts.AUDIT_API})
+	if err != nil {
+		return nil, err
+	}
+
+	go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, "Your merchant account has been suspended. Please contact support for assistance.")
+
+	return merchant, nil
+}
+
+func (s *service) ReactivateMerchant(id uint, reason, actor string) (*presenter.Merchant, error) {
+	merchant, err := s.repository.ReadMerchant(id)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status != consts.MERCHANT_SUSPENDED {
+		return nil, pkg.ErrInvalidStatusTransition
+	}
+
+	// Restore the status held before suspension so onboarding resumes where it stopped
+	status := consts.MERCHANT_ACTIVE
+	if merchant.FloatAccountId == 0 {
+		status = consts.MERCHANT_PENDING_KYB
+	}
+	if change, err := s.repository.ReadLastStatusChangeTo(id, consts.MERCHANT_SUSPENDED); err == nil && change.FromStatus != "" {
+		status = change.FromStatus
+	}
+
+	merchant, err = s.transitionStatus(id, status, reason, Audit{Actor: actor, Source: consts.AUDIT_API})
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant.Status == consts.MERCHANT_ACTIVE {
+		go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, "Your merchant account has been reactivated.")
+	}
+
+	return merchant, nil
+}
+
+func (s *service) CloseMerchant(id uint, reason, actor string) (*presenter.Merchant, error) {
+	merchant, err := s.transitionStatus(id, consts.MERCHANT_CLOSED, reason, Audit{Actor: actor, Source: consts.AUDIT_API})
 	if err != nil {
 		return nil, err
 	}
 
-	data.Phone = account.Phone
-	merchant, err = s.repository.CreateMerchant(data)
+	go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, "Your merchant account has been closed.")
 
-	go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, "KYC details created")
+	return merchant, nil
+}
 
-	return
+func (s *service) GetStatusHistory(id uint) (*[]presenter.MerchantStatusChange, error) {
+	return s.repository.ReadStatusChanges(id)
 }
 
-func (s *service) UpdateMerchantKYB(data *entities.Merchant) (merchant *presenter.Merchant, err error) {
-	merchant, err = s.repository.UpdateMerchant(data)
+func (s *service) GetAuditLog(id uint, pagination datastore.Pagination) (*presenter.Paginated, error) {
+	audits, total, err := s.repository.ReadAudits(id, pagination)
 	if err != nil {
-		return nil, pkg.ErrServerError
-	}
-
-	// TODO: Generate code and assign float account
-	// TODO: Fix this to ensure uniqueness - get all codes and generate while comparing... or generate and check loop
-	existingCodes := s.repository.GetMerchantCodes()
-	code := uint(utils.RandomIntBetween(10000, 99999))
-	isUnique := false
-	for !isUnique {
-		if slices.Contains[[]uint, uint](existingCodes, code) {
-			code = uint(utils.RandomIntBetween(10000, 99999))
-		} else {
-			isUnique = true
+		return nil, err
+	}
+
+	pagination = pagination.Normalize()
+
+	return &presenter.Paginated{
+		Data:     audits,
+		Page:     pagination.Page,
+		PageSize: pagination.PageSize,
+		Total:    total,
+	}, nil
+}
+
+func (s *service) transitionStatus(id uint, status, reason string, audit Audit) (*presenter.Merchant, error) {
+	merchant, err := s.repository.ReadMerchant(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(statusTransitions[merchant.Status], status) {
+		return nil, pkg.ErrInvalidStatusTransition
+	}
+	if status == consts.MERCHANT_ACTIVE && merchant.FloatAccountId == 0 {
+		return nil, pkg.ErrInvalidStatusTransition
+	}
+
+	return s.repository.TransitionStatus(&entities.MerchantStatusChange{
+		FromStatus: merchant.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		Actor:      audit.Actor,
+		MerchantId: id,
+	}, audit)
+}
+
+// RegenerateMerchantCode replaces a merchant's code. Payments keeps the code its float account was opened with and has
+// no way to change it, so merchants with a float account are refused rather than left with mismatched codes.
+func (s *service) RegenerateMerchantCode(id uint, reason, actor string) (*presenter.Merchant, error) {
+	merchant, err := s.repository.ReadMerchant(id)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.FloatAccountId != 0 {
+		return nil, pkg.ErrMerchantCodeInUse
+	}
+
+	var previous uint
+	if merchant.Code != "" {
+		if previous, err = parseCode(merchant.Code); err != nil {
+			return nil, err
+		}
+	}
+
+	code, err := s.allocateCode(merchant.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous != 0 {
+		if err = s.repository.RetireCode(previous, reason, actor); err != nil {
+			return nil, err
 		}
 	}
 
-	data.Code = &code
+	return s.repository.UpdateMerchantColumn(merchant.Id, "code", code, Audit{Actor: actor, Source: consts.AUDIT_API})
+}
+
+// RetireMerchantCode removes a merchant's code, refusing merchants with a float account as RegenerateMerchantCode does.
+func (s *service) RetireMerchantCode(id uint, reason, actor string) (*presenter.Merchant, error) {
+	merchant, err := s.repository.ReadMerchant(id)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Code == "" {
+		return nil, pkg.ErrInvalidMerchantCode
+	}
+	if merchant.FloatAccountId != 0 {
+		return nil, pkg.ErrMerchantCodeInUse
+	}
+
+	code, err := parseCode(merchant.Code)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.repository.RetireCode(code, reason, actor); err != nil {
+		return nil, err
+	}
+
+	return s.repository.UpdateMerchantColumn(merchant.Id, "code", nil, Audit{Actor: actor, Source: consts.AUDIT_API})
+}
 
-	floatAccount, err := s.paymentsApi.CreateFloatAccount(int(merchant.Id), int(merchant.AccountId), int(code))
+// AssignCode allocates a code to a merchant that does not have one yet, it is a no-op otherwise.
+func (s *service) AssignCode(id uint) (*presenter.Merchant, error) {
+	merchant, err := s.repository.ReadMerchant(id)
 	if err != nil {
-		return nil, pkg.ErrServerError
+		return nil, err
+	}
+	if merchant.Code != "" {
+		return merchant, nil
 	}
-	id := uint(floatAccount.Id)
-	data.FloatAccountId = &id
 
-	merchant, err = s.repository.UpdateMerchant(data)
+	code, err := s.allocateCode(merchant.Id)
 	if err != nil {
 		return nil, err
 	}
 
-	go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, "KYB details updated")
+	return s.repository.UpdateMerchantColumn(merchant.Id, "code", code, Audit{Actor: "SYSTEM", Source: consts.AUDIT_ONBOARDING})
+}
+
+// SetSystemStatus applies status changes driven by the service itself, e.g. on completing onboarding.
+func (s *service) SetSystemStatus(id uint, status, reason string) (*presenter.Merchant, error) {
+	return s.transitionStatus(id, status, reason, Audit{Actor: "SYSTEM", Source: consts.AUDIT_SYSTEM})
+}
+
+// allocateCode reserves a random unused code. Reservations are never deleted, so retired codes are not reissued.
+func (s *service) allocateCode(merchantId uint) (uint, error) {
+	var err error
+	for i := 0; i < codeAllocationAttempts; i++ {
+		payload := strconv.FormatInt(utils.RandomIntBetween(codePayloadMin, codePayloadMax), 10)
+		check, _ := utils.LuhnCheckDigit(payload)
+		code, _ := strconv.Atoi(payload + strconv.Itoa(check))
+
+		var reserved *entities.MerchantCode
+		reserved, err = s.repository.ReserveCode(&entities.MerchantCode{
+			Code:       uint(code),
+			Status:     consts.CODE_ACTIVE,
+			MerchantId: merchantId,
+		})
+		if err == nil {
+			return reserved.Code, nil
+		}
+	}
+
+	logger.ClientLog.Error("failed to allocate merchant code", "merchant", merchantId, "err", err)
+	return 0, err
+}
+
+func parseCode(code string) (uint, error) {
+	parsed, err := strconv.ParseUint(code, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not numeric", pkg.ErrInvalidMerchantCode, code)
+	}
 
-	return
+	return uint(parsed), nil
 }
 
 func NewService(r Repository) Service {
-	return &service{repository: r, paymentsApi: clients.GetPaymentClient(), notifyApi: clients.GetNotifyClient(), accountApi: clients.GetAccountClient()}
+	return &service{repository: r, notifyApi: clients.GetNotifyClient()}
 }
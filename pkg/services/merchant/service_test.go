@@ -0,0 +1,218 @@
+package merchant
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
+	"strconv"
+	"testing"
+)
+
+type fakeRepository struct {
+	Repository
+
+	merchants []presenter.NearbyMerchant
+	records   map[uint]*presenter.Merchant
+	changes   []entities.MerchantStatusChange
+	reserved  []uint
+	retired   []uint
+}
+
+func (f *fakeRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	merchant, ok := f.records[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *merchant
+	return &copied, nil
+}
+
+func (f *fakeRepository) TransitionStatus(change *entities.MerchantStatusChange, audit Audit) (*presenter.Merchant, error) {
+	merchant := f.records[change.MerchantId]
+	if merchant.Status != change.FromStatus {
+		return nil, pkg.ErrInvalidStatusTransition
+	}
+	merchant.Status = change.ToStatus
+	f.changes = append(f.changes, *change)
+	return f.ReadMerchant(change.MerchantId)
+}
+
+func (f *fakeRepository) ReadLastStatusChangeTo(merchantId uint, status string) (*entities.MerchantStatusChange, error) {
+	for i := len(f.changes) - 1; i >= 0; i-- {
+		if f.changes[i].MerchantId == merchantId && f.changes[i].ToStatus == status {
+			return &f.changes[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func newTransitionTestService(merchants ...presenter.Merchant) (*service, *fakeRepository) {
+	repo := &fakeRepository{records: map[uint]*presenter.Merchant{}}
+	for i := range merchants {
+		repo.records[merchants[i].Id] = &merchants[i]
+	}
+	return &service{repository: repo}, repo
+}
+
+func (f *fakeRepository) ReadMerchantsWithin(minLat, maxLat, minLng, maxLng float64) (merchants []presenter.NearbyMerchant, err error) {
+	for _, merchant := range f.merchants {
+		if merchant.Latitude >= minLat && merchant.Latitude <= maxLat && merchant.Longitude >= minLng && merchant.Longitude <= maxLng {
+			merchants = append(merchants, merchant)
+		}
+	}
+	return
+}
+
+func TestService_GetNearbyMerchants(t *testing.T) {
+	s := NewService(&fakeRepository{merchants: []presenter.NearbyMerchant{
+		{Id: 1, Latitude: -1.2921, Longitude: 36.8219}, // ~0.8km away
+		{Id: 2, Latitude: -1.2864, Longitude: 36.8172}, // the point itself
+		{Id: 3, Latitude: -1.2980, Longitude: 36.8280}, // ~1.75km away, in the box's corner
+		{Id: 4, Latitude: -1.3000, Longitude: 36.8300}, // ~2km away, outside the radius
+		{Id: 5, Latitude: -4.0435, Longitude: 39.6682}, // Mombasa
+	}})
+
+	merchants, err := s.GetNearbyMerchants(-1.2864, 36.8172, 1.9, 10)
+	assert.NoError(t, err)
+
+	var ids []uint
+	for _, merchant := range merchants {
+		ids = append(ids, merchant.Id)
+	}
+	assert.Equal(t, []uint{2, 1, 3}, ids)
+	assert.Zero(t, merchants[0].Distance)
+	assert.InDelta(t, 0.82, merchants[1].Distance, 0.01)
+
+	merchants, err = s.GetNearbyMerchants(-1.2864, 36.8172, 1.9, 1)
+	assert.NoError(t, err)
+	assert.Len(t, merchants, 1)
+}
+
+func TestService_SetSystemStatus_Transitions(t *testing.T) {
+	s, repo := newTransitionTestService(
+		presenter.Merchant{Id: 1, Status: consts.MERCHANT_PENDING_KYC},
+		presenter.Merchant{Id: 2, Status: consts.MERCHANT_PENDING_KYB},
+		presenter.Merchant{Id: 3, Status: consts.MERCHANT_PENDING_KYB, FloatAccountId: 7},
+		presenter.Merchant{Id: 4, Status: consts.MERCHANT_CLOSED, FloatAccountId: 7},
+	)
+
+	merchant, err := s.SetSystemStatus(1, consts.MERCHANT_PENDING_KYB, "KYC details completed")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.MERCHANT_PENDING_KYB, merchant.Status)
+	assert.Equal(t, entities.MerchantStatusChange{
+		FromStatus: consts.MERCHANT_PENDING_KYC, ToStatus: consts.MERCHANT_PENDING_KYB,
+		Reason: "KYC details completed", Actor: "SYSTEM", MerchantId: 1,
+	}, repo.changes[0])
+
+	// Skipping KYB
+	_, err = s.SetSystemStatus(1, consts.MERCHANT_PENDING_KYC, "back")
+	assert.ErrorIs(t, err, pkg.ErrInvalidStatusTransition)
+
+	// Activating needs a float account
+	_, err = s.SetSystemStatus(2, consts.MERCHANT_ACTIVE, "KYB details completed")
+	assert.ErrorIs(t, err, pkg.ErrInvalidStatusTransition)
+
+	merchant, err = s.SetSystemStatus(3, consts.MERCHANT_ACTIVE, "KYB details completed")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.MERCHANT_ACTIVE, merchant.Status)
+
+	// Closed is final
+	for _, status := range []string{consts.MERCHANT_ACTIVE, consts.MERCHANT_SUSPENDED, consts.MERCHANT_PENDING_KYB} {
+		_, err = s.SetSystemStatus(4, status, "reopen")
+		assert.ErrorIs(t, err, pkg.ErrInvalidStatusTransition)
+	}
+	assert.Len(t, repo.changes, 2)
+}
+
+func TestService_SuspendAndReactivateMerchant(t *testing.T) {
+	s, repo := newTransitionTestService(
+		presenter.Merchant{Id: 1, Status: consts.MERCHANT_PENDING_KYB},
+		presenter.Merchant{Id: 2, Status: consts.MERCHANT_ACTIVE, FloatAccountId: 7},
+	)
+
+	_, err := s.transitionStatus(1, consts.MERCHANT_SUSPENDED, "fraud review", Audit{Actor: "9", Source: consts.AUDIT_API})
+	assert.NoError(t, err)
+
+	// Reactivating restores the status held before the suspension
+	merchant, err := s.ReactivateMerchant(1, "review cleared", "9")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.MERCHANT_PENDING_KYB, merchant.Status)
+
+	_, err = s.ReactivateMerchant(2, "not suspended", "9")
+	assert.ErrorIs(t, err, pkg.ErrInvalidStatusTransition)
+	assert.Equal(t, consts.MERCHANT_ACTIVE, repo.records[2].Status)
+
+	_, err = s.transitionStatus(99, consts.MERCHANT_SUSPENDED, "missing", Audit{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func (f *fakeRepository) ReserveCode(code *entities.MerchantCode) (*entities.MerchantCode, error) {
+	f.reserved = append(f.reserved, code.Code)
+	return code, nil
+}
+
+func (f *fakeRepository) RetireCode(code uint, reason, actor string) error {
+	f.retired = append(f.retired, code)
+	return nil
+}
+
+func (f *fakeRepository) UpdateMerchantColumn(id uint, column string, value interface{}, audit Audit) (*presenter.Merchant, error) {
+	merchant := f.records[id]
+	merchant.Code = ""
+	if code, ok := value.(uint); ok {
+		merchant.Code = strconv.Itoa(int(code))
+	}
+	return f.ReadMerchant(id)
+}
+
+func TestService_RegenerateMerchantCode(t *testing.T) {
+	s, repo := newTransitionTestService(
+		presenter.Merchant{Id: 1, Code: "123458", Status: consts.MERCHANT_PENDING_KYB},
+		presenter.Merchant{Id: 2, Code: "123458", Status: consts.MERCHANT_ACTIVE, FloatAccountId: 7},
+		presenter.Merchant{Id: 3, Code: "12x45", Status: consts.MERCHANT_PENDING_KYB},
+	)
+
+	merchant, err := s.RegenerateMerchantCode(1, "leaked", "9")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "123458", merchant.Code)
+	assert.True(t, utils.ValidLuhn(merchant.Code))
+	assert.Equal(t, []uint{123458}, repo.retired)
+
+	// Payments still holds the code the float account was opened with
+	_, err = s.RegenerateMerchantCode(2, "leaked", "9")
+	assert.ErrorIs(t, err, pkg.ErrMerchantCodeInUse)
+	assert.Equal(t, "123458", repo.records[2].Code)
+
+	_, err = s.RegenerateMerchantCode(3, "leaked", "9")
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchantCode)
+	assert.Len(t, repo.reserved, 1)
+	assert.Len(t, repo.retired, 1)
+}
+
+func TestService_RetireMerchantCode(t *testing.T) {
+	s, repo := newTransitionTestService(
+		presenter.Merchant{Id: 1, Code: "123458", Status: consts.MERCHANT_PENDING_KYB},
+		presenter.Merchant{Id: 2, Code: "123458", Status: consts.MERCHANT_ACTIVE, FloatAccountId: 7},
+		presenter.Merchant{Id: 3, Status: consts.MERCHANT_PENDING_KYB},
+		presenter.Merchant{Id: 4, Code: "12x45", Status: consts.MERCHANT_PENDING_KYB},
+	)
+
+	merchant, err := s.RetireMerchantCode(1, "leaked", "9")
+	assert.NoError(t, err)
+	assert.Equal(t, "", merchant.Code)
+	assert.Equal(t, []uint{123458}, repo.retired)
+
+	_, err = s.RetireMerchantCode(2, "leaked", "9")
+	assert.ErrorIs(t, err, pkg.ErrMerchantCodeInUse)
+
+	_, err = s.RetireMerchantCode(3, "leaked", "9")
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchantCode)
+
+	_, err = s.RetireMerchantCode(4, "leaked", "9")
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchantCode)
+	assert.Len(t, repo.retired, 1)
+}
@@ -0,0 +1,106 @@
+package merchant_import
+
+import (
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	CreateImport(job *entities.MerchantImport, rows []entities.MerchantImportRow) (*entities.MerchantImport, error)
+	ReadImport(id uint) (*presenter.MerchantImport, error)
+	ReadOutstandingRows(importId uint) ([]entities.MerchantImportRow, error)
+	UpdateRow(row *entities.MerchantImportRow) error
+	ClaimImport(id uint, staleBefore time.Time) (bool, error)
+	UpdateProgress(id uint, status string) error
+}
+type repository struct {
+}
+
+func (r *repository) CreateImport(job *entities.MerchantImport, rows []entities.MerchantImportRow) (*entities.MerchantImport, error) {
+	err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+
+		for i := range rows {
+			rows[i].MerchantImportId = job.Id
+		}
+
+		return tx.CreateInBatches(&rows, 100).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (r *repository) ReadImport(id uint) (job *presenter.MerchantImport, err error) {
+	err = datastore.DB.Model(&entities.MerchantImport{}).First(&job, id).Error
+	if err != nil {
+		return
+	}
+
+	err = datastore.DB.Model(&entities.MerchantImportRow{}).
+		Where("merchant_import_id", id).
+		Order("line").
+		Find(&job.Rows).
+		Error
+	return
+}
+
+// ReadOutstandingRows returns the valid rows that were not created yet, including those that failed on a previous run.
+func (r *repository) ReadOutstandingRows(importId uint) (rows []entities.MerchantImportRow, err error) {
+	err = datastore.DB.
+		Where("merchant_import_id", importId).
+		Where("status IN ?", []string{consts.IMPORT_ROW_VALID, consts.IMPORT_ROW_FAILED}).
+		Order("line").
+		Find(&rows).
+		Error
+	return
+}
+
+func (r *repository) UpdateRow(row *entities.MerchantImportRow) error {
+	return datastore.DB.Save(row).Error
+}
+
+// ClaimImport marks the import as running, the conditional update ensures only one run processes an import at a time.
+// Runs that stopped updating before staleBefore, e.g. because the service restarted, may be taken over.
+func (r *repository) ClaimImport(id uint, staleBefore time.Time) (bool, error) {
+	result := datastore.DB.Model(&entities.MerchantImport{}).
+		Where("id", id).
+		Where("status IN ? OR (status = ? AND updated_at < ?)",
+			[]string{consts.IMPORT_PENDING, consts.IMPORT_PARTIAL}, consts.IMPORT_RUNNING, staleBefore).
+		Update("status", consts.IMPORT_RUNNING)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// UpdateProgress recounts the created and failed rows, an empty status leaves the status as is.
+func (r *repository) UpdateProgress(id uint, status string) error {
+	var created, failed int64
+	query := datastore.DB.Model(&entities.MerchantImportRow{}).Where("merchant_import_id", id)
+	if err := query.Session(&gorm.Session{}).Where("status", consts.IMPORT_ROW_CREATED).Count(&created).Error; err != nil {
+		return err
+	}
+	if err := query.Session(&gorm.Session{}).Where("status", consts.IMPORT_ROW_FAILED).Count(&failed).Error; err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{"created": created, "failed": failed, "updated_at": time.Now()}
+	if status != "" {
+		values["status"] = status
+	}
+
+	return datastore.DB.Model(&entities.MerchantImport{}).Where("id", id).Updates(values).Error
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
@@ -0,0 +1,462 @@
+package merchant_import
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"io"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/location"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/services/onboarding"
+	"merchants.sidooh/utils"
+	"merchants.sidooh/utils/consts"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	Validate(fileName string, file io.Reader) (*presenter.MerchantImport, error)
+	CreateImport(fileName string, file io.Reader, actor string) (*presenter.MerchantImport, error)
+	GetImport(id uint) (*presenter.MerchantImport, error)
+	Start(id uint) (*presenter.MerchantImport, error)
+	Run(id uint) (*presenter.MerchantImport, error)
+}
+
+// accounts is the part of the accounts api imports resolve phone numbers with.
+type accounts interface {
+	GetOrCreateAccount(phone string) (*clients.Account, error)
+}
+
+var columns = []string{"first_name", "last_name", "id_number", "phone", "account_id", "business_name", "landmark"}
+
+var phoneRegex = regexp.MustCompile(`^254[17]\d{8}$`)
+
+type service struct {
+	repository         Repository
+	merchantRepository merchant.Repository
+	locationRepository location.Repository
+	onboardingService  onboarding.Service
+
+	accountApi accounts
+}
+
+// Validate parses and checks every row of the file without persisting anything, it is the dry run of an import.
+func (s *service) Validate(fileName string, file io.Reader) (*presenter.MerchantImport, error) {
+	rows, err := s.parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &presenter.MerchantImport{FileName: fileName, Status: consts.IMPORT_PENDING, Total: len(rows)}
+	for _, row := range rows {
+		if row.Status == consts.IMPORT_ROW_INVALID {
+			report.Invalid++
+		}
+		report.Rows = append(report.Rows, rowPresenter(row))
+	}
+	if report.Invalid > 0 {
+		report.Status = consts.IMPORT_INVALID
+	}
+
+	return report, nil
+}
+
+// CreateImport persists the validated file as an import job. Jobs with invalid rows are kept for reference
+// but are never run, nothing is created unless the whole file is valid.
+func (s *service) CreateImport(fileName string, file io.Reader, actor string) (*presenter.MerchantImport, error) {
+	rows, err := s.parse(file)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &entities.MerchantImport{FileName: fileName, Status: consts.IMPORT_PENDING, Actor: actor, Total: len(rows)}
+	for _, row := range rows {
+		if row.Status == consts.IMPORT_ROW_INVALID {
+			job.Invalid++
+		}
+	}
+	if job.Invalid > 0 {
+		job.Status = consts.IMPORT_INVALID
+	}
+
+	job, err = s.repository.CreateImport(job, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.ReadImport(job.Id)
+}
+
+func (s *service) GetImport(id uint) (*presenter.MerchantImport, error) {
+	return s.repository.ReadImport(id)
+}
+
+// Start claims the import and processes its outstanding rows in the background.
+func (s *service) Start(id uint) (*presenter.MerchantImport, error) {
+	if err := s.claim(id); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		if err := s.process(id); err != nil {
+			logger.ClientLog.Error("merchant import failed", "import", id, "err", err)
+		}
+	}()
+
+	return s.repository.ReadImport(id)
+}
+
+// Run claims the import and processes its outstanding rows before returning, it is used by the cli.
+func (s *service) Run(id uint) (*presenter.MerchantImport, error) {
+	if err := s.claim(id); err != nil {
+		return nil, err
+	}
+
+	if err := s.process(id); err != nil {
+		return nil, err
+	}
+
+	return s.repository.ReadImport(id)
+}
+
+func (s *service) claim(id uint) error {
+	job, err := s.repository.ReadImport(id)
+	if err != nil {
+		return err
+	}
+	if job.Status == consts.IMPORT_INVALID || job.Status == consts.IMPORT_COMPLETED {
+		return fmt.Errorf("%w: import is %s", pkg.ErrImportNotResumable, job.Status)
+	}
+
+	claimed, err := s.repository.ClaimImport(id, time.Now().Add(-staleAfter()))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return fmt.Errorf("%w: import is already running", pkg.ErrImportNotResumable)
+	}
+
+	return nil
+}
+
+// process creates the merchants of rows not yet created. Each row records its merchant as soon as it exists,
+// so a run that is interrupted or retried continues with the KYB details instead of creating the merchant twice.
+func (s *service) process(id uint) error {
+	rows, err := s.repository.ReadOutstandingRows(id)
+	if err != nil {
+		return err
+	}
+
+	for i := range rows {
+		row := &rows[i]
+
+		if err := s.importRow(row); err != nil {
+			message := err.Error()
+			row.Status = consts.IMPORT_ROW_FAILED
+			row.Error = &message
+		}
+
+		if err := s.repository.UpdateRow(row); err != nil {
+			return err
+		}
+		if err := s.repository.UpdateProgress(id, ""); err != nil {
+			return err
+		}
+	}
+
+	job, err := s.repository.ReadImport(id)
+	if err != nil {
+		return err
+	}
+
+	status := consts.IMPORT_COMPLETED
+	if job.Failed > 0 {
+		status = consts.IMPORT_PARTIAL
+	}
+
+	return s.repository.UpdateProgress(id, status)
+}
+
+func (s *service) importRow(row *entities.MerchantImportRow) error {
+	if row.MerchantId == nil {
+		if row.AccountId == nil {
+			account, err := s.accountApi.GetOrCreateAccount(row.Phone)
+			if err != nil {
+				return fmt.Errorf("account: %s", err)
+			}
+			accountId := uint(account.Id)
+			row.AccountId = &accountId
+		}
+
+		created, err := s.onboardingService.CreateMerchant(&entities.Merchant{
+			FirstName: row.FirstName,
+			LastName:  row.LastName,
+			IdNumber:  row.IdNumber,
+			AccountId: *row.AccountId,
+		})
+		if err != nil {
+			return err
+		}
+
+		row.MerchantId = &created.Id
+		if err := s.repository.UpdateRow(row); err != nil {
+			return err
+		}
+	}
+
+	data := &entities.Merchant{
+		ModelID:      entities.ModelID{Id: *row.MerchantId},
+		BusinessName: &row.BusinessName,
+	}
+
+	landmark, err := s.locationRepository.GetLandmark(row.Landmark)
+	if err == nil {
+		data.LocationId = &landmark.Id
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		data.Landmark = &row.Landmark
+	} else {
+		return err
+	}
+
+	row.Status = consts.IMPORT_ROW_CREATED
+	row.Error = nil
+
+	_, err = s.onboardingService.SubmitKYB(data, "IMPORT")
+	if errors.Is(err, pkg.ErrOnboardingStepFailed) {
+		// The merchant exists, the remaining onboarding steps are resumed like any other merchant's
+		message := err.Error()
+		row.Error = &message
+		return nil
+	}
+
+	return err
+}
+
+// parse reads the csv and validates each row, the header decides the column order and phone or account_id may be left out.
+func (s *service) parse(file io.Reader) ([]entities.MerchantImportRow, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header", pkg.ErrInvalidImportFile)
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+	for _, column := range columns {
+		if _, ok := index[column]; !ok && column != "phone" && column != "account_id" {
+			return nil, fmt.Errorf("%w: missing %s column", pkg.ErrInvalidImportFile, column)
+		}
+	}
+	_, hasPhone := index["phone"]
+	_, hasAccount := index["account_id"]
+	if !hasPhone && !hasAccount {
+		return nil, fmt.Errorf("%w: missing phone or account_id column", pkg.ErrInvalidImportFile)
+	}
+
+	var rows []entities.MerchantImportRow
+	seen := map[string]int{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", pkg.ErrInvalidImportFile, err)
+		}
+
+		if len(rows) == maxRows() {
+			return nil, fmt.Errorf("%w: more than %d rows", pkg.ErrInvalidImportFile, maxRows())
+		}
+
+		value := func(column string) string {
+			if i, ok := index[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := entities.MerchantImportRow{
+			Line:         line,
+			FirstName:    value("first_name"),
+			LastName:     value("last_name"),
+			IdNumber:     value("id_number"),
+			Phone:        value("phone"),
+			BusinessName: value("business_name"),
+			Landmark:     value("landmark"),
+			Status:       consts.IMPORT_ROW_VALID,
+		}
+		if row.Phone != "" {
+			row.Phone = utils.FormatPhone(row.Phone)
+		}
+
+		problems, err := s.validate(&row, value("account_id"), seen)
+		if err != nil {
+			return nil, err
+		}
+		if len(problems) > 0 {
+			message := strings.Join(problems, "; ")
+			row.Status = consts.IMPORT_ROW_INVALID
+			row.Error = &message
+		}
+
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no rows", pkg.ErrInvalidImportFile)
+	}
+
+	return rows, nil
+}
+
+// validate returns what is wrong with the row, including clashes with earlier rows and existing merchants.
+func (s *service) validate(row *entities.MerchantImportRow, accountId string, seen map[string]int) (problems []string, err error) {
+	required := map[string]string{
+		"first_name":    row.FirstName,
+		"last_name":     row.LastName,
+		"id_number":     row.IdNumber,
+		"business_name": row.BusinessName,
+		"landmark":      row.Landmark,
+	}
+	for _, column := range columns {
+		if value, ok := required[column]; ok && value == "" {
+			problems = append(problems, column+" is required")
+		}
+	}
+
+	if row.IdNumber != "" {
+		if _, err := strconv.Atoi(row.IdNumber); err != nil || len(row.IdNumber) < 8 {
+			problems = append(problems, "id_number must be numeric with at least 8 digits")
+		}
+	}
+	if len(row.FirstName) > 32 || len(row.LastName) > 32 {
+		problems = append(problems, "names must be at most 32 characters")
+	}
+	if len(row.BusinessName) > 128 || len(row.Landmark) > 128 {
+		problems = append(problems, "business_name and landmark must be at most 128 characters")
+	}
+
+	if accountId != "" {
+		id, err := strconv.ParseUint(accountId, 10, 32)
+		if err != nil || id == 0 {
+			problems = append(problems, "account_id must be a positive number")
+		} else {
+			account := uint(id)
+			row.AccountId = &account
+		}
+	}
+	if row.Phone != "" && !phoneRegex.MatchString(row.Phone) {
+		problems = append(problems, "phone is not a valid number")
+	}
+	if row.Phone == "" && accountId == "" {
+		problems = append(problems, "phone or account_id is required")
+	}
+
+	duplicate := func(key, description string) {
+		if line, ok := seen[key]; ok {
+			problems = append(problems, fmt.Sprintf("%s is repeated from line %d", description, line))
+		} else {
+			seen[key] = row.Line
+		}
+	}
+	if row.IdNumber != "" {
+		duplicate("id:"+row.IdNumber, "id_number")
+	}
+	if row.Phone != "" {
+		duplicate("phone:"+row.Phone, "phone")
+	}
+	if row.AccountId != nil {
+		duplicate("account:"+strconv.Itoa(int(*row.AccountId)), "account_id")
+	}
+
+	exists := func(err error, description string) error {
+		if err == nil {
+			problems = append(problems, "a merchant with this "+description+" exists")
+			return nil
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if row.IdNumber != "" {
+		_, err := s.merchantRepository.ReadMerchantByIdNumber(row.IdNumber)
+		if err = exists(err, "id_number"); err != nil {
+			return nil, err
+		}
+	}
+	if row.Phone != "" {
+		_, err := s.merchantRepository.ReadMerchantByPhone(row.Phone)
+		if err = exists(err, "phone"); err != nil {
+			return nil, err
+		}
+	}
+	if row.AccountId != nil {
+		_, err := s.merchantRepository.ReadMerchantByAccount(*row.AccountId)
+		if err = exists(err, "account_id"); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+func rowPresenter(row entities.MerchantImportRow) presenter.MerchantImportRow {
+	return presenter.MerchantImportRow{
+		Line:         row.Line,
+		FirstName:    row.FirstName,
+		LastName:     row.LastName,
+		IdNumber:     row.IdNumber,
+		Phone:        row.Phone,
+		AccountId:    row.AccountId,
+		BusinessName: row.BusinessName,
+		Landmark:     row.Landmark,
+		Status:       row.Status,
+		Error:        row.Error,
+		MerchantId:   row.MerchantId,
+	}
+}
+
+func maxRows() int {
+	rows := viper.GetInt("IMPORT_MAX_ROWS")
+	if rows <= 0 {
+		return 1000
+	}
+
+	return rows
+}
+
+// staleAfter is how long a running import may go without progress before it can be resumed elsewhere.
+func staleAfter() time.Duration {
+	stale := viper.GetDuration("IMPORT_STALE_AFTER")
+	if stale <= 0 {
+		return 10 * time.Minute
+	}
+
+	return stale
+}
+
+func NewService(r Repository, merchantRepo merchant.Repository, locationRepo location.Repository, onboardingSrv onboarding.Service) Service {
+	return &service{
+		repository:         r,
+		merchantRepository: merchantRepo,
+		locationRepository: locationRepo,
+		onboardingService:  onboardingSrv,
+
+		accountApi: clients.GetAccountClient(),
+	}
+}
@@ -0,0 +1,224 @@
+package merchant_import
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/location"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/services/onboarding"
+	"merchants.sidooh/utils/consts"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	job  *entities.MerchantImport
+	rows []entities.MerchantImportRow
+}
+
+func (f *fakeRepository) CreateImport(job *entities.MerchantImport, rows []entities.MerchantImportRow) (*entities.MerchantImport, error) {
+	job.Id = 1
+	f.job, f.rows = job, rows
+	return job, nil
+}
+
+func (f *fakeRepository) ReadImport(uint) (*presenter.MerchantImport, error) {
+	job := &presenter.MerchantImport{Id: f.job.Id, Status: f.job.Status, Total: f.job.Total, Invalid: f.job.Invalid, Created: f.job.Created, Failed: f.job.Failed}
+	for _, row := range f.rows {
+		job.Rows = append(job.Rows, rowPresenter(row))
+	}
+	return job, nil
+}
+
+func (f *fakeRepository) ReadOutstandingRows(uint) (rows []entities.MerchantImportRow, err error) {
+	for _, row := range f.rows {
+		if row.Status == consts.IMPORT_ROW_VALID || row.Status == consts.IMPORT_ROW_FAILED {
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+func (f *fakeRepository) UpdateRow(row *entities.MerchantImportRow) error {
+	for i := range f.rows {
+		if f.rows[i].Line == row.Line {
+			f.rows[i] = *row
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) ClaimImport(uint, time.Time) (bool, error) {
+	if f.job.Status == consts.IMPORT_RUNNING {
+		return false, nil
+	}
+	f.job.Status = consts.IMPORT_RUNNING
+	return true, nil
+}
+
+func (f *fakeRepository) UpdateProgress(_ uint, status string) error {
+	f.job.Created, f.job.Failed = 0, 0
+	for _, row := range f.rows {
+		switch row.Status {
+		case consts.IMPORT_ROW_CREATED:
+			f.job.Created++
+		case consts.IMPORT_ROW_FAILED:
+			f.job.Failed++
+		}
+	}
+	if status != "" {
+		f.job.Status = status
+	}
+	return nil
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+}
+
+func (f *fakeMerchantRepository) ReadMerchantByIdNumber(idNumber string) (*presenter.Merchant, error) {
+	if idNumber == "99999999" {
+		return &presenter.Merchant{Id: 9}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeMerchantRepository) ReadMerchantByPhone(string) (*presenter.Merchant, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeMerchantRepository) ReadMerchantByAccount(uint) (*presenter.Merchant, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeLocationRepository struct {
+	location.Repository
+}
+
+func (f *fakeLocationRepository) GetLandmark(landmarkId string) (*entities.Location, error) {
+	if landmarkId == "LM1" {
+		return &entities.Location{ModelID: entities.ModelID{Id: 3}}, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeOnboardingService struct {
+	onboarding.Service
+
+	created   int
+	submitted []*entities.Merchant
+	failOn    string
+}
+
+func (f *fakeOnboardingService) CreateMerchant(data *entities.Merchant) (*entities.Merchant, error) {
+	if data.IdNumber == f.failOn {
+		return nil, fmt.Errorf("%w: %s", pkg.ErrOnboardingStepFailed, consts.ONBOARDING_ACCOUNT_LOOKUP)
+	}
+	f.created++
+	data.Id = uint(100 + f.created)
+	return data, nil
+}
+
+func (f *fakeOnboardingService) SubmitKYB(data *entities.Merchant, _ string) (*presenter.Merchant, error) {
+	f.submitted = append(f.submitted, data)
+	return &presenter.Merchant{Id: data.Id}, nil
+}
+
+type fakeAccounts struct{}
+
+func (f *fakeAccounts) GetOrCreateAccount(string) (*clients.Account, error) {
+	return &clients.Account{Id: 50}, nil
+}
+
+func newTestService(repository *fakeRepository, onboardingSrv *fakeOnboardingService) Service {
+	return &service{
+		repository:         repository,
+		merchantRepository: &fakeMerchantRepository{},
+		locationRepository: &fakeLocationRepository{},
+		onboardingService:  onboardingSrv,
+		accountApi:         &fakeAccounts{},
+	}
+}
+
+const validFile = `first_name,last_name,id_number,phone,account_id,business_name,landmark
+Jane,Doe,12345678,0712345678,,Mama Mboga,LM1
+John,Doe,23456789,,7,Duka,Next to the chief's office
+`
+
+func TestService_Validate(t *testing.T) {
+	file := validFile + `Jim,,1234,0712345678,,Kibanda,LM1
+Joy,Doe,99999999,,,Kiosk,LM1
+`
+	report, err := newTestService(&fakeRepository{}, &fakeOnboardingService{}).Validate("merchants.csv", strings.NewReader(file))
+	assert.NoError(t, err)
+
+	assert.Equal(t, consts.IMPORT_INVALID, report.Status)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Invalid)
+
+	assert.Equal(t, "254712345678", report.Rows[0].Phone)
+	assert.Equal(t, uint(7), *report.Rows[1].AccountId)
+	assert.Equal(t, "last_name is required; id_number must be numeric with at least 8 digits; phone is repeated from line 2", *report.Rows[2].Error)
+	assert.Equal(t, "phone or account_id is required; a merchant with this id_number exists", *report.Rows[3].Error)
+}
+
+func TestService_Validate_InvalidFile(t *testing.T) {
+	s := newTestService(&fakeRepository{}, &fakeOnboardingService{})
+
+	_, err := s.Validate("merchants.csv", strings.NewReader("first_name,last_name,id_number,phone\n"))
+	assert.ErrorIs(t, err, pkg.ErrInvalidImportFile)
+
+	_, err = s.Validate("merchants.csv", strings.NewReader(strings.SplitN(validFile, "\n", 2)[0]))
+	assert.ErrorIs(t, err, pkg.ErrInvalidImportFile)
+}
+
+func TestService_Run(t *testing.T) {
+	repository := &fakeRepository{}
+	onboardingSrv := &fakeOnboardingService{failOn: "23456789"}
+	s := newTestService(repository, onboardingSrv)
+
+	job, err := s.CreateImport("merchants.csv", strings.NewReader(validFile), "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.IMPORT_PENDING, job.Status)
+
+	job, err = s.Run(job.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.IMPORT_PARTIAL, job.Status)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.Failed)
+
+	assert.Equal(t, uint(50), *job.Rows[0].AccountId)
+	assert.Equal(t, uint(3), *onboardingSrv.submitted[0].LocationId)
+	assert.Equal(t, consts.IMPORT_ROW_FAILED, job.Rows[1].Status)
+
+	// Resuming only retries the failed row
+	onboardingSrv.failOn = ""
+	job, err = s.Run(job.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.IMPORT_COMPLETED, job.Status)
+	assert.Equal(t, 2, onboardingSrv.created)
+	assert.Equal(t, "Next to the chief's office", *onboardingSrv.submitted[1].Landmark)
+
+	_, err = s.Run(job.Id)
+	assert.ErrorIs(t, err, pkg.ErrImportNotResumable)
+}
+
+func TestService_Run_Invalid(t *testing.T) {
+	repository := &fakeRepository{}
+	onboardingSrv := &fakeOnboardingService{}
+	s := newTestService(repository, onboardingSrv)
+
+	job, err := s.CreateImport("merchants.csv", strings.NewReader(validFile+"Joy,Doe,99999999,0711111111,,Kiosk,LM1\n"), "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.IMPORT_INVALID, job.Status)
+
+	_, err = s.Run(job.Id)
+	assert.ErrorIs(t, err, pkg.ErrImportNotResumable)
+	assert.Zero(t, onboardingSrv.created)
+}
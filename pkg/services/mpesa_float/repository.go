@@ -0,0 +1,128 @@
+package mpesa_float
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadLastPurchase(merchantId uint, destination string) (*entities.Transaction, error)
+
+	CreateSchedule(schedule *entities.MpesaFloatSchedule) (*entities.MpesaFloatSchedule, error)
+	ReadSchedules(merchantId uint) ([]presenter.MpesaFloatSchedule, error)
+	ReadSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error)
+	UpdateSchedule(id uint, values map[string]interface{}) error
+	ReadDueSchedules(until time.Time) ([]entities.MpesaFloatSchedule, error)
+	AdvanceSchedule(id uint, from, next, lastRun time.Time) error
+
+	CreateRun(run *entities.MpesaFloatScheduleRun) (bool, error)
+	UpdateRun(run *entities.MpesaFloatScheduleRun) error
+	ReadRuns(scheduleId uint, pagination datastore.Pagination) ([]presenter.MpesaFloatScheduleRun, int64, error)
+}
+type repository struct {
+}
+
+// ReadLastPurchase finds the merchant's latest completed float purchase for the agent store destination.
+func (r *repository) ReadLastPurchase(merchantId uint, destination string) (tx *entities.Transaction, err error) {
+	err = datastore.DB.
+		Where("merchant_id = ? AND product = ? AND destination = ? AND status = ?",
+			merchantId, consts.MPESA_FLOAT, destination, "COMPLETED").
+		Order("id desc").
+		First(&tx).Error
+	return
+}
+
+func (r *repository) CreateSchedule(schedule *entities.MpesaFloatSchedule) (*entities.MpesaFloatSchedule, error) {
+	result := datastore.DB.Create(&schedule)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return schedule, nil
+}
+
+func (r *repository) ReadSchedules(merchantId uint) (schedules []presenter.MpesaFloatSchedule, err error) {
+	err = scheduleQuery().
+		Where("mpesa_float_schedules.merchant_id", merchantId).
+		Order("mpesa_float_schedules.id desc").
+		Scan(&schedules).Error
+	return
+}
+
+func (r *repository) ReadSchedule(merchantId, id uint) (schedule *presenter.MpesaFloatSchedule, err error) {
+	err = scheduleQuery().
+		Where("mpesa_float_schedules.merchant_id = ? AND mpesa_float_schedules.id = ?", merchantId, id).
+		Take(&schedule).Error
+	return
+}
+
+func (r *repository) UpdateSchedule(id uint, values map[string]interface{}) error {
+	return datastore.DB.Model(&entities.MpesaFloatSchedule{}).Where("id", id).Updates(values).Error
+}
+
+// ReadDueSchedules returns active schedules due by the time given, with the store they buy for.
+// Stores the merchant has since deleted are left empty.
+func (r *repository) ReadDueSchedules(until time.Time) (schedules []entities.MpesaFloatSchedule, err error) {
+	err = datastore.DB.
+		Preload("StoreAccount").
+		Where("status = ? AND next_run_at <= ?", consts.SCHEDULE_ACTIVE, until).
+		Order("next_run_at, id").
+		Find(&schedules).Error
+	return
+}
+
+// AdvanceSchedule moves the schedule on from the run it was due for, unless another run already did.
+func (r *repository) AdvanceSchedule(id uint, from, next, lastRun time.Time) error {
+	return datastore.DB.Model(&entities.MpesaFloatSchedule{}).
+		Where("id = ? AND next_run_at = ?", id, from).
+		Updates(map[string]interface{}{"next_run_at": next, "last_run_at": lastRun}).Error
+}
+
+// CreateRun records the schedule's run for a due time, reporting false when it was already recorded
+// so concurrent runners never purchase twice.
+func (r *repository) CreateRun(run *entities.MpesaFloatScheduleRun) (bool, error) {
+	result := datastore.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&run)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) UpdateRun(run *entities.MpesaFloatScheduleRun) error {
+	return datastore.DB.Save(run).Error
+}
+
+func (r *repository) ReadRuns(scheduleId uint, pagination datastore.Pagination) (runs []presenter.MpesaFloatScheduleRun, total int64, err error) {
+	query := datastore.DB.Model(&entities.MpesaFloatScheduleRun{}).
+		Where("mpesa_float_schedule_runs.schedule_id", scheduleId)
+
+	if err = query.Count(&total).Error; err != nil {
+		return
+	}
+
+	err = query.
+		Select("mpesa_float_schedule_runs.id, mpesa_float_schedule_runs.scheduled_for, mpesa_float_schedule_runs.status, " +
+			"mpesa_float_schedule_runs.error, mpesa_float_schedule_runs.transaction_id, mpesa_float_schedule_runs.created_at, " +
+			"transactions.status AS transaction_status, transactions.amount").
+		Joins("LEFT JOIN transactions ON transactions.id = mpesa_float_schedule_runs.transaction_id").
+		Order("mpesa_float_schedule_runs.scheduled_for desc, mpesa_float_schedule_runs.id desc").
+		Scopes(datastore.Paginate(pagination)).
+		Scan(&runs).Error
+	return
+}
+
+func scheduleQuery() *gorm.DB {
+	return datastore.DB.Table("mpesa_float_schedules").
+		Select("mpesa_float_schedules.*, mpesa_agent_store_accounts.agent, mpesa_agent_store_accounts.store, " +
+			"mpesa_agent_store_accounts.name, mpesa_agent_store_accounts.nickname").
+		Joins("JOIN mpesa_agent_store_accounts ON mpesa_agent_store_accounts.id = mpesa_float_schedules.store_account_id")
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
@@ -0,0 +1,85 @@
+package mpesa_float
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"merchants.sidooh/pkg"
+	"slices"
+	"strings"
+	"time"
+)
+
+// weekdays are the day names schedules are written with, in time.Weekday order.
+var weekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// parseDays returns the days as stored, de-duplicated in week order starting on monday.
+func parseDays(days []string) (string, error) {
+	for _, day := range days {
+		if !slices.Contains(weekdays, strings.ToUpper(day)) {
+			return "", fmt.Errorf("%w: unknown day %q", pkg.ErrInvalidSchedule, day)
+		}
+	}
+
+	var parsed []string
+	for _, day := range append(weekdays[1:], weekdays[0]) {
+		if slices.ContainsFunc(days, func(d string) bool { return strings.EqualFold(d, day) }) {
+			parsed = append(parsed, day)
+		}
+	}
+	if len(parsed) == 0 {
+		return "", fmt.Errorf("%w: at least one day is required", pkg.ErrInvalidSchedule)
+	}
+
+	return strings.Join(parsed, ","), nil
+}
+
+// parseClock validates a HH:MM time of day.
+func parseClock(clock string) (hour, minute int, err error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: time must be HH:MM", pkg.ErrInvalidSchedule)
+	}
+
+	return parsed.Hour(), parsed.Minute(), nil
+}
+
+// nextRun is the first time after the one given that the schedule is due in the schedule's time zone,
+// returned in UTC as schedule times are stored.
+func nextRun(days, clock string, after time.Time, location *time.Location) (time.Time, error) {
+	hour, minute, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	after = after.In(location)
+	for i := 0; i <= 7; i++ {
+		date := after.AddDate(0, 0, i)
+		candidate := time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, location)
+		if candidate.After(after) && strings.Contains(days, weekdays[candidate.Weekday()]) {
+			return candidate.UTC(), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%w: no days to run on", pkg.ErrInvalidSchedule)
+}
+
+// scheduleLocation is the time zone schedule times are in, merchants set them in local time.
+func scheduleLocation() *time.Location {
+	if name := viper.GetString("SCHEDULE_TIMEZONE"); name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+
+	return time.FixedZone("EAT", 3*60*60)
+}
+
+// runGrace is how late a run may start, e.g. after the job runner was down, before it is skipped.
+func runGrace() time.Duration {
+	grace := viper.GetDuration("SCHEDULE_RUN_GRACE")
+	if grace <= 0 {
+		return time.Hour
+	}
+
+	return grace
+}
@@ -0,0 +1,313 @@
+package mpesa_float
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/services/mpesa_store"
+	"merchants.sidooh/pkg/services/operator"
+	"merchants.sidooh/pkg/services/transaction"
+	"merchants.sidooh/utils/consts"
+	"time"
+)
+
+type Service interface {
+	RepeatLastPurchase(merchantId, storeId uint, operatorAccountId *uint) (*entities.Transaction, error)
+
+	CreateSchedule(request ScheduleRequest) (*presenter.MpesaFloatSchedule, error)
+	GetSchedules(merchantId uint) ([]presenter.MpesaFloatSchedule, error)
+	GetSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error)
+	PauseSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error)
+	ResumeSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error)
+	GetScheduleRuns(merchantId, id uint, pagination datastore.Pagination) (*presenter.Paginated, error)
+
+	RunDueSchedules() (int, error)
+}
+
+// ScheduleRequest is a recurring purchase for a saved store, paid from the merchant's voucher float.
+type ScheduleRequest struct {
+	MerchantId        uint
+	StoreAccountId    uint
+	Amount            float32
+	Days              []string
+	Time              string
+	OperatorAccountId *uint
+}
+
+const (
+	repeatDescription    = "Mpesa Float Purchase"
+	scheduledDescription = "Scheduled Mpesa Float Purchase"
+)
+
+type service struct {
+	repository           Repository
+	mpesaStoreRepository mpesa_store.Repository
+	merchantRepository   merchant.Repository
+
+	transactionService transaction.Service
+	operatorService    operator.Service
+
+	now      func() time.Time
+	location *time.Location
+}
+
+// RepeatLastPurchase buys the same amount as the merchant's last completed purchase for the saved store,
+// paying from the voucher float.
+func (s *service) RepeatLastPurchase(merchantId, storeId uint, operatorAccountId *uint) (*entities.Transaction, error) {
+	store, err := s.mpesaStoreRepository.ReadStore(merchantId, storeId)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := fmt.Sprintf("%v-%v", store.Agent, store.Store)
+	last, err := s.repository.ReadLastPurchase(merchantId, destination)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkg.ErrNoPreviousPurchase
+		}
+		return nil, err
+	}
+
+	return s.transactionService.PurchaseMpesaFloat(&entities.Transaction{
+		Amount:      last.Amount,
+		Description: repeatDescription,
+		Destination: &destination,
+		MerchantId:  merchantId,
+		Product:     consts.MPESA_FLOAT,
+
+		OperatorAccountId: operatorAccountId,
+	}, store.Agent, store.Store, "", "")
+}
+
+func (s *service) CreateSchedule(request ScheduleRequest) (*presenter.MpesaFloatSchedule, error) {
+	if request.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be more than 0", pkg.ErrInvalidSchedule)
+	}
+	days, err := parseDays(request.Days)
+	if err != nil {
+		return nil, err
+	}
+	next, err := nextRun(days, request.Time, s.now(), s.location)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.mpesaStoreRepository.ReadStore(request.MerchantId, request.StoreAccountId); err != nil {
+		return nil, err
+	}
+
+	merchant, err := s.merchantRepository.ReadMerchant(request.MerchantId)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status != consts.MERCHANT_ACTIVE || merchant.FloatAccountId == 0 {
+		return nil, pkg.ErrMerchantNotActive
+	}
+	// Runs are made on behalf of whoever created the schedule, so they have to be allowed to buy float
+	if err = s.operatorService.Authorize(merchant, request.OperatorAccountId, consts.MPESA_FLOAT, request.Amount); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.repository.CreateSchedule(&entities.MpesaFloatSchedule{
+		Amount:            request.Amount,
+		Days:              days,
+		Time:              request.Time,
+		Status:            consts.SCHEDULE_ACTIVE,
+		NextRunAt:         &next,
+		StoreAccountId:    request.StoreAccountId,
+		MerchantId:        request.MerchantId,
+		OperatorAccountId: request.OperatorAccountId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.ReadSchedule(schedule.MerchantId, schedule.Id)
+}
+
+func (s *service) GetSchedules(merchantId uint) ([]presenter.MpesaFloatSchedule, error) {
+	schedules, err := s.repository.ReadSchedules(merchantId)
+	if schedules == nil {
+		schedules = []presenter.MpesaFloatSchedule{}
+	}
+
+	return schedules, err
+}
+
+func (s *service) GetSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error) {
+	return s.repository.ReadSchedule(merchantId, id)
+}
+
+// PauseSchedule stops the schedule's runs until it is resumed, pausing a paused schedule changes nothing.
+func (s *service) PauseSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error) {
+	schedule, err := s.repository.ReadSchedule(merchantId, id)
+	if err != nil || schedule.Status == consts.SCHEDULE_PAUSED {
+		return schedule, err
+	}
+
+	err = s.repository.UpdateSchedule(id, map[string]interface{}{"status": consts.SCHEDULE_PAUSED, "next_run_at": nil})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.ReadSchedule(merchantId, id)
+}
+
+// ResumeSchedule picks up from the next time the schedule is due, runs missed while paused are not made up.
+func (s *service) ResumeSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error) {
+	schedule, err := s.repository.ReadSchedule(merchantId, id)
+	if err != nil || schedule.Status == consts.SCHEDULE_ACTIVE {
+		return schedule, err
+	}
+
+	if _, err = s.mpesaStoreRepository.ReadStore(merchantId, schedule.StoreAccountId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: the store is no longer saved", pkg.ErrInvalidSchedule)
+		}
+		return nil, err
+	}
+
+	next, err := nextRun(schedule.Days, schedule.Time, s.now(), s.location)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repository.UpdateSchedule(id, map[string]interface{}{"status": consts.SCHEDULE_ACTIVE, "next_run_at": next})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.ReadSchedule(merchantId, id)
+}
+
+func (s *service) GetScheduleRuns(merchantId, id uint, pagination datastore.Pagination) (*presenter.Paginated, error) {
+	if _, err := s.repository.ReadSchedule(merchantId, id); err != nil {
+		return nil, err
+	}
+
+	pagination = pagination.Normalize()
+	runs, total, err := s.repository.ReadRuns(id, pagination)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []presenter.MpesaFloatScheduleRun{}
+	}
+
+	return &presenter.Paginated{Data: runs, Page: pagination.Page, PageSize: pagination.PageSize, Total: total}, nil
+}
+
+// RunDueSchedules makes the purchases of every schedule that is due, returning how many runs it made.
+// It is driven by the job runner and is safe to call again while a previous call is still running.
+func (s *service) RunDueSchedules() (int, error) {
+	now := s.now().UTC()
+
+	schedules, err := s.repository.ReadDueSchedules(now)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, schedule := range schedules {
+		ran, err := s.run(schedule, now)
+		if err != nil {
+			logger.ClientLog.Error("Error running float purchase schedule", "schedule", schedule.Id, "error", err)
+			continue
+		}
+		if ran {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *service) run(schedule entities.MpesaFloatSchedule, now time.Time) (bool, error) {
+	due := *schedule.NextRunAt
+
+	next, err := nextRun(schedule.Days, schedule.Time, now, s.location)
+	if err != nil {
+		return false, err
+	}
+
+	run := &entities.MpesaFloatScheduleRun{ScheduleId: schedule.Id, ScheduledFor: due, Status: consts.RUN_PENDING}
+	claimed, err := s.repository.CreateRun(run)
+	if err != nil {
+		return false, err
+	}
+
+	// Advanced even when another runner claimed the run, so a schedule never gets stuck on a run that was interrupted
+	if err = s.repository.AdvanceSchedule(schedule.Id, due, next, now); err != nil || !claimed {
+		return false, err
+	}
+
+	switch {
+	case now.Sub(due) > runGrace():
+		run.Status = consts.RUN_SKIPPED
+		run.Error = message(fmt.Sprintf("missed, it was due at %s", due.In(s.location).Format("02/01/2006 15:04")))
+
+	case schedule.StoreAccount.Id == 0:
+		run.Status = consts.RUN_FAILED
+		run.Error = message("the store is no longer saved, the schedule was paused")
+
+		err = s.repository.UpdateSchedule(schedule.Id, map[string]interface{}{"status": consts.SCHEDULE_PAUSED, "next_run_at": nil})
+		if err != nil {
+			return false, err
+		}
+
+	default:
+		destination := fmt.Sprintf("%v-%v", schedule.StoreAccount.Agent, schedule.StoreAccount.Store)
+		tx, err := s.transactionService.PurchaseMpesaFloat(&entities.Transaction{
+			Amount:      schedule.Amount,
+			Description: scheduledDescription,
+			Destination: &destination,
+			MerchantId:  schedule.MerchantId,
+			Product:     consts.MPESA_FLOAT,
+
+			OperatorAccountId: schedule.OperatorAccountId,
+		}, schedule.StoreAccount.Agent, schedule.StoreAccount.Store, "", "")
+
+		switch {
+		case err != nil:
+			run.Status = consts.RUN_FAILED
+			run.Error = message(err.Error())
+		case tx == nil:
+			run.Status = consts.RUN_FAILED
+			run.Error = message("the purchase could not be made")
+		default:
+			run.Status = consts.RUN_SUBMITTED
+			run.TransactionId = &tx.Id
+		}
+	}
+
+	return true, s.repository.UpdateRun(run)
+}
+
+// message fits a run's error in its column.
+func message(value string) *string {
+	if len(value) > 255 {
+		value = value[:255]
+	}
+
+	return &value
+}
+
+func NewService(r Repository, mpesaStoreRepo mpesa_store.Repository, merchantRepo merchant.Repository, transactionSrv transaction.Service, operatorSrv operator.Service) Service {
+	return &service{
+		repository:           r,
+		mpesaStoreRepository: mpesaStoreRepo,
+		merchantRepository:   merchantRepo,
+
+		transactionService: transactionSrv,
+		operatorService:    operatorSrv,
+
+		now:      time.Now,
+		location: scheduleLocation(),
+	}
+}
@@ -0,0 +1,286 @@
+package mpesa_float
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/services/mpesa_store"
+	"merchants.sidooh/pkg/services/operator"
+	"merchants.sidooh/pkg/services/transaction"
+	"merchants.sidooh/utils/consts"
+	"testing"
+	"time"
+)
+
+var eat = time.FixedZone("EAT", 3*60*60)
+
+type fakeRepository struct {
+	Repository
+
+	schedules []*entities.MpesaFloatSchedule
+	runs      []*entities.MpesaFloatScheduleRun
+	last      *entities.Transaction
+}
+
+func (f *fakeRepository) ReadLastPurchase(uint, string) (*entities.Transaction, error) {
+	if f.last == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return f.last, nil
+}
+
+func (f *fakeRepository) CreateSchedule(schedule *entities.MpesaFloatSchedule) (*entities.MpesaFloatSchedule, error) {
+	schedule.Id = uint(len(f.schedules) + 1)
+	f.schedules = append(f.schedules, schedule)
+	return schedule, nil
+}
+
+func (f *fakeRepository) ReadSchedule(merchantId, id uint) (*presenter.MpesaFloatSchedule, error) {
+	for _, s := range f.schedules {
+		if s.Id == id && s.MerchantId == merchantId {
+			return &presenter.MpesaFloatSchedule{Id: s.Id, Amount: s.Amount, Days: s.Days, Time: s.Time, Status: s.Status,
+				NextRunAt: s.NextRunAt, StoreAccountId: s.StoreAccountId, MerchantId: s.MerchantId}, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepository) UpdateSchedule(id uint, values map[string]interface{}) error {
+	schedule := f.schedules[id-1]
+	schedule.Status = values["status"].(string)
+	if next, ok := values["next_run_at"].(time.Time); ok {
+		schedule.NextRunAt = &next
+	} else {
+		schedule.NextRunAt = nil
+	}
+	return nil
+}
+
+func (f *fakeRepository) ReadDueSchedules(until time.Time) (schedules []entities.MpesaFloatSchedule, err error) {
+	for _, s := range f.schedules {
+		if s.Status == consts.SCHEDULE_ACTIVE && !s.NextRunAt.After(until) {
+			schedules = append(schedules, *s)
+		}
+	}
+	return
+}
+
+func (f *fakeRepository) AdvanceSchedule(id uint, from, next, lastRun time.Time) error {
+	if schedule := f.schedules[id-1]; schedule.NextRunAt.Equal(from) {
+		schedule.NextRunAt = &next
+		schedule.LastRunAt = &lastRun
+	}
+	return nil
+}
+
+func (f *fakeRepository) CreateRun(run *entities.MpesaFloatScheduleRun) (bool, error) {
+	for _, r := range f.runs {
+		if r.ScheduleId == run.ScheduleId && r.ScheduledFor.Equal(run.ScheduledFor) {
+			return false, nil
+		}
+	}
+	f.runs = append(f.runs, run)
+	return true, nil
+}
+
+func (f *fakeRepository) UpdateRun(*entities.MpesaFloatScheduleRun) error {
+	return nil
+}
+
+type fakeStoreRepository struct {
+	mpesa_store.Repository
+}
+
+func (f *fakeStoreRepository) ReadStore(merchantId, id uint) (*presenter.MpesaAgentStoreAccount, error) {
+	if id != 3 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &presenter.MpesaAgentStoreAccount{Id: id, Agent: "123456", Store: "1"}, nil
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+}
+
+func (f *fakeMerchantRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	return &presenter.Merchant{Id: id, AccountId: 10, FloatAccountId: 7, Status: consts.MERCHANT_ACTIVE}, nil
+}
+
+type fakeOperatorService struct {
+	operator.Service
+}
+
+func (f *fakeOperatorService) Authorize(_ *presenter.Merchant, accountId *uint, _ string, _ float32) error {
+	if accountId != nil && *accountId == 99 {
+		return pkg.ErrOperatorNotPermitted
+	}
+	return nil
+}
+
+type fakeTransactionService struct {
+	transaction.Service
+
+	purchases []*entities.Transaction
+	err       error
+}
+
+func (f *fakeTransactionService) PurchaseMpesaFloat(tx *entities.Transaction, agent, store, source, sourceAccount string) (*entities.Transaction, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	tx.Id = uint(len(f.purchases) + 100)
+	f.purchases = append(f.purchases, tx)
+	return tx, nil
+}
+
+func newTestService(repository *fakeRepository, transactions *fakeTransactionService, now *time.Time) Service {
+	return &service{
+		repository:           repository,
+		mpesaStoreRepository: &fakeStoreRepository{},
+		merchantRepository:   &fakeMerchantRepository{},
+		transactionService:   transactions,
+		operatorService:      &fakeOperatorService{},
+		now:                  func() time.Time { return *now },
+		location:             eat,
+	}
+}
+
+func TestService_RepeatLastPurchase(t *testing.T) {
+	repository := &fakeRepository{}
+	transactions := &fakeTransactionService{}
+	now := time.Now()
+	s := newTestService(repository, transactions, &now)
+
+	_, err := s.RepeatLastPurchase(1, 3, nil)
+	assert.ErrorIs(t, err, pkg.ErrNoPreviousPurchase)
+
+	_, err = s.RepeatLastPurchase(1, 4, nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	repository.last = &entities.Transaction{Amount: 2500}
+	tx, err := s.RepeatLastPurchase(1, 3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, float32(2500), tx.Amount)
+	assert.Equal(t, "123456-1", *tx.Destination)
+	assert.Equal(t, consts.MPESA_FLOAT, tx.Product)
+}
+
+func TestService_CreateSchedule(t *testing.T) {
+	// Friday 08:00 in Nairobi
+	now := time.Date(2024, 3, 15, 5, 0, 0, 0, time.UTC)
+	s := newTestService(&fakeRepository{}, &fakeTransactionService{}, &now)
+
+	schedule, err := s.CreateSchedule(ScheduleRequest{MerchantId: 1, StoreAccountId: 3, Amount: 1000, Days: []string{"fri", "MON", "MON"}, Time: "07:00"})
+	assert.NoError(t, err)
+	assert.Equal(t, "MON,FRI", schedule.Days)
+	assert.Equal(t, time.Date(2024, 3, 18, 7, 0, 0, 0, eat), schedule.NextRunAt.In(eat))
+
+	_, err = s.CreateSchedule(ScheduleRequest{MerchantId: 1, StoreAccountId: 3, Amount: 1000, Days: []string{"MON"}, Time: "7am"})
+	assert.ErrorIs(t, err, pkg.ErrInvalidSchedule)
+
+	_, err = s.CreateSchedule(ScheduleRequest{MerchantId: 1, StoreAccountId: 4, Amount: 1000, Days: []string{"MON"}, Time: "07:00"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	cashier := uint(99)
+	_, err = s.CreateSchedule(ScheduleRequest{MerchantId: 1, StoreAccountId: 3, Amount: 1000, Days: []string{"MON"}, Time: "07:00", OperatorAccountId: &cashier})
+	assert.ErrorIs(t, err, pkg.ErrOperatorNotPermitted)
+}
+
+func TestService_RunDueSchedules(t *testing.T) {
+	repository := &fakeRepository{}
+	transactions := &fakeTransactionService{}
+	// Friday 06:00 in Nairobi
+	now := time.Date(2024, 3, 15, 3, 0, 0, 0, time.UTC)
+	s := newTestService(repository, transactions, &now)
+
+	_, _ = s.CreateSchedule(ScheduleRequest{MerchantId: 1, StoreAccountId: 3, Amount: 1000, Days: []string{"MON", "TUE", "WED", "THU", "FRI"}, Time: "07:00"})
+	repository.schedules[0].StoreAccount = entities.MpesaAgentStoreAccount{ModelID: entities.ModelID{Id: 3}, Agent: "123456", Store: "1"}
+
+	count, err := s.RunDueSchedules()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	now = time.Date(2024, 3, 15, 4, 1, 0, 0, time.UTC)
+	count, _ = s.RunDueSchedules()
+	assert.Equal(t, 1, count)
+	assert.Len(t, transactions.purchases, 1)
+	assert.Equal(t, consts.RUN_SUBMITTED, repository.runs[0].Status)
+	assert.Equal(t, uint(100), *repository.runs[0].TransactionId)
+	// Over the weekend to monday
+	assert.Equal(t, time.Date(2024, 3, 18, 7, 0, 0, 0, eat), repository.schedules[0].NextRunAt.In(eat))
+
+	// Running again does not purchase twice
+	count, _ = s.RunDueSchedules()
+	assert.Equal(t, 0, count)
+
+	// A failed purchase is recorded with its reason
+	transactions.err = pkg.ErrLimitExceeded
+	now = time.Date(2024, 3, 18, 4, 0, 0, 0, time.UTC)
+	count, _ = s.RunDueSchedules()
+	assert.Equal(t, 1, count)
+	assert.Equal(t, consts.RUN_FAILED, repository.runs[1].Status)
+	assert.Equal(t, pkg.ErrLimitExceeded.Error(), *repository.runs[1].Error)
+
+	// Runs the runner missed are skipped rather than made late
+	transactions.err = nil
+	now = time.Date(2024, 3, 19, 12, 0, 0, 0, time.UTC)
+	count, _ = s.RunDueSchedules()
+	assert.Equal(t, 1, count)
+	assert.Equal(t, consts.RUN_SKIPPED, repository.runs[2].Status)
+	assert.Len(t, transactions.purchases, 1)
+	assert.Equal(t, time.Date(2024, 3, 20, 7, 0, 0, 0, eat), repository.schedules[0].NextRunAt.In(eat))
+}
+
+func TestService_RunDueSchedules_RemovedStore(t *testing.T) {
+	repository := &fakeRepository{}
+	now := time.Date(2024, 3, 15, 3, 0, 0, 0, time.UTC)
+	s := newTestService(repository, &fakeTransactionService{err: errors.New("unexpected")}, &now)
+
+	_, _ = s.CreateSchedule(ScheduleRequest{MerchantId: 1, StoreAccountId: 3, Amount: 1000, Days: []string{"FRI"}, Time: "07:00"})
+
+	now = now.Add(time.Hour)
+	count, _ := s.RunDueSchedules()
+	assert.Equal(t, 1, count)
+	assert.Equal(t, consts.RUN_FAILED, repository.runs[0].Status)
+	assert.Equal(t, consts.SCHEDULE_PAUSED, repository.schedules[0].Status)
+	assert.Nil(t, repository.schedules[0].NextRunAt)
+}
+
+func TestService_PauseResumeSchedule(t *testing.T) {
+	repository := &fakeRepository{}
+	now := time.Date(2024, 3, 15, 3, 0, 0, 0, time.UTC)
+	s := newTestService(repository, &fakeTransactionService{}, &now)
+
+	created, _ := s.CreateSchedule(ScheduleRequest{MerchantId: 1, StoreAccountId: 3, Amount: 1000, Days: []string{"FRI"}, Time: "07:00"})
+
+	paused, err := s.PauseSchedule(1, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.SCHEDULE_PAUSED, paused.Status)
+	assert.Nil(t, paused.NextRunAt)
+
+	count, _ := s.RunDueSchedules()
+	assert.Equal(t, 0, count)
+
+	// Resumed after this friday's run was due, so it picks up next week
+	now = time.Date(2024, 3, 15, 6, 0, 0, 0, time.UTC)
+	resumed, err := s.ResumeSchedule(1, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.SCHEDULE_ACTIVE, resumed.Status)
+	assert.Equal(t, time.Date(2024, 3, 22, 7, 0, 0, 0, eat), resumed.NextRunAt.In(eat))
+
+	_, err = s.PauseSchedule(2, created.Id)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_GetScheduleRuns(t *testing.T) {
+	now := time.Now()
+	s := newTestService(&fakeRepository{}, &fakeTransactionService{}, &now)
+
+	_, err := s.GetScheduleRuns(1, 1, datastore.Pagination{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
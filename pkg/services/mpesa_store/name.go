@@ -0,0 +1,49 @@
+package mpesa_store
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	maxNameWords  = 4
+	maxNameLength = 64
+)
+
+// ParseStoreName extracts the store's name from the store description payments reports on a float purchase.
+// Numbers and separators around the name are dropped, and it is cut to its first few words so it fits the column.
+// An empty string means the description had no usable name.
+func ParseStoreName(description string) string {
+	words := strings.Fields(description)
+
+	isNoise := func(word string) bool {
+		return strings.IndexFunc(word, func(r rune) bool { return unicode.IsLetter(r) }) == -1
+	}
+	for len(words) > 0 && isNoise(words[0]) {
+		words = words[1:]
+	}
+	for len(words) > 0 && isNoise(words[len(words)-1]) {
+		words = words[:len(words)-1]
+	}
+
+	if len(words) > maxNameWords {
+		words = words[:maxNameWords]
+	}
+
+	name := []rune(strings.Join(words, " "))
+	if len(name) > maxNameLength {
+		name = []rune(strings.TrimSpace(string(name[:maxNameLength])))
+	}
+
+	return string(name)
+}
+
+// ParseDestination splits a float purchase destination of the form agent-store.
+func ParseDestination(destination string) (agent, store string, ok bool) {
+	agent, store, ok = strings.Cut(destination, "-")
+	if !ok || agent == "" || store == "" {
+		return "", "", false
+	}
+
+	return agent, store, true
+}
@@ -0,0 +1,40 @@
+package mpesa_store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseStoreName(t *testing.T) {
+	tests := map[string]string{
+		"":                                   "",
+		"   ":                                "",
+		"12345 - 1":                          "",
+		"SHOP":                               "SHOP",
+		"MAMA MBOGA":                         "MAMA MBOGA",
+		"  MAMA   MBOGA  STORES ":            "MAMA MBOGA STORES",
+		"MAMA MBOGA STORES KAWANGWARE STAGE": "MAMA MBOGA STORES KAWANGWARE",
+		"123456 - MAMA MBOGA STORES - 1":     "MAMA MBOGA STORES",
+		"Duka la Mzee 2":                     "Duka la Mzee",
+	}
+
+	for description, name := range tests {
+		assert.Equal(t, name, ParseStoreName(description), description)
+	}
+
+	long := ParseStoreName(strings.Repeat("A", 40) + " " + strings.Repeat("B", 40))
+	assert.Equal(t, strings.Repeat("A", 40)+" "+strings.Repeat("B", 23), long)
+}
+
+func TestParseDestination(t *testing.T) {
+	agent, store, ok := ParseDestination("123456-1")
+	assert.True(t, ok)
+	assert.Equal(t, "123456", agent)
+	assert.Equal(t, "1", store)
+
+	for _, destination := range []string{"", "123456", "123456-", "-1"} {
+		_, _, ok = ParseDestination(destination)
+		assert.False(t, ok, destination)
+	}
+}
@@ -1,16 +1,28 @@
 package mpesa_store
 
 import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"merchants.sidooh/api/presenter"
 	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
+	"strings"
 )
 
 // Repository interface allows us to access the CRUD Operations here.
 type Repository interface {
 	CreateStore(store *entities.MpesaAgentStoreAccount) (*entities.MpesaAgentStoreAccount, error)
+	CaptureStore(store *entities.MpesaAgentStoreAccount) (bool, error)
 	ReadStoresByMerchant(merchantId uint) (*[]presenter.MpesaAgentStoreAccount, error)
-	ReadAllStores() ([]*presenter.MpesaStore, error)
+	ReadStore(merchantId, id uint) (*presenter.MpesaAgentStoreAccount, error)
+	ReadStoreByNumbers(merchantId uint, agent, store string) (*entities.MpesaAgentStoreAccount, error)
+	ReadStoreName(agent, store string) (string, error)
+	UpdateStore(merchantId, id uint, values map[string]interface{}) (*presenter.MpesaAgentStoreAccount, error)
+	RestoreStore(id uint, values map[string]interface{}) error
+	DeleteStore(merchantId, id uint) error
+	RecordStore(store *entities.MpesaAgentStore) error
+	ReadDirectoryStore(agent, store string) (*presenter.MpesaStore, error)
+	ReadDirectoryStores(search string, pagination datastore.Pagination) ([]presenter.MpesaStore, int64, error)
 }
 type repository struct {
 }
@@ -24,17 +36,127 @@ func (r *repository) CreateStore(store *entities.MpesaAgentStoreAccount) (*entit
 	return store, nil
 }
 
+// CaptureStore saves the store unless the merchant has it already, including ones they deleted, reporting whether it was saved.
+func (r *repository) CaptureStore(store *entities.MpesaAgentStoreAccount) (bool, error) {
+	result := datastore.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&store)
+
+	return result.RowsAffected == 1, result.Error
+}
+
+// ReadStoresByMerchant lists favourites first, then the most recently saved.
 func (r *repository) ReadStoresByMerchant(merchantId uint) (stores *[]presenter.MpesaAgentStoreAccount, err error) {
-	err = datastore.DB.Where("merchant_id", merchantId).Find(&stores).Error
+	err = datastore.DB.Model(&entities.MpesaAgentStoreAccount{}).
+		Where("merchant_id", merchantId).
+		Order("favourite desc, id desc").
+		Find(&stores).Error
+	return
+}
+
+func (r *repository) ReadStore(merchantId, id uint) (store *presenter.MpesaAgentStoreAccount, err error) {
+	err = datastore.DB.Model(&entities.MpesaAgentStoreAccount{}).
+		Where("merchant_id", merchantId).
+		First(&store, id).Error
+	return
+}
+
+// ReadStoreByNumbers also finds stores the merchant deleted.
+func (r *repository) ReadStoreByNumbers(merchantId uint, agent, store string) (account *entities.MpesaAgentStoreAccount, err error) {
+	err = datastore.DB.Unscoped().
+		Where("merchant_id = ? AND agent = ? AND store = ?", merchantId, agent, store).
+		First(&account).Error
+	return
+}
+
+// ReadStoreName returns the name payments reported for the store when any merchant bought from it.
+func (r *repository) ReadStoreName(agent, store string) (name string, err error) {
+	err = datastore.DB.Unscoped().Model(&entities.MpesaAgentStoreAccount{}).
+		Select("name").
+		Where("agent = ? AND store = ? AND name <> ''", agent, store).
+		Order("id desc").
+		Limit(1).
+		Scan(&name).Error
 	return
 }
 
-func (r *repository) ReadAllStores() (stores []*presenter.MpesaStore, err error) {
-	err = datastore.DB.Model(entities.MpesaAgentStoreAccount{}).
-		Select("agent", "store", "name").
-		Distinct("agent", "store", "name").
-		Find(&stores).
-		Error
+func (r *repository) UpdateStore(merchantId, id uint, values map[string]interface{}) (*presenter.MpesaAgentStoreAccount, error) {
+	// Checked first since updates that change nothing affect no rows on mysql
+	if _, err := r.ReadStore(merchantId, id); err != nil {
+		return nil, err
+	}
+
+	err := datastore.DB.Model(&entities.MpesaAgentStoreAccount{}).
+		Where("merchant_id = ? AND id = ?", merchantId, id).
+		Updates(values).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ReadStore(merchantId, id)
+}
+
+func (r *repository) RestoreStore(id uint, values map[string]interface{}) error {
+	values["deleted_at"] = nil
+
+	return datastore.DB.Unscoped().Model(&entities.MpesaAgentStoreAccount{}).
+		Where("id", id).
+		Updates(values).Error
+}
+
+func (r *repository) DeleteStore(merchantId, id uint) error {
+	result := datastore.DB.Where("merchant_id", merchantId).Delete(&entities.MpesaAgentStoreAccount{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RecordStore adds the store to the directory or counts another use of it, a blank name keeps the known one.
+func (r *repository) RecordStore(store *entities.MpesaAgentStore) error {
+	updates := map[string]interface{}{
+		"usage_count":  gorm.Expr("usage_count + 1"),
+		"last_seen_at": store.LastSeenAt,
+		"updated_at":   store.LastSeenAt,
+	}
+	if store.Name != "" {
+		updates["name"] = store.Name
+	}
+
+	store.UsageCount = 1
+	return datastore.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "agent"}, {Name: "store"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(&store).Error
+}
+
+func (r *repository) ReadDirectoryStore(agent, store string) (directoryStore *presenter.MpesaStore, err error) {
+	err = datastore.DB.Model(&entities.MpesaAgentStore{}).
+		Where("agent = ? AND store = ?", agent, store).
+		First(&directoryStore).Error
+	return
+}
+
+// ReadDirectoryStores lists the most used stores first. Every search term has to match the start of the agent
+// or store number, or any part of the name.
+func (r *repository) ReadDirectoryStores(search string, pagination datastore.Pagination) (stores []presenter.MpesaStore, total int64, err error) {
+	query := datastore.DB.Model(&entities.MpesaAgentStore{})
+	for _, term := range strings.Fields(search) {
+		query = query.Where(datastore.DB.
+			Where("agent LIKE ?", term+"%").
+			Or("store LIKE ?", term+"%").
+			Or("name LIKE ?", "%"+term+"%"))
+	}
+
+	if err = query.Count(&total).Error; err != nil {
+		return
+	}
+
+	err = query.Order("usage_count desc, last_seen_at desc, id").
+		Scopes(datastore.Paginate(pagination)).
+		Find(&stores).Error
 	return
 }
 
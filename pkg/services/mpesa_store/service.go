@@ -1,30 +1,203 @@
 package mpesa_store
 
 import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
 	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
+	"regexp"
+	"strings"
+	"time"
 )
 
 type Service interface {
-	FetchAllStores() ([]*presenter.MpesaStore, error)
+	SearchStores(search string, pagination datastore.Pagination) (*presenter.Paginated, error)
 	FetchStoresByMerchant(merchantId uint) (*[]presenter.MpesaAgentStoreAccount, error)
-	CreateStore(store *entities.MpesaAgentStoreAccount) (*entities.MpesaAgentStoreAccount, error)
+	GetStore(merchantId, id uint) (*presenter.MpesaAgentStoreAccount, error)
+	CreateStore(store *entities.MpesaAgentStoreAccount) (*presenter.MpesaAgentStoreAccount, error)
+	UpdateStore(merchantId, id uint, update StoreUpdate) (*presenter.MpesaAgentStoreAccount, error)
+	DeleteStore(merchantId, id uint) error
+	CaptureStore(merchantId uint, agent, store, name string) error
+	RecordPurchase(merchantId uint, destination, description string, at time.Time) error
 }
 
+// StoreUpdate holds the fields a merchant may change, nil fields are left as they are and an empty nickname clears it.
+type StoreUpdate struct {
+	Nickname  *string
+	Favourite *bool
+}
+
+var (
+	agentRegex = regexp.MustCompile(`^\d{5,8}$`)
+	storeRegex = regexp.MustCompile(`^\d{1,8}$`)
+)
+
 type service struct {
 	repository Repository
 }
 
-func (s *service) FetchAllStores() ([]*presenter.MpesaStore, error) {
-	return s.repository.ReadAllStores()
+// SearchStores looks up the store directory, with an empty search listing the most used stores.
+func (s *service) SearchStores(search string, pagination datastore.Pagination) (*presenter.Paginated, error) {
+	pagination = pagination.Normalize()
+
+	stores, total, err := s.repository.ReadDirectoryStores(search, pagination)
+	if err != nil {
+		return nil, err
+	}
+	if stores == nil {
+		stores = []presenter.MpesaStore{}
+	}
+
+	return &presenter.Paginated{Data: stores, Page: pagination.Page, PageSize: pagination.PageSize, Total: total}, nil
 }
 
 func (s *service) FetchStoresByMerchant(merchantId uint) (*[]presenter.MpesaAgentStoreAccount, error) {
 	return s.repository.ReadStoresByMerchant(merchantId)
 }
 
-func (s *service) CreateStore(store *entities.MpesaAgentStoreAccount) (*entities.MpesaAgentStoreAccount, error) {
-	return s.repository.CreateStore(store)
+func (s *service) GetStore(merchantId, id uint) (*presenter.MpesaAgentStoreAccount, error) {
+	return s.repository.ReadStore(merchantId, id)
+}
+
+// CreateStore saves a store for the merchant, a store they deleted before is restored with the new details.
+func (s *service) CreateStore(store *entities.MpesaAgentStoreAccount) (*presenter.MpesaAgentStoreAccount, error) {
+	if err := validate(store.Agent, store.Store); err != nil {
+		return nil, err
+	}
+	store.Nickname = nickname(store.Nickname)
+
+	existing, err := s.repository.ReadStoreByNumbers(store.MerchantId, store.Agent, store.Store)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		if !existing.DeletedAt.Valid {
+			return nil, pkg.ErrMpesaStoreExists
+		}
+
+		err = s.repository.RestoreStore(existing.Id, map[string]interface{}{"nickname": store.Nickname, "favourite": store.Favourite})
+		if err != nil {
+			return nil, err
+		}
+
+		return s.repository.ReadStore(store.MerchantId, existing.Id)
+	}
+
+	if store.Name == "" {
+		if store.Name, err = s.storeName(store.Agent, store.Store); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := s.repository.CreateStore(store)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repository.ReadStore(created.MerchantId, created.Id)
+}
+
+func (s *service) UpdateStore(merchantId, id uint, update StoreUpdate) (*presenter.MpesaAgentStoreAccount, error) {
+	values := map[string]interface{}{}
+	if update.Nickname != nil {
+		values["nickname"] = nickname(update.Nickname)
+	}
+	if update.Favourite != nil {
+		values["favourite"] = *update.Favourite
+	}
+	if len(values) == 0 {
+		return s.repository.ReadStore(merchantId, id)
+	}
+
+	return s.repository.UpdateStore(merchantId, id, values)
+}
+
+func (s *service) DeleteStore(merchantId, id uint) error {
+	return s.repository.DeleteStore(merchantId, id)
+}
+
+// CaptureStore saves the store a merchant bought float from. It is safe to repeat for the same purchase,
+// and stores the merchant deleted are not saved again.
+func (s *service) CaptureStore(merchantId uint, agent, store, name string) error {
+	if err := validate(agent, store); err != nil {
+		return err
+	}
+
+	_, err := s.repository.CaptureStore(&entities.MpesaAgentStoreAccount{
+		Agent:      agent,
+		Store:      store,
+		Name:       name,
+		MerchantId: merchantId,
+	})
+
+	return err
+}
+
+// RecordPurchase notes a float purchase in the store directory and saves the store for the merchant,
+// naming it from the store description payments reported.
+func (s *service) RecordPurchase(merchantId uint, destination, description string, at time.Time) error {
+	agent, store, ok := ParseDestination(destination)
+	if !ok {
+		return fmt.Errorf("%w: unexpected destination %q", pkg.ErrInvalidMpesaStore, destination)
+	}
+	if err := validate(agent, store); err != nil {
+		return err
+	}
+
+	name := ParseStoreName(description)
+	err := s.repository.RecordStore(&entities.MpesaAgentStore{Agent: agent, Store: store, Name: name, LastSeenAt: at})
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		if name, err = s.storeName(agent, store); err != nil {
+			return err
+		}
+	}
+
+	return s.CaptureStore(merchantId, agent, store, name)
+}
+
+// storeName prefers the directory's name, falling back to one merchants saved before the directory existed.
+func (s *service) storeName(agent, store string) (string, error) {
+	directoryStore, err := s.repository.ReadDirectoryStore(agent, store)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if directoryStore != nil && directoryStore.Name != "" {
+		return directoryStore.Name, nil
+	}
+
+	return s.repository.ReadStoreName(agent, store)
+}
+
+func validate(agent, store string) error {
+	if !agentRegex.MatchString(agent) {
+		return fmt.Errorf("%w: agent number must be 5 to 8 digits", pkg.ErrInvalidMpesaStore)
+	}
+	if !storeRegex.MatchString(store) {
+		return fmt.Errorf("%w: store number must be 1 to 8 digits", pkg.ErrInvalidMpesaStore)
+	}
+
+	return nil
+}
+
+// nickname trims the nickname, blank nicknames are stored as none.
+func nickname(value *string) *string {
+	if value == nil {
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil
+	}
+
+	return &trimmed
 }
 
 func NewService(r Repository) Service {
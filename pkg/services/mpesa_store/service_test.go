@@ -0,0 +1,234 @@
+package mpesa_store
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	Repository
+
+	stores    []*entities.MpesaAgentStoreAccount
+	directory []*entities.MpesaAgentStore
+}
+
+func (f *fakeRepository) RecordStore(store *entities.MpesaAgentStore) error {
+	for _, s := range f.directory {
+		if s.Agent == store.Agent && s.Store == store.Store {
+			s.UsageCount++
+			s.LastSeenAt = store.LastSeenAt
+			if store.Name != "" {
+				s.Name = store.Name
+			}
+			return nil
+		}
+	}
+	store.UsageCount = 1
+	f.directory = append(f.directory, store)
+	return nil
+}
+
+func (f *fakeRepository) ReadDirectoryStore(agent, store string) (*presenter.MpesaStore, error) {
+	for _, s := range f.directory {
+		if s.Agent == agent && s.Store == store {
+			return &presenter.MpesaStore{Agent: s.Agent, Store: s.Store, Name: s.Name, UsageCount: s.UsageCount, LastSeenAt: s.LastSeenAt}, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepository) ReadDirectoryStores(search string, pagination datastore.Pagination) (stores []presenter.MpesaStore, total int64, err error) {
+	for _, s := range f.directory {
+		if strings.HasPrefix(s.Agent, search) || strings.Contains(s.Name, search) {
+			stores = append(stores, presenter.MpesaStore{Agent: s.Agent, Store: s.Store, Name: s.Name, UsageCount: s.UsageCount})
+		}
+	}
+	return stores, int64(len(stores)), nil
+}
+
+func (f *fakeRepository) CreateStore(store *entities.MpesaAgentStoreAccount) (*entities.MpesaAgentStoreAccount, error) {
+	store.Id = uint(len(f.stores) + 1)
+	f.stores = append(f.stores, store)
+	return store, nil
+}
+
+func (f *fakeRepository) CaptureStore(store *entities.MpesaAgentStoreAccount) (bool, error) {
+	if existing, _ := f.ReadStoreByNumbers(store.MerchantId, store.Agent, store.Store); existing != nil {
+		return false, nil
+	}
+	_, err := f.CreateStore(store)
+	return true, err
+}
+
+func (f *fakeRepository) ReadStore(merchantId, id uint) (*presenter.MpesaAgentStoreAccount, error) {
+	for _, store := range f.stores {
+		if store.MerchantId == merchantId && store.Id == id && !store.DeletedAt.Valid {
+			return &presenter.MpesaAgentStoreAccount{Id: store.Id, Agent: store.Agent, Store: store.Store, Name: store.Name, Nickname: store.Nickname, Favourite: store.Favourite}, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepository) ReadStoreByNumbers(merchantId uint, agent, store string) (*entities.MpesaAgentStoreAccount, error) {
+	for _, s := range f.stores {
+		if s.MerchantId == merchantId && s.Agent == agent && s.Store == store {
+			return s, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepository) ReadStoreName(agent, store string) (string, error) {
+	for _, s := range f.stores {
+		if s.Agent == agent && s.Store == store && s.Name != "" {
+			return s.Name, nil
+		}
+	}
+	return "", nil
+}
+
+func (f *fakeRepository) UpdateStore(merchantId, id uint, values map[string]interface{}) (*presenter.MpesaAgentStoreAccount, error) {
+	if _, err := f.ReadStore(merchantId, id); err != nil {
+		return nil, err
+	}
+	store := f.stores[id-1]
+	if nickname, ok := values["nickname"]; ok {
+		store.Nickname = nickname.(*string)
+	}
+	if favourite, ok := values["favourite"]; ok {
+		store.Favourite = favourite.(bool)
+	}
+	return f.ReadStore(merchantId, id)
+}
+
+func (f *fakeRepository) RestoreStore(id uint, values map[string]interface{}) error {
+	store := f.stores[id-1]
+	store.DeletedAt = gorm.DeletedAt{}
+	store.Nickname = values["nickname"].(*string)
+	store.Favourite = values["favourite"].(bool)
+	return nil
+}
+
+func (f *fakeRepository) DeleteStore(merchantId, id uint) error {
+	if _, err := f.ReadStore(merchantId, id); err != nil {
+		return err
+	}
+	f.stores[id-1].DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+func TestService_CreateStore(t *testing.T) {
+	repository := &fakeRepository{stores: []*entities.MpesaAgentStoreAccount{
+		{ModelID: entities.ModelID{Id: 1}, Agent: "123456", Store: "1", Name: "Mama Mboga Shop", MerchantId: 2},
+	}}
+	s := NewService(repository)
+
+	nickname := "  Corner shop "
+	store, err := s.CreateStore(&entities.MpesaAgentStoreAccount{Agent: "123456", Store: "1", Nickname: &nickname, MerchantId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Mama Mboga Shop", store.Name)
+	assert.Equal(t, "Corner shop", *store.Nickname)
+
+	_, err = s.CreateStore(&entities.MpesaAgentStoreAccount{Agent: "123456", Store: "1", MerchantId: 1})
+	assert.ErrorIs(t, err, pkg.ErrMpesaStoreExists)
+
+	_, err = s.CreateStore(&entities.MpesaAgentStoreAccount{Agent: "1234", Store: "1", MerchantId: 1})
+	assert.ErrorIs(t, err, pkg.ErrInvalidMpesaStore)
+
+	_, err = s.CreateStore(&entities.MpesaAgentStoreAccount{Agent: "123456", Store: "1a", MerchantId: 1})
+	assert.ErrorIs(t, err, pkg.ErrInvalidMpesaStore)
+}
+
+func TestService_DeleteStore_RestoresOnCreate(t *testing.T) {
+	repository := &fakeRepository{}
+	s := NewService(repository)
+
+	store, _ := s.CreateStore(&entities.MpesaAgentStoreAccount{Agent: "123456", Store: "1", MerchantId: 1})
+	assert.NoError(t, s.DeleteStore(1, store.Id))
+	assert.ErrorIs(t, s.DeleteStore(1, store.Id), gorm.ErrRecordNotFound)
+
+	// Purchases don't bring a deleted store back
+	assert.NoError(t, s.CaptureStore(1, "123456", "1", "Shop"))
+	_, err := s.GetStore(1, store.Id)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	restored, err := s.CreateStore(&entities.MpesaAgentStoreAccount{Agent: "123456", Store: "1", Favourite: true, MerchantId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, store.Id, restored.Id)
+	assert.True(t, restored.Favourite)
+	assert.Len(t, repository.stores, 1)
+}
+
+func TestService_UpdateStore(t *testing.T) {
+	s := NewService(&fakeRepository{})
+	nickname := "Shop"
+	store, _ := s.CreateStore(&entities.MpesaAgentStoreAccount{Agent: "123456", Store: "1", Nickname: &nickname, MerchantId: 1})
+
+	favourite := true
+	updated, err := s.UpdateStore(1, store.Id, StoreUpdate{Favourite: &favourite})
+	assert.NoError(t, err)
+	assert.True(t, updated.Favourite)
+	assert.Equal(t, "Shop", *updated.Nickname)
+
+	blank := " "
+	updated, err = s.UpdateStore(1, store.Id, StoreUpdate{Nickname: &blank})
+	assert.NoError(t, err)
+	assert.Nil(t, updated.Nickname)
+
+	_, err = s.UpdateStore(2, store.Id, StoreUpdate{Favourite: &favourite})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_CaptureStore(t *testing.T) {
+	repository := &fakeRepository{}
+	s := NewService(repository)
+
+	assert.NoError(t, s.CaptureStore(1, "123456", "1", "Shop"))
+	assert.NoError(t, s.CaptureStore(1, "123456", "1", "Shop"))
+	assert.Len(t, repository.stores, 1)
+
+	assert.ErrorIs(t, s.CaptureStore(1, "", "1", "Shop"), pkg.ErrInvalidMpesaStore)
+}
+
+func TestService_RecordPurchase(t *testing.T) {
+	repository := &fakeRepository{}
+	s := NewService(repository)
+	at := time.Date(2024, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, s.RecordPurchase(1, "123456-1", "MAMA MBOGA STORES KAWANGWARE STAGE", at))
+	// Short descriptions no longer panic, and one without a name keeps the known one
+	assert.NoError(t, s.RecordPurchase(2, "123456-1", "", at.Add(time.Hour)))
+	assert.NoError(t, s.RecordPurchase(1, "123456-1", "MAMA", at.Add(2*time.Hour)))
+
+	assert.Len(t, repository.directory, 1)
+	assert.Equal(t, uint(3), repository.directory[0].UsageCount)
+	assert.Equal(t, "MAMA", repository.directory[0].Name)
+	assert.Equal(t, at.Add(2*time.Hour), repository.directory[0].LastSeenAt)
+
+	assert.Len(t, repository.stores, 2)
+	assert.Equal(t, "MAMA MBOGA STORES KAWANGWARE", repository.stores[0].Name)
+	assert.Equal(t, "MAMA MBOGA STORES KAWANGWARE", repository.stores[1].Name)
+
+	assert.ErrorIs(t, s.RecordPurchase(1, "123456", "SHOP", at), pkg.ErrInvalidMpesaStore)
+}
+
+func TestService_SearchStores(t *testing.T) {
+	s := NewService(&fakeRepository{})
+
+	result, err := s.SearchStores("1234", datastore.Pagination{})
+	assert.NoError(t, err)
+	assert.Equal(t, []presenter.MpesaStore{}, result.Data)
+	assert.Equal(t, 1, result.Page)
+	assert.Equal(t, datastore.DefaultPageSize, result.PageSize)
+
+	_ = s.RecordPurchase(1, "123456-1", "SHOP", time.Now())
+	result, _ = s.SearchStores("1234", datastore.Pagination{Page: 1, PageSize: 5})
+	assert.Equal(t, int64(1), result.Total)
+}
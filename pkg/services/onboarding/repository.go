@@ -0,0 +1,44 @@
+package onboarding
+
+import (
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadSteps(merchantId uint) (*[]presenter.OnboardingStep, error)
+	ReadStep(merchantId uint, step string) (*entities.OnboardingStep, error)
+	SaveStep(step *entities.OnboardingStep) (*entities.OnboardingStep, error)
+}
+type repository struct {
+}
+
+func (r *repository) ReadSteps(merchantId uint) (steps *[]presenter.OnboardingStep, err error) {
+	err = datastore.DB.Model(&entities.OnboardingStep{}).
+		Where("merchant_id", merchantId).
+		Order("id").
+		Find(&steps).
+		Error
+	return
+}
+
+func (r *repository) ReadStep(merchantId uint, step string) (result *entities.OnboardingStep, err error) {
+	err = datastore.DB.Where("merchant_id", merchantId).Where("step", step).First(&result).Error
+	return
+}
+
+func (r *repository) SaveStep(step *entities.OnboardingStep) (*entities.OnboardingStep, error) {
+	result := datastore.DB.Save(step)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return step, nil
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
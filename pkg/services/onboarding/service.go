@@ -0,0 +1,338 @@
+package onboarding
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/document"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	CreateMerchant(data *entities.Merchant) (*entities.Merchant, error)
+	SubmitKYB(data *entities.Merchant, actor string) (*presenter.Merchant, error)
+	Resume(merchantId uint) (*presenter.Merchant, error)
+	GetSteps(merchantId uint) (*[]presenter.OnboardingStep, error)
+}
+
+// steps that run after KYB details are submitted, each has to be safe to re-run.
+var steps = []string{
+	consts.ONBOARDING_DOCUMENTS,
+	consts.ONBOARDING_CODE_ALLOCATION,
+	consts.ONBOARDING_FLOAT_ACCOUNT,
+	consts.ONBOARDING_WELCOME_NOTIFICATION,
+}
+
+// audit attributes the changes onboarding steps make to the service itself.
+var audit = merchant.Audit{Actor: "SYSTEM", Source: consts.AUDIT_ONBOARDING}
+
+type service struct {
+	repository         Repository
+	merchantRepository merchant.Repository
+	merchantService    merchant.Service
+	documentRepository document.Repository
+
+	accountApi  *clients.ApiClient
+	paymentsApi floatAccountApi
+	notifyApi   smsApi
+}
+
+// floatAccountApi and smsApi are the parts of the payments and notify clients onboarding steps use.
+type floatAccountApi interface {
+	FetchMerchantFloatAccount(merchantId, accountId int) (*clients.FloatAccount, error)
+	CreateFloatAccount(merchantId, accountId, code int) (*clients.FloatAccount, error)
+}
+
+type smsApi interface {
+	SendSMS(event, phone, message string) error
+}
+
+// CreateMerchant runs the account lookup and KYC steps, after which the merchant waits on KYB details.
+func (s *service) CreateMerchant(data *entities.Merchant) (*entities.Merchant, error) {
+	account, err := s.accountApi.GetAccountById(strconv.Itoa(int(data.AccountId)))
+	if err != nil {
+		logger.ClientLog.Error("onboarding account lookup failed", "account", data.AccountId, "err", err)
+		return nil, fmt.Errorf("%w: %s", pkg.ErrOnboardingStepFailed, consts.ONBOARDING_ACCOUNT_LOOKUP)
+	}
+
+	data.Phone = account.Phone
+	data.Status = consts.MERCHANT_PENDING_KYC
+	data.OnboardingStep = consts.ONBOARDING_KYB
+	merchant, err := s.merchantRepository.CreateMerchant(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.completeStep(merchant.Id, consts.ONBOARDING_ACCOUNT_LOOKUP)
+	s.completeStep(merchant.Id, consts.ONBOARDING_KYC)
+
+	_, err = s.merchantRepository.CreateStatusChange(&entities.MerchantStatusChange{
+		ToStatus:   merchant.Status,
+		Reason:     "KYC details created",
+		Actor:      "SYSTEM",
+		MerchantId: merchant.Id,
+	})
+	if err != nil {
+		logger.ClientLog.Error("failed to record merchant status change", "merchant", merchant.Id, "err", err)
+	}
+
+	// A merchant left in PENDING_KYC here is moved on when onboarding activates it
+	if _, err = s.merchantService.SetSystemStatus(merchant.Id, consts.MERCHANT_PENDING_KYB, "KYC details completed"); err != nil {
+		logger.ClientLog.Error("failed to complete merchant KYC", "merchant", merchant.Id, "err", err)
+	} else {
+		merchant.Status = consts.MERCHANT_PENDING_KYB
+	}
+
+	go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, "KYC details created")
+
+	return merchant, nil
+}
+
+// SubmitKYB saves the KYB details and continues onboarding. Once onboarding is complete it only updates the details.
+func (s *service) SubmitKYB(data *entities.Merchant, actor string) (*presenter.Merchant, error) {
+	merchant, err := s.merchantRepository.ReadMerchant(data.Id)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status == consts.MERCHANT_SUSPENDED || merchant.Status == consts.MERCHANT_CLOSED {
+		return nil, pkg.ErrMerchantNotActive
+	}
+
+	if merchant.OnboardingStep == consts.ONBOARDING_KYB {
+		data.OnboardingStep = steps[0]
+	}
+
+	merchant, err = s.merchantRepository.UpdateMerchant(data, auditBy(actor))
+	if err != nil {
+		return nil, err
+	}
+	if merchant.OnboardingStep == consts.ONBOARDING_COMPLETED {
+		go s.notifyApi.SendSMS("DEFAULT", merchant.Phone, "KYB details updated")
+
+		return merchant, nil
+	}
+
+	s.completeStep(merchant.Id, consts.ONBOARDING_KYB)
+
+	merchant, err = s.run(merchant)
+	if errors.Is(err, pkg.ErrOnboardingInputRequired) {
+		return merchant, nil
+	}
+
+	return merchant, err
+}
+
+// Resume retries onboarding from the step that last failed.
+func (s *service) Resume(merchantId uint) (*presenter.Merchant, error) {
+	merchant, err := s.merchantRepository.ReadMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+	if merchant.Status == consts.MERCHANT_SUSPENDED || merchant.Status == consts.MERCHANT_CLOSED {
+		return nil, pkg.ErrMerchantNotActive
+	}
+
+	switch merchant.OnboardingStep {
+	case consts.ONBOARDING_COMPLETED:
+		return merchant, nil
+	case consts.ONBOARDING_KYB, consts.ONBOARDING_KYC, consts.ONBOARDING_ACCOUNT_LOOKUP, "":
+		return nil, pkg.ErrOnboardingInputRequired
+	}
+
+	return s.run(merchant)
+}
+
+func (s *service) GetSteps(merchantId uint) (*[]presenter.OnboardingStep, error) {
+	return s.repository.ReadSteps(merchantId)
+}
+
+func (s *service) run(merchant *presenter.Merchant) (*presenter.Merchant, error) {
+	start := slices.Index(steps, merchant.OnboardingStep)
+	if start < 0 {
+		return nil, pkg.ErrOnboardingInputRequired
+	}
+
+	id := merchant.Id
+	for i, step := range steps[start:] {
+		var err error
+		switch step {
+		case consts.ONBOARDING_DOCUMENTS:
+			err = s.checkDocuments(merchant)
+			if errors.Is(err, pkg.ErrOnboardingInputRequired) {
+				// Not a failure, onboarding continues once the documents are approved
+				return merchant, err
+			}
+		case consts.ONBOARDING_CODE_ALLOCATION:
+			merchant, err = s.allocateCode(merchant)
+		case consts.ONBOARDING_FLOAT_ACCOUNT:
+			merchant, err = s.createFloatAccount(merchant)
+		case consts.ONBOARDING_WELCOME_NOTIFICATION:
+			err = s.sendWelcomeNotification(merchant)
+		}
+
+		if err != nil {
+			s.failStep(id, step, err)
+			return nil, fmt.Errorf("%w: %s", pkg.ErrOnboardingStepFailed, step)
+		}
+
+		s.completeStep(id, step)
+
+		next := consts.ONBOARDING_COMPLETED
+		if start+i+1 < len(steps) {
+			next = steps[start+i+1]
+		}
+
+		merchant, err = s.merchantRepository.UpdateMerchantColumn(id, "onboarding_step", next, audit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.merchantRepository.UpdateMerchantColumn(id, "onboarding_error", nil, audit)
+}
+
+func (s *service) checkDocuments(merchant *presenter.Merchant) error {
+	missing, err := document.MissingDocuments(s.documentRepository, merchant.Id)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: awaiting approved %s documents", pkg.ErrOnboardingInputRequired, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func (s *service) allocateCode(merchant *presenter.Merchant) (*presenter.Merchant, error) {
+	return s.merchantService.AssignCode(merchant.Id)
+}
+
+func (s *service) createFloatAccount(merchant *presenter.Merchant) (*presenter.Merchant, error) {
+	if merchant.FloatAccountId == 0 {
+		// An earlier attempt may have opened the float account and failed before saving it
+		floatAccount, err := s.paymentsApi.FetchMerchantFloatAccount(int(merchant.Id), int(merchant.AccountId))
+		if err != nil {
+			return nil, err
+		}
+
+		if floatAccount == nil {
+			code, err := strconv.Atoi(merchant.Code)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q is not numeric", pkg.ErrInvalidMerchantCode, merchant.Code)
+			}
+
+			floatAccount, err = s.paymentsApi.CreateFloatAccount(int(merchant.Id), int(merchant.AccountId), code)
+			if err != nil {
+				return nil, err
+			}
+			if floatAccount == nil {
+				return nil, errors.New("float account was not returned")
+			}
+		}
+
+		merchant, err = s.merchantRepository.UpdateMerchantColumn(merchant.Id, "float_account_id", floatAccount.Id, audit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if merchant.Status == consts.MERCHANT_PENDING_KYC {
+		var err error
+		merchant, err = s.merchantService.SetSystemStatus(merchant.Id, consts.MERCHANT_PENDING_KYB, "KYC details completed")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if merchant.Status == consts.MERCHANT_PENDING_KYB {
+		return s.merchantService.SetSystemStatus(merchant.Id, consts.MERCHANT_ACTIVE, "KYB details completed")
+	}
+
+	return merchant, nil
+}
+
+func (s *service) sendWelcomeNotification(merchant *presenter.Merchant) error {
+	message := fmt.Sprintf("Welcome to Sidooh Merchants, %s. Your merchant code is %s.", merchant.BusinessName, merchant.Code)
+
+	return s.notifyApi.SendSMS("DEFAULT", merchant.Phone, message)
+}
+
+func (s *service) completeStep(merchantId uint, name string) {
+	now := time.Now()
+
+	step := s.readStep(merchantId, name)
+	step.Attempts++
+	step.Status = consts.STEP_COMPLETED
+	step.Error = nil
+	step.CompletedAt = &now
+
+	if _, err := s.repository.SaveStep(step); err != nil {
+		logger.ClientLog.Error("failed to save onboarding step", "merchant", merchantId, "step", name, "err", err)
+	}
+}
+
+func (s *service) failStep(merchantId uint, name string, cause error) {
+	logger.ClientLog.Error("onboarding step failed", "merchant", merchantId, "step", name, "err", cause)
+
+	message := cause.Error()
+	if len(message) > 255 {
+		message = message[:255]
+	}
+
+	step := s.readStep(merchantId, name)
+	step.Attempts++
+	step.Status = consts.STEP_FAILED
+	step.Error = &message
+
+	if _, err := s.repository.SaveStep(step); err != nil {
+		logger.ClientLog.Error("failed to save onboarding step", "merchant", merchantId, "step", name, "err", err)
+	}
+
+	_, err := s.merchantRepository.UpdateMerchant(&entities.Merchant{
+		ModelID:         entities.ModelID{Id: merchantId},
+		OnboardingStep:  name,
+		OnboardingError: &message,
+	}, audit)
+	if err != nil {
+		logger.ClientLog.Error("failed to save onboarding error", "merchant", merchantId, "err", err)
+	}
+}
+
+func auditBy(actor string) merchant.Audit {
+	return merchant.Audit{Actor: actor, Source: consts.AUDIT_API}
+}
+
+func (s *service) readStep(merchantId uint, name string) *entities.OnboardingStep {
+	step, err := s.repository.ReadStep(merchantId, name)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.ClientLog.Error("failed to read onboarding step", "merchant", merchantId, "step", name, "err", err)
+		}
+
+		return &entities.OnboardingStep{MerchantId: merchantId, Step: name, Status: consts.STEP_PENDING}
+	}
+
+	return step
+}
+
+func NewService(r Repository, merchantRepo merchant.Repository, merchantSrv merchant.Service, documentRepo document.Repository) Service {
+	return &service{
+		repository:         r,
+		merchantRepository: merchantRepo,
+		merchantService:    merchantSrv,
+		documentRepository: documentRepo,
+
+		accountApi:  clients.GetAccountClient(),
+		paymentsApi: clients.GetPaymentClient(),
+		notifyApi:   clients.GetNotifyClient(),
+	}
+}
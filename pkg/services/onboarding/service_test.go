@@ -0,0 +1,236 @@
+package onboarding
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/document"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"testing"
+)
+
+type fakeRepository struct {
+	Repository
+
+	steps map[string]*entities.OnboardingStep
+}
+
+func (f *fakeRepository) ReadStep(merchantId uint, step string) (*entities.OnboardingStep, error) {
+	saved, ok := f.steps[fmt.Sprint(merchantId, step)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *saved
+	return &copied, nil
+}
+
+func (f *fakeRepository) SaveStep(step *entities.OnboardingStep) (*entities.OnboardingStep, error) {
+	saved := *step
+	f.steps[fmt.Sprint(step.MerchantId, step.Step)] = &saved
+	return step, nil
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+
+	merchants map[uint]*presenter.Merchant
+}
+
+func (f *fakeMerchantRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	m, ok := f.merchants[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *m
+	return &copied, nil
+}
+
+func (f *fakeMerchantRepository) UpdateMerchant(data *entities.Merchant, audit merchant.Audit) (*presenter.Merchant, error) {
+	m := f.merchants[data.Id]
+	if data.OnboardingStep != "" {
+		m.OnboardingStep = data.OnboardingStep
+	}
+	if data.OnboardingError != nil {
+		m.OnboardingError = *data.OnboardingError
+	}
+	return f.ReadMerchant(data.Id)
+}
+
+func (f *fakeMerchantRepository) UpdateMerchantColumn(id uint, column string, value interface{}, audit merchant.Audit) (*presenter.Merchant, error) {
+	m := f.merchants[id]
+	switch column {
+	case "onboarding_step":
+		m.OnboardingStep = value.(string)
+	case "onboarding_error":
+		m.OnboardingError = ""
+	case "float_account_id":
+		m.FloatAccountId = uint(value.(int))
+	}
+	return f.ReadMerchant(id)
+}
+
+type fakeMerchantService struct {
+	merchant.Service
+
+	repository *fakeMerchantRepository
+}
+
+func (f *fakeMerchantService) AssignCode(id uint) (*presenter.Merchant, error) {
+	if m := f.repository.merchants[id]; m.Code == "" {
+		m.Code = "123458"
+	}
+	return f.repository.ReadMerchant(id)
+}
+
+func (f *fakeMerchantService) SetSystemStatus(id uint, status, reason string) (*presenter.Merchant, error) {
+	f.repository.merchants[id].Status = status
+	return f.repository.ReadMerchant(id)
+}
+
+type fakeDocumentRepository struct {
+	document.Repository
+
+	approved []string
+}
+
+func (f *fakeDocumentRepository) ReadApprovedTypes(merchantId uint) ([]string, error) {
+	return f.approved, nil
+}
+
+type fakePaymentsApi struct {
+	existing *clients.FloatAccount
+	err      error
+	created  []int
+}
+
+func (f *fakePaymentsApi) FetchMerchantFloatAccount(merchantId, accountId int) (*clients.FloatAccount, error) {
+	return f.existing, f.err
+}
+
+func (f *fakePaymentsApi) CreateFloatAccount(merchantId, accountId, code int) (*clients.FloatAccount, error) {
+	f.created = append(f.created, code)
+	return &clients.FloatAccount{Id: 7, AccountId: accountId}, nil
+}
+
+type fakeNotifyApi struct {
+	sent []string
+}
+
+func (f *fakeNotifyApi) SendSMS(event, phone, message string) error {
+	f.sent = append(f.sent, message)
+	return nil
+}
+
+func newTestService(merchants ...presenter.Merchant) (*service, *fakeRepository, *fakeMerchantRepository, *fakeDocumentRepository, *fakePaymentsApi) {
+	merchantRepo := &fakeMerchantRepository{merchants: map[uint]*presenter.Merchant{}}
+	for i := range merchants {
+		merchantRepo.merchants[merchants[i].Id] = &merchants[i]
+	}
+	repo := &fakeRepository{steps: map[string]*entities.OnboardingStep{}}
+	documentRepo := &fakeDocumentRepository{approved: document.RequiredTypes()}
+	payments := &fakePaymentsApi{}
+
+	return &service{
+		repository:         repo,
+		merchantRepository: merchantRepo,
+		merchantService:    &fakeMerchantService{repository: merchantRepo},
+		documentRepository: documentRepo,
+		paymentsApi:        payments,
+		notifyApi:          &fakeNotifyApi{},
+	}, repo, merchantRepo, documentRepo, payments
+}
+
+func TestService_Resume_RecordsFailureAndResumes(t *testing.T) {
+	s, repo, merchantRepo, _, payments := newTestService(presenter.Merchant{
+		Id: 1, AccountId: 10, Status: consts.MERCHANT_PENDING_KYB, OnboardingStep: consts.ONBOARDING_DOCUMENTS,
+	})
+	payments.err = errors.New("payments service unavailable")
+
+	_, err := s.Resume(1)
+	assert.ErrorIs(t, err, pkg.ErrOnboardingStepFailed)
+
+	assert.Equal(t, consts.STEP_COMPLETED, repo.steps["1"+consts.ONBOARDING_DOCUMENTS].Status)
+	assert.Equal(t, consts.STEP_COMPLETED, repo.steps["1"+consts.ONBOARDING_CODE_ALLOCATION].Status)
+	failed := repo.steps["1"+consts.ONBOARDING_FLOAT_ACCOUNT]
+	assert.Equal(t, consts.STEP_FAILED, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "payments service unavailable", *failed.Error)
+
+	m := merchantRepo.merchants[1]
+	assert.Equal(t, consts.ONBOARDING_FLOAT_ACCOUNT, m.OnboardingStep)
+	assert.Equal(t, "payments service unavailable", m.OnboardingError)
+	assert.Equal(t, "123458", m.Code)
+	assert.Equal(t, consts.MERCHANT_PENDING_KYB, m.Status)
+
+	payments.err = nil
+	merchant, err := s.Resume(1)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.ONBOARDING_COMPLETED, merchant.OnboardingStep)
+	assert.Equal(t, "", merchant.OnboardingError)
+	assert.Equal(t, uint(7), merchant.FloatAccountId)
+	assert.Equal(t, consts.MERCHANT_ACTIVE, merchant.Status)
+	assert.Equal(t, []int{123458}, payments.created)
+
+	// Completed steps aren't run again
+	assert.Equal(t, 1, repo.steps["1"+consts.ONBOARDING_CODE_ALLOCATION].Attempts)
+	done := repo.steps["1"+consts.ONBOARDING_FLOAT_ACCOUNT]
+	assert.Equal(t, consts.STEP_COMPLETED, done.Status)
+	assert.Equal(t, 2, done.Attempts)
+	assert.Nil(t, done.Error)
+	assert.Equal(t, consts.STEP_COMPLETED, repo.steps["1"+consts.ONBOARDING_WELCOME_NOTIFICATION].Status)
+
+	merchant, err = s.Resume(1)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.ONBOARDING_COMPLETED, merchant.OnboardingStep)
+	assert.Len(t, payments.created, 1)
+}
+
+func TestService_Resume_ReusesExistingFloatAccount(t *testing.T) {
+	s, _, merchantRepo, _, payments := newTestService(presenter.Merchant{
+		Id: 1, AccountId: 10, Code: "123458", Status: consts.MERCHANT_PENDING_KYB, OnboardingStep: consts.ONBOARDING_FLOAT_ACCOUNT,
+	})
+	payments.existing = &clients.FloatAccount{Id: 5, AccountId: 10}
+
+	merchant, err := s.Resume(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), merchant.FloatAccountId)
+	assert.Empty(t, payments.created)
+	assert.Equal(t, consts.MERCHANT_ACTIVE, merchantRepo.merchants[1].Status)
+}
+
+func TestService_Resume_AwaitsDocuments(t *testing.T) {
+	s, repo, merchantRepo, documentRepo, _ := newTestService(presenter.Merchant{
+		Id: 1, AccountId: 10, Status: consts.MERCHANT_PENDING_KYB, OnboardingStep: consts.ONBOARDING_DOCUMENTS,
+	})
+	documentRepo.approved = nil
+
+	merchant, err := s.Resume(1)
+	assert.ErrorIs(t, err, pkg.ErrOnboardingInputRequired)
+	assert.Equal(t, consts.ONBOARDING_DOCUMENTS, merchant.OnboardingStep)
+	assert.Empty(t, repo.steps)
+	assert.Equal(t, "", merchantRepo.merchants[1].OnboardingError)
+
+	documentRepo.approved = document.RequiredTypes()
+	merchant, err = s.Resume(1)
+	assert.NoError(t, err)
+	assert.Equal(t, consts.ONBOARDING_COMPLETED, merchant.OnboardingStep)
+}
+
+func TestService_Resume_NeedsKYB(t *testing.T) {
+	s, _, _, _, _ := newTestService(
+		presenter.Merchant{Id: 1, Status: consts.MERCHANT_PENDING_KYB, OnboardingStep: consts.ONBOARDING_KYB},
+		presenter.Merchant{Id: 2, Status: consts.MERCHANT_SUSPENDED, OnboardingStep: consts.ONBOARDING_FLOAT_ACCOUNT},
+	)
+
+	_, err := s.Resume(1)
+	assert.ErrorIs(t, err, pkg.ErrOnboardingInputRequired)
+
+	_, err = s.Resume(2)
+	assert.ErrorIs(t, err, pkg.ErrMerchantNotActive)
+}
@@ -0,0 +1,86 @@
+package operator
+
+import (
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	CreateOperator(operator *entities.MerchantOperator) (*entities.MerchantOperator, error)
+	ReadOperator(id uint) (*entities.MerchantOperator, error)
+	ReadOperators(merchantId uint) (*[]entities.MerchantOperator, error)
+	ReadActiveOperator(merchantId, accountId uint) (*entities.MerchantOperator, error)
+	ReadOperatorByAccount(merchantId, accountId uint) (*entities.MerchantOperator, error)
+	ReadOperatorsByAccount(accountId uint) (*[]entities.MerchantOperator, error)
+	UpdateOperator(operator *entities.MerchantOperator) (*entities.MerchantOperator, error)
+	ReadDailyAmount(merchantId, accountId uint, since time.Time) (float32, error)
+}
+type repository struct {
+}
+
+func (r *repository) CreateOperator(operator *entities.MerchantOperator) (*entities.MerchantOperator, error) {
+	result := datastore.DB.Create(&operator)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return operator, nil
+}
+
+func (r *repository) ReadOperator(id uint) (operator *entities.MerchantOperator, err error) {
+	err = datastore.DB.First(&operator, id).Error
+	return
+}
+
+func (r *repository) ReadOperators(merchantId uint) (operators *[]entities.MerchantOperator, err error) {
+	err = datastore.DB.Where("merchant_id", merchantId).Where("status", consts.OPERATOR_ACTIVE).Find(&operators).Error
+	return
+}
+
+func (r *repository) ReadActiveOperator(merchantId, accountId uint) (operator *entities.MerchantOperator, err error) {
+	err = datastore.DB.
+		Where("merchant_id", merchantId).
+		Where("account_id", accountId).
+		Where("status", consts.OPERATOR_ACTIVE).
+		First(&operator).Error
+	return
+}
+
+func (r *repository) ReadOperatorByAccount(merchantId, accountId uint) (operator *entities.MerchantOperator, err error) {
+	err = datastore.DB.Where("merchant_id", merchantId).Where("account_id", accountId).First(&operator).Error
+	return
+}
+
+func (r *repository) ReadOperatorsByAccount(accountId uint) (operators *[]entities.MerchantOperator, err error) {
+	err = datastore.DB.Where("account_id", accountId).Where("status", consts.OPERATOR_ACTIVE).Find(&operators).Error
+	return
+}
+
+func (r *repository) UpdateOperator(operator *entities.MerchantOperator) (*entities.MerchantOperator, error) {
+	result := datastore.DB.Save(operator)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return operator, nil
+}
+
+// ReadDailyAmount sums the transactions the operator initiated for the merchant since the given time, failed ones don't count.
+func (r *repository) ReadDailyAmount(merchantId, accountId uint, since time.Time) (amount float32, err error) {
+	err = datastore.DB.Model(&entities.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("merchant_id", merchantId).
+		Where("operator_account_id", accountId).
+		Where("status <> ?", "FAILED").
+		Where("created_at >= ?", since).
+		Scan(&amount).Error
+	return
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
@@ -0,0 +1,239 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"slices"
+	"time"
+)
+
+type Service interface {
+	GetOperators(merchantId uint) (*[]entities.MerchantOperator, error)
+	GetOperatorMerchants(accountId uint) (*[]presenter.Merchant, error)
+	AddOperator(operator *entities.MerchantOperator, actingAccountId *uint) (*entities.MerchantOperator, error)
+	UpdateOperator(operator *entities.MerchantOperator, actingAccountId *uint) (*entities.MerchantOperator, error)
+	RemoveOperator(merchantId, id uint, actingAccountId *uint) error
+
+	Authorize(merchant *presenter.Merchant, accountId *uint, product string, amount float32) error
+}
+
+// permissions lists the products each role may transact, viewers may only read.
+var permissions = map[string][]string{
+	consts.OPERATOR_CASHIER: {
+		consts.MPESA_FLOAT,
+		consts.CASH_WITHDRAW,
+		consts.FLOAT_PURCHASE,
+		consts.FLOAT_TRANSFER,
+	},
+	consts.OPERATOR_VIEWER: {},
+}
+
+type service struct {
+	repository         Repository
+	merchantRepository merchant.Repository
+
+	accountsApi *clients.ApiClient
+	notifyApi   *clients.ApiClient
+}
+
+func (s *service) GetOperators(merchantId uint) (*[]entities.MerchantOperator, error) {
+	return s.repository.ReadOperators(merchantId)
+}
+
+// GetOperatorMerchants returns the merchants an account may act for, including the one it owns.
+func (s *service) GetOperatorMerchants(accountId uint) (*[]presenter.Merchant, error) {
+	var merchants []presenter.Merchant
+
+	owned, err := s.merchantRepository.ReadMerchantByAccount(accountId)
+	if err == nil {
+		merchants = append(merchants, *owned)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	operators, err := s.repository.ReadOperatorsByAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+	for _, operator := range *operators {
+		m, err := s.merchantRepository.ReadMerchant(operator.MerchantId)
+		if err != nil {
+			return nil, err
+		}
+		merchants = append(merchants, *m)
+	}
+
+	return &merchants, nil
+}
+
+// AddOperator links the account with the operator's phone to the merchant, creating the account if need be.
+// Only the merchant's owners may add operators.
+func (s *service) AddOperator(data *entities.MerchantOperator, actingAccountId *uint) (*entities.MerchantOperator, error) {
+	merchant, err := s.readManagedMerchant(data.MerchantId, actingAccountId)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountsApi.GetOrCreateAccount(data.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if uint(account.Id) == merchant.AccountId {
+		return nil, fmt.Errorf("%w: the merchant's account is already its owner", pkg.ErrInvalidAccount)
+	}
+
+	data.AccountId = uint(account.Id)
+	data.Phone = account.Phone
+	data.Status = consts.OPERATOR_ACTIVE
+
+	// A removed operator that is added again reuses their row
+	operator, err := s.repository.ReadOperatorByAccount(merchant.Id, data.AccountId)
+	switch {
+	case err == nil && operator.Status == consts.OPERATOR_ACTIVE:
+		return nil, fmt.Errorf("%w: account is already an operator", pkg.ErrInvalidAccount)
+	case err == nil:
+		data.Id = operator.Id
+		data.CreatedAt = operator.CreatedAt
+		operator, err = s.repository.UpdateOperator(data)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		operator, err = s.repository.CreateOperator(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go s.notifyApi.SendSMS("DEFAULT", operator.Phone,
+		fmt.Sprintf("You have been added as a %s for %s on Sidooh Merchants.", operator.Role, merchant.BusinessName))
+
+	return operator, nil
+}
+
+func (s *service) UpdateOperator(data *entities.MerchantOperator, actingAccountId *uint) (*entities.MerchantOperator, error) {
+	if _, err := s.readManagedMerchant(data.MerchantId, actingAccountId); err != nil {
+		return nil, err
+	}
+
+	operator, err := s.readOperator(data.MerchantId, data.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	operator.Name = data.Name
+	operator.Role = data.Role
+	operator.MaxAmount = data.MaxAmount
+	operator.DailyAmount = data.DailyAmount
+
+	return s.repository.UpdateOperator(operator)
+}
+
+func (s *service) RemoveOperator(merchantId, id uint, actingAccountId *uint) error {
+	if _, err := s.readManagedMerchant(merchantId, actingAccountId); err != nil {
+		return err
+	}
+
+	operator, err := s.readOperator(merchantId, id)
+	if err != nil {
+		return err
+	}
+
+	operator.Status = consts.OPERATOR_REMOVED
+	_, err = s.repository.UpdateOperator(operator)
+
+	return err
+}
+
+// Authorize checks that the account may initiate a transaction of the product for the merchant.
+// The merchant's own account acts as the owner, who may do anything. Without an acting account nothing is allowed.
+func (s *service) Authorize(merchant *presenter.Merchant, accountId *uint, product string, amount float32) error {
+	operator, err := s.readActingOperator(merchant, accountId)
+	if err != nil || operator.Role == consts.OPERATOR_OWNER {
+		return err
+	}
+	if !slices.Contains(permissions[operator.Role], product) {
+		return pkg.ErrOperatorNotPermitted
+	}
+
+	if operator.MaxAmount != nil && amount > *operator.MaxAmount {
+		return fmt.Errorf("%w: operator maximum amount is KES%v", pkg.ErrLimitExceeded, *operator.MaxAmount)
+	}
+	if operator.DailyAmount != nil {
+		now := time.Now()
+		used, err := s.repository.ReadDailyAmount(merchant.Id, operator.AccountId, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()))
+		if err != nil {
+			return err
+		}
+		if used+amount > *operator.DailyAmount {
+			return fmt.Errorf("%w: operator daily limit of KES%v", pkg.ErrLimitExceeded, *operator.DailyAmount)
+		}
+	}
+
+	return nil
+}
+
+// readManagedMerchant reads the merchant whose operators the account is managing, which only its owners may do.
+func (s *service) readManagedMerchant(merchantId uint, accountId *uint) (*presenter.Merchant, error) {
+	merchant, err := s.merchantRepository.ReadMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	operator, err := s.readActingOperator(merchant, accountId)
+	if err != nil {
+		return nil, err
+	}
+	if operator.Role != consts.OPERATOR_OWNER {
+		return nil, pkg.ErrOperatorNotPermitted
+	}
+
+	return merchant, nil
+}
+
+// readActingOperator reads the active operator the account acts for the merchant as, the merchant's own account being
+// its owner.
+func (s *service) readActingOperator(merchant *presenter.Merchant, accountId *uint) (*entities.MerchantOperator, error) {
+	if accountId == nil {
+		return nil, pkg.ErrOperatorNotPermitted
+	}
+	if *accountId == merchant.AccountId {
+		return &entities.MerchantOperator{MerchantId: merchant.Id, AccountId: merchant.AccountId, Role: consts.OPERATOR_OWNER}, nil
+	}
+
+	operator, err := s.repository.ReadActiveOperator(merchant.Id, *accountId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkg.ErrOperatorNotPermitted
+		}
+		return nil, err
+	}
+
+	return operator, nil
+}
+
+func (s *service) readOperator(merchantId, id uint) (*entities.MerchantOperator, error) {
+	operator, err := s.repository.ReadOperator(id)
+	if err != nil {
+		return nil, err
+	}
+	if operator.MerchantId != merchantId || operator.Status != consts.OPERATOR_ACTIVE {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return operator, nil
+}
+
+func NewService(r Repository, merchantRepo merchant.Repository) Service {
+	return &service{
+		repository:         r,
+		merchantRepository: merchantRepo,
+
+		accountsApi: clients.GetAccountClient(),
+		notifyApi:   clients.GetNotifyClient(),
+	}
+}
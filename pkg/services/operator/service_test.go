@@ -0,0 +1,125 @@
+package operator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	Repository
+
+	operators []entities.MerchantOperator
+	daily     float32
+}
+
+func (f *fakeRepository) ReadActiveOperator(merchantId, accountId uint) (*entities.MerchantOperator, error) {
+	for _, operator := range f.operators {
+		if operator.MerchantId == merchantId && operator.AccountId == accountId && operator.Status == consts.OPERATOR_ACTIVE {
+			return &operator, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepository) ReadDailyAmount(uint, uint, time.Time) (float32, error) {
+	return f.daily, nil
+}
+
+func (f *fakeRepository) ReadOperator(id uint) (*entities.MerchantOperator, error) {
+	for _, operator := range f.operators {
+		if operator.Id == id {
+			return &operator, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeRepository) UpdateOperator(data *entities.MerchantOperator) (*entities.MerchantOperator, error) {
+	for i := range f.operators {
+		if f.operators[i].Id == data.Id {
+			f.operators[i] = *data
+		}
+	}
+	return data, nil
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+}
+
+func (f *fakeMerchantRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	if id != 1 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &presenter.Merchant{Id: 1, AccountId: 10}, nil
+}
+
+func account(id uint) *uint {
+	return &id
+}
+
+func TestAuthorize(t *testing.T) {
+	max := float32(1000)
+	daily := float32(5000)
+	repository := &fakeRepository{operators: []entities.MerchantOperator{
+		{MerchantId: 1, AccountId: 20, Role: consts.OPERATOR_CASHIER, Status: consts.OPERATOR_ACTIVE, MaxAmount: &max, DailyAmount: &daily},
+		{MerchantId: 1, AccountId: 30, Role: consts.OPERATOR_VIEWER, Status: consts.OPERATOR_ACTIVE},
+		{MerchantId: 1, AccountId: 40, Role: consts.OPERATOR_OWNER, Status: consts.OPERATOR_ACTIVE},
+		{MerchantId: 1, AccountId: 50, Role: consts.OPERATOR_OWNER, Status: consts.OPERATOR_REMOVED},
+	}}
+	s := &service{repository: repository}
+	merchant := &presenter.Merchant{Id: 1, AccountId: 10}
+
+	// the merchant's own account acts as the owner, requests without an acting account are refused
+	assert.ErrorIs(t, s.Authorize(merchant, nil, consts.FLOAT_WITHDRAW, 100), pkg.ErrOperatorNotPermitted)
+	assert.Nil(t, s.Authorize(merchant, account(10), consts.FLOAT_WITHDRAW, 100))
+	assert.Nil(t, s.Authorize(merchant, account(40), consts.EARNINGS_WITHDRAW, 100))
+
+	assert.Nil(t, s.Authorize(merchant, account(20), consts.FLOAT_TRANSFER, 100))
+	assert.ErrorIs(t, s.Authorize(merchant, account(20), consts.FLOAT_WITHDRAW, 100), pkg.ErrOperatorNotPermitted)
+	assert.ErrorIs(t, s.Authorize(merchant, account(20), consts.FLOAT_TRANSFER, 1001), pkg.ErrLimitExceeded)
+
+	repository.daily = 4500
+	assert.ErrorIs(t, s.Authorize(merchant, account(20), consts.FLOAT_TRANSFER, 600), pkg.ErrLimitExceeded)
+
+	assert.ErrorIs(t, s.Authorize(merchant, account(30), consts.FLOAT_TRANSFER, 100), pkg.ErrOperatorNotPermitted)
+	assert.ErrorIs(t, s.Authorize(merchant, account(50), consts.FLOAT_TRANSFER, 100), pkg.ErrOperatorNotPermitted)
+	assert.ErrorIs(t, s.Authorize(merchant, account(60), consts.FLOAT_TRANSFER, 100), pkg.ErrOperatorNotPermitted)
+}
+
+func TestService_ManageOperators_OwnersOnly(t *testing.T) {
+	repository := &fakeRepository{operators: []entities.MerchantOperator{
+		{ModelID: entities.ModelID{Id: 1}, MerchantId: 1, AccountId: 20, Role: consts.OPERATOR_CASHIER, Status: consts.OPERATOR_ACTIVE},
+		{ModelID: entities.ModelID{Id: 2}, MerchantId: 1, AccountId: 40, Role: consts.OPERATOR_OWNER, Status: consts.OPERATOR_ACTIVE},
+		{ModelID: entities.ModelID{Id: 3}, MerchantId: 1, AccountId: 50, Role: consts.OPERATOR_CASHIER, Status: consts.OPERATOR_ACTIVE},
+	}}
+	s := &service{repository: repository, merchantRepository: &fakeMerchantRepository{}}
+
+	// Foreign accounts, the merchant's non-owner operators and callers without an account may not manage operators
+	for _, acting := range []*uint{nil, account(60), account(20)} {
+		_, err := s.AddOperator(&entities.MerchantOperator{MerchantId: 1, Phone: "254712345678", Role: consts.OPERATOR_OWNER}, acting)
+		assert.ErrorIs(t, err, pkg.ErrOperatorNotPermitted)
+		_, err = s.UpdateOperator(&entities.MerchantOperator{ModelID: entities.ModelID{Id: 1}, MerchantId: 1, Role: consts.OPERATOR_OWNER}, acting)
+		assert.ErrorIs(t, err, pkg.ErrOperatorNotPermitted)
+		assert.ErrorIs(t, s.RemoveOperator(1, 3, acting), pkg.ErrOperatorNotPermitted)
+	}
+	assert.Equal(t, consts.OPERATOR_CASHIER, repository.operators[0].Role)
+	assert.Equal(t, consts.OPERATOR_ACTIVE, repository.operators[2].Status)
+
+	// The merchant's account and its owner operators may
+	updated, err := s.UpdateOperator(&entities.MerchantOperator{ModelID: entities.ModelID{Id: 1}, MerchantId: 1, Role: consts.OPERATOR_VIEWER}, account(10))
+	assert.Nil(t, err)
+	assert.Equal(t, consts.OPERATOR_VIEWER, updated.Role)
+	assert.Nil(t, s.RemoveOperator(1, 3, account(40)))
+	assert.Equal(t, consts.OPERATOR_REMOVED, repository.operators[2].Status)
+
+	_, err = s.UpdateOperator(&entities.MerchantOperator{ModelID: entities.ModelID{Id: 1}, MerchantId: 2}, account(10))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
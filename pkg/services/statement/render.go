@@ -0,0 +1,150 @@
+package statement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/utils"
+	"strconv"
+	"time"
+)
+
+const dateTimeLayout = "02/01/2006 15:04"
+
+// WriteCSV writes the statement's lines preceded by the opening and followed by the closing balance of each account.
+func WriteCSV(w io.Writer, statement *presenter.Statement) error {
+	writer := csv.NewWriter(w)
+
+	_ = writer.Write([]string{"Date", "Reference", "Description", "Account", "Debit", "Credit", "Balance"})
+
+	from := statement.From.Format(time.DateOnly)
+	for _, account := range accounts(statement.OpeningBalances) {
+		_ = writer.Write([]string{from, "", "Opening Balance", account, "", "", amount(statement.OpeningBalances[account])})
+	}
+
+	for _, entry := range statement.Entries {
+		balance := ""
+		if entry.Balance != nil {
+			balance = amount(*entry.Balance)
+		}
+
+		_ = writer.Write([]string{
+			entry.Date.Format(dateTimeLayout),
+			entry.Reference,
+			entry.Description,
+			entry.Account,
+			optionalAmount(entry.Debit),
+			optionalAmount(entry.Credit),
+			balance,
+		})
+	}
+
+	to := statement.To.Format(time.DateOnly)
+	for _, account := range accounts(statement.ClosingBalances) {
+		_ = writer.Write([]string{to, "", "Closing Balance", account, "", "", amount(statement.ClosingBalances[account])})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WritePDF lays the statement out on A4 pages, repeating the table header on every page.
+func WritePDF(w io.Writer, statement *presenter.Statement) error {
+	const (
+		left       = 40.0
+		right      = utils.PDFPageWidth - 40
+		top        = 50.0
+		bottom     = utils.PDFPageHeight - 50
+		lineHeight = 14.0
+		fontSize   = 8.0
+	)
+
+	pdf := utils.NewPDF()
+	pdf.AddPage()
+	y := top
+
+	pdf.Text(left, y, 16, true, "Merchant Statement")
+	y += 22
+	pdf.Text(left, y, 10, false, fmt.Sprintf("%s (%s)", statement.BusinessName, statement.Code))
+	y += lineHeight
+	pdf.Text(left, y, 10, false, fmt.Sprintf("Period: %s to %s", statement.From.Format(time.DateOnly), statement.To.Format(time.DateOnly)))
+	y += lineHeight
+	pdf.Text(left, y, 10, false, "Generated: "+time.Now().Format(dateTimeLayout))
+	y += lineHeight * 1.5
+
+	summary := func(title string, balances map[string]float32) {
+		pdf.Text(left, y, 10, true, title)
+		y += lineHeight
+		for _, account := range accounts(balances) {
+			pdf.Text(left+10, y, 9, false, account)
+			pdf.TextRight(left+200, y, 9, false, amount(balances[account]))
+			y += lineHeight
+		}
+		y += lineHeight / 2
+	}
+	summary("Opening Balances", statement.OpeningBalances)
+
+	header := func() {
+		pdf.Text(left, y, fontSize, true, "Date")
+		pdf.Text(left+75, y, fontSize, true, "Reference")
+		pdf.Text(left+125, y, fontSize, true, "Description")
+		pdf.Text(left+300, y, fontSize, true, "Account")
+		pdf.TextRight(left+420, y, fontSize, true, "Debit")
+		pdf.TextRight(left+470, y, fontSize, true, "Credit")
+		pdf.TextRight(right, y, fontSize, true, "Balance")
+		pdf.Line(left, y+4, right, y+4)
+		y += lineHeight
+	}
+	header()
+
+	for _, entry := range statement.Entries {
+		if y > bottom {
+			pdf.AddPage()
+			y = top
+			header()
+		}
+
+		description := entry.Description
+		if len(description) > 40 {
+			description = description[:37] + "..."
+		}
+
+		pdf.Text(left, y, fontSize, false, entry.Date.Format(dateTimeLayout))
+		pdf.Text(left+75, y, fontSize, false, entry.Reference)
+		pdf.Text(left+125, y, fontSize, false, description)
+		pdf.Text(left+300, y, fontSize, false, entry.Account)
+		pdf.TextRight(left+420, y, fontSize, false, optionalAmount(entry.Debit))
+		pdf.TextRight(left+470, y, fontSize, false, optionalAmount(entry.Credit))
+		if entry.Balance != nil {
+			pdf.TextRight(right, y, fontSize, false, amount(*entry.Balance))
+		}
+		y += lineHeight
+	}
+
+	pdf.Line(left, y-lineHeight+4, right, y-lineHeight+4)
+	pdf.Text(left+125, y, fontSize, true, "Totals")
+	pdf.TextRight(left+420, y, fontSize, true, amount(statement.TotalDebit))
+	pdf.TextRight(left+470, y, fontSize, true, amount(statement.TotalCredit))
+	y += lineHeight * 2
+
+	if y+lineHeight*float64(len(statement.ClosingBalances)+2) > bottom {
+		pdf.AddPage()
+		y = top
+	}
+	summary("Closing Balances", statement.ClosingBalances)
+
+	return pdf.Output(w)
+}
+
+func amount(value float32) string {
+	return strconv.FormatFloat(float64(value), 'f', 2, 32)
+}
+
+func optionalAmount(value float32) string {
+	if value == 0 {
+		return ""
+	}
+
+	return amount(value)
+}
@@ -0,0 +1,137 @@
+package statement
+
+import (
+	"gorm.io/gorm"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/utils/consts"
+	"strconv"
+	"time"
+)
+
+// Repository interface allows us to access the CRUD Operations here.
+type Repository interface {
+	ReadTransactions(merchantId uint, from, until time.Time) ([]TransactionRow, error)
+	ReadEarningAccountTransactions(accountId uint, from, until time.Time) ([]EarningAccountRow, error)
+	ReadSavingsWithdrawals(merchantId uint, from, until time.Time) ([]SavingsRow, error)
+
+	ReadVoucherBalance(merchantId uint, before time.Time) (float32, error)
+	ReadEarningAccountBalances(accountId uint, before time.Time) ([]BalanceRow, error)
+}
+type repository struct {
+}
+
+type TransactionRow struct {
+	Id          uint
+	Amount      float32
+	Charge      float32
+	Product     string
+	Description string
+	Destination *string
+	MerchantId  uint
+	CreatedAt   time.Time
+}
+
+type EarningAccountRow struct {
+	Id        uint
+	Type      string
+	Amount    float32
+	Account   string
+	CreatedAt time.Time
+}
+
+type BalanceRow struct {
+	Account string
+	Balance float32
+}
+
+type SavingsRow struct {
+	Id          uint
+	Amount      float32
+	Description string
+	CreatedAt   time.Time
+}
+
+// ReadTransactions reads the merchant's completed transactions with their payment charges,
+// including voucher transfers other merchants made to them.
+func (r *repository) ReadTransactions(merchantId uint, from, until time.Time) (rows []TransactionRow, err error) {
+	err = datastore.DB.Table("transactions").
+		Select("transactions.id, transactions.amount, COALESCE(payments.charge, 0) AS charge, transactions.product, "+
+			"transactions.description, transactions.destination, transactions.merchant_id, transactions.created_at").
+		Scopes(merchantTransactions(merchantId)).
+		Where("transactions.created_at >= ? AND transactions.created_at < ?", from, until).
+		Order("transactions.created_at, transactions.id").
+		Scan(&rows).Error
+	return
+}
+
+func (r *repository) ReadEarningAccountTransactions(accountId uint, from, until time.Time) (rows []EarningAccountRow, err error) {
+	err = datastore.DB.Table("earning_account_transactions").
+		Select("earning_account_transactions.id, earning_account_transactions.type, earning_account_transactions.amount, "+
+			"earning_accounts.type AS account, earning_account_transactions.created_at").
+		Joins("JOIN earning_accounts ON earning_accounts.id = earning_account_transactions.earning_account_id").
+		Where("earning_accounts.account_id = ?", accountId).
+		Where("earning_account_transactions.created_at >= ? AND earning_account_transactions.created_at < ?", from, until).
+		Order("earning_account_transactions.created_at, earning_account_transactions.id").
+		Scan(&rows).Error
+	return
+}
+
+func (r *repository) ReadSavingsWithdrawals(merchantId uint, from, until time.Time) (rows []SavingsRow, err error) {
+	err = datastore.DB.Table("savings_transactions").
+		Select("savings_transactions.id, savings_transactions.amount, savings_transactions.description, savings_transactions.created_at").
+		Joins("JOIN transactions ON transactions.id = savings_transactions.transaction_id").
+		Where("transactions.merchant_id = ?", merchantId).
+		Where("savings_transactions.status = ?", "COMPLETED").
+		Where("savings_transactions.created_at >= ? AND savings_transactions.created_at < ?", from, until).
+		Order("savings_transactions.created_at, savings_transactions.id").
+		Scan(&rows).Error
+	return
+}
+
+// ReadVoucherBalance nets the merchant's voucher credits and debits before a date, classifying transactions as the
+// statement lines are.
+func (r *repository) ReadVoucherBalance(merchantId uint, before time.Time) (balance float32, err error) {
+	err = datastore.DB.Table("transactions").
+		Select(`COALESCE(SUM(CASE
+			WHEN transactions.merchant_id <> ? THEN transactions.amount
+			WHEN transactions.product IN ? THEN transactions.amount
+			WHEN transactions.product IN ? THEN -(transactions.amount + COALESCE(payments.charge, 0))
+			WHEN transactions.product IN ? AND transactions.destination LIKE 'FLOAT-%' THEN transactions.amount
+			ELSE 0 END), 0)`,
+			merchantId,
+			[]string{consts.CASH_WITHDRAW, consts.FLOAT_PURCHASE},
+			[]string{consts.MPESA_FLOAT, consts.FLOAT_TRANSFER, consts.FLOAT_WITHDRAW},
+			[]string{consts.EARNINGS_WITHDRAW, consts.SAVINGS_WITHDRAW}).
+		Scopes(merchantTransactions(merchantId)).
+		Where("transactions.created_at < ?", before).
+		Scan(&balance).Error
+	return
+}
+
+func (r *repository) ReadEarningAccountBalances(accountId uint, before time.Time) (rows []BalanceRow, err error) {
+	err = datastore.DB.Table("earning_account_transactions").
+		Select("earning_accounts.type AS account, SUM(CASE WHEN earning_account_transactions.type = 'CREDIT' "+
+			"THEN earning_account_transactions.amount ELSE -earning_account_transactions.amount END) AS balance").
+		Joins("JOIN earning_accounts ON earning_accounts.id = earning_account_transactions.earning_account_id").
+		Where("earning_accounts.account_id = ?", accountId).
+		Where("earning_account_transactions.created_at < ?", before).
+		Group("earning_accounts.type").
+		Scan(&rows).Error
+	return
+}
+
+// merchantTransactions scopes to the merchant's completed transactions and the voucher transfers made to them.
+func merchantTransactions(merchantId uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Joins("LEFT JOIN payments ON payments.transaction_id = transactions.id").
+			Where("transactions.status = ?", "COMPLETED").
+			Where("transactions.merchant_id = ? OR (transactions.product = ? AND transactions.destination = ?)",
+				merchantId, consts.FLOAT_TRANSFER, strconv.Itoa(int(merchantId)))
+	}
+}
+
+// NewRepo is the single instance repo that is being created.
+func NewRepo() Repository {
+	return &repository{}
+}
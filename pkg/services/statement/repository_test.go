@@ -0,0 +1,80 @@
+package statement
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/utils/consts"
+	"os"
+	"testing"
+)
+
+func TestRepository_OpeningBalances(t *testing.T) {
+	viper.Set("APP_ENV", "TEST")
+	viper.Set("MIGRATE_DB", true)
+	datastore.Init()
+	t.Cleanup(func() { os.Remove("test.db") })
+
+	toFloat, toMpesa, toMerchant, toOther := "FLOAT-5", "MPESA-254712345678", "1", "3"
+	stamp := func(day int) entities.ModelTimeStamps {
+		return entities.ModelTimeStamps{CreatedAt: date(day, 9), UpdatedAt: date(day, 9)}
+	}
+	completed := func(tx entities.Transaction) entities.Transaction {
+		tx.Status = "COMPLETED"
+		return tx
+	}
+
+	transactions := []entities.Transaction{
+		completed(entities.Transaction{Amount: 1000, Product: consts.FLOAT_PURCHASE, MerchantId: 1, ModelTimeStamps: stamp(1)}),
+		completed(entities.Transaction{Amount: 200, Product: consts.FLOAT_TRANSFER, Destination: &toOther, MerchantId: 1, ModelTimeStamps: stamp(2)}),
+		completed(entities.Transaction{Amount: 50, Product: consts.FLOAT_TRANSFER, Destination: &toMerchant, MerchantId: 2, ModelTimeStamps: stamp(3)}),
+		completed(entities.Transaction{Amount: 30, Product: consts.EARNINGS_WITHDRAW, Destination: &toFloat, MerchantId: 1, ModelTimeStamps: stamp(3)}),
+		completed(entities.Transaction{Amount: 20, Product: consts.SAVINGS_WITHDRAW, Destination: &toMpesa, MerchantId: 1, ModelTimeStamps: stamp(3)}),
+		completed(entities.Transaction{Amount: 70, Product: consts.FLOAT_TRANSFER, Destination: &toOther, MerchantId: 2, ModelTimeStamps: stamp(3)}),
+		{Amount: 500, Product: consts.FLOAT_PURCHASE, Status: "FAILED", MerchantId: 1, ModelTimeStamps: stamp(3)},
+		completed(entities.Transaction{Amount: 100, Product: consts.CASH_WITHDRAW, MerchantId: 1, ModelTimeStamps: stamp(6)}),
+	}
+	assert.Nil(t, datastore.DB.Create(&transactions).Error)
+	assert.Nil(t, datastore.DB.Create(&entities.Payment{Charge: 10, TransactionId: transactions[1].Id, PaymentId: 1}).Error)
+
+	cashback := entities.EarningAccount{Type: "CASHBACK", AccountId: 10}
+	commission := entities.EarningAccount{Type: "COMMISSION", AccountId: 10}
+	assert.Nil(t, datastore.DB.Create(&[]*entities.EarningAccount{&cashback, &commission}).Error)
+	assert.Nil(t, datastore.DB.Create(&[]entities.EarningAccountTransaction{
+		{Type: "CREDIT", Amount: 40, EarningAccountId: cashback.Id, ModelTimeStamps: stamp(2)},
+		{Type: "DEBIT", Amount: 30, EarningAccountId: cashback.Id, ModelTimeStamps: stamp(3)},
+		{Type: "CREDIT", Amount: 5, EarningAccountId: commission.Id, ModelTimeStamps: stamp(6)},
+	}).Error)
+
+	r := NewRepo()
+
+	// Top up, less the transfer and its charge, plus the transfer received and the earnings withdrawn to the voucher
+	balance, err := r.ReadVoucherBalance(1, date(5, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, float32(870), balance)
+
+	balance, err = r.ReadVoucherBalance(1, date(1, 0))
+	assert.NoError(t, err)
+	assert.Zero(t, balance)
+
+	balances, err := r.ReadEarningAccountBalances(10, date(5, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, []BalanceRow{{Account: "CASHBACK", Balance: 10}}, balances)
+
+	// Only the period's rows are read
+	rows, err := r.ReadTransactions(1, date(5, 0), date(7, 0))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, transactions[7].Id, rows[0].Id)
+
+	rows, err = r.ReadTransactions(1, date(2, 0), date(3, 0))
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, float32(10), rows[0].Charge)
+
+	earnings, err := r.ReadEarningAccountTransactions(10, date(5, 0), date(7, 0))
+	assert.NoError(t, err)
+	assert.Len(t, earnings, 1)
+	assert.Equal(t, "COMMISSION", earnings[0].Account)
+}
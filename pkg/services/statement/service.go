@@ -0,0 +1,209 @@
+package statement
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/spf13/viper"
+	"maps"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	GetStatement(merchantId uint, from, to time.Time) (*presenter.Statement, error)
+}
+
+type service struct {
+	repository         Repository
+	merchantRepository merchant.Repository
+}
+
+// GetStatement lists the merchant's debits and credits between the from and to dates, both inclusive.
+// Balances are derived from the local ledger, so the opening balance is the net of everything recorded before from.
+func (s *service) GetStatement(merchantId uint, from, to time.Time) (*presenter.Statement, error) {
+	until := to.AddDate(0, 0, 1)
+	if !from.Before(until) {
+		return nil, fmt.Errorf("%w: from is after to", pkg.ErrInvalidStatementPeriod)
+	}
+
+	maxDays := viper.GetInt("STATEMENT_MAX_DAYS")
+	if maxDays <= 0 {
+		maxDays = 366
+	}
+	if until.Sub(from) > time.Duration(maxDays)*24*time.Hour {
+		return nil, fmt.Errorf("%w: period is longer than %d days", pkg.ErrInvalidStatementPeriod, maxDays)
+	}
+
+	merchant, err := s.merchantRepository.ReadMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := s.readOpeningBalances(merchant.Id, merchant.AccountId, from)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.readEntries(merchant.Id, merchant.AccountId, from, until)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &presenter.Statement{
+		MerchantId:      merchant.Id,
+		BusinessName:    merchant.BusinessName,
+		Code:            merchant.Code,
+		From:            from,
+		To:              to,
+		OpeningBalances: opening,
+		Entries:         []presenter.StatementEntry{},
+	}
+
+	balances := maps.Clone(opening)
+	for _, e := range entries {
+		tracked := e.Account != consts.STATEMENT_SAVINGS
+		if tracked {
+			balances[e.Account] += e.Credit - e.Debit
+			balance := balances[e.Account]
+			e.Balance = &balance
+			if _, ok := statement.OpeningBalances[e.Account]; !ok {
+				statement.OpeningBalances[e.Account] = 0
+			}
+		}
+
+		statement.TotalDebit += e.Debit
+		statement.TotalCredit += e.Credit
+		statement.Entries = append(statement.Entries, e)
+	}
+
+	statement.ClosingBalances = map[string]float32{}
+	for account := range statement.OpeningBalances {
+		statement.ClosingBalances[account] = balances[account]
+	}
+
+	return statement, nil
+}
+
+// readOpeningBalances sums the voucher and earning accounts up to from, the voucher is always listed.
+func (s *service) readOpeningBalances(merchantId, accountId uint, from time.Time) (map[string]float32, error) {
+	voucher, err := s.repository.ReadVoucherBalance(merchantId, from)
+	if err != nil {
+		return nil, err
+	}
+
+	earnings, err := s.repository.ReadEarningAccountBalances(accountId, from)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := map[string]float32{consts.STATEMENT_VOUCHER: voucher}
+	for _, row := range earnings {
+		balances[row.Account] = row.Balance
+	}
+
+	return balances, nil
+}
+
+// readEntries reads the period's statement lines in chronological order, without balances.
+func (s *service) readEntries(merchantId, accountId uint, from, until time.Time) ([]presenter.StatementEntry, error) {
+	transactions, err := s.repository.ReadTransactions(merchantId, from, until)
+	if err != nil {
+		return nil, err
+	}
+
+	earnings, err := s.repository.ReadEarningAccountTransactions(accountId, from, until)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals, err := s.repository.ReadSavingsWithdrawals(merchantId, from, until)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []presenter.StatementEntry
+	add := func(date time.Time, reference, description, account string, amount float32, credit bool) {
+		line := presenter.StatementEntry{Date: date, Reference: reference, Description: description, Account: account}
+		if credit {
+			line.Credit = amount
+		} else {
+			line.Debit = amount
+		}
+
+		entries = append(entries, line)
+	}
+
+	for _, tx := range transactions {
+		reference := "TX" + strconv.Itoa(int(tx.Id))
+
+		// A transfer made by another merchant to this one
+		if tx.MerchantId != merchantId {
+			add(tx.CreatedAt, reference, "Voucher Transfer Received", consts.STATEMENT_VOUCHER, tx.Amount, true)
+			continue
+		}
+
+		switch tx.Product {
+		case consts.CASH_WITHDRAW, consts.FLOAT_PURCHASE:
+			add(tx.CreatedAt, reference, tx.Description, consts.STATEMENT_VOUCHER, tx.Amount, true)
+		case consts.MPESA_FLOAT, consts.FLOAT_TRANSFER, consts.FLOAT_WITHDRAW:
+			add(tx.CreatedAt, reference, tx.Description, consts.STATEMENT_VOUCHER, tx.Amount, false)
+			if tx.Charge > 0 {
+				add(tx.CreatedAt, reference, "Charge - "+tx.Description, consts.STATEMENT_VOUCHER, tx.Charge, false)
+			}
+		case consts.EARNINGS_WITHDRAW, consts.SAVINGS_WITHDRAW:
+			// The debit shows on the earning or savings account, only withdrawals to the voucher credit it
+			if tx.Destination != nil && strings.HasPrefix(*tx.Destination, "FLOAT-") {
+				add(tx.CreatedAt, reference, tx.Description, consts.STATEMENT_VOUCHER, tx.Amount, true)
+			}
+		}
+	}
+
+	for _, tx := range earnings {
+		add(tx.CreatedAt, "EA"+strconv.Itoa(int(tx.Id)), "Earnings "+strings.ToLower(tx.Type)+" - "+tx.Account, tx.Account, tx.Amount, tx.Type == "CREDIT")
+	}
+
+	for _, tx := range withdrawals {
+		description := tx.Description
+		if description == "" {
+			description = "Savings Withdrawal"
+		}
+
+		add(tx.CreatedAt, "SV"+strconv.Itoa(int(tx.Id)), description, consts.STATEMENT_SAVINGS, tx.Amount, false)
+	}
+
+	slices.SortStableFunc(entries, func(a, b presenter.StatementEntry) int {
+		return a.Date.Compare(b.Date)
+	})
+
+	return entries, nil
+}
+
+// accounts lists the accounts of a statement's balances, the voucher first.
+func accounts(balances map[string]float32) []string {
+	var keys []string
+	for account := range balances {
+		keys = append(keys, account)
+	}
+
+	slices.SortFunc(keys, func(a, b string) int {
+		switch {
+		case a == consts.STATEMENT_VOUCHER:
+			return -1
+		case b == consts.STATEMENT_VOUCHER:
+			return 1
+		}
+		return cmp.Compare(a, b)
+	})
+
+	return keys
+}
+
+func NewService(r Repository, merchantRepo merchant.Repository) Service {
+	return &service{repository: r, merchantRepository: merchantRepo}
+}
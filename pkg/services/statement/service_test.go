@@ -0,0 +1,163 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/stretchr/testify/assert"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/utils/consts"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRepository struct {
+	transactions []TransactionRow
+	earnings     []EarningAccountRow
+	savings      []SavingsRow
+
+	// opening balances by the date they are read before
+	vouchers map[time.Time]float32
+	earned   map[time.Time][]BalanceRow
+}
+
+func within(date, from, until time.Time) bool {
+	return !date.Before(from) && date.Before(until)
+}
+
+func (f *fakeRepository) ReadTransactions(_ uint, from, until time.Time) (rows []TransactionRow, err error) {
+	for _, row := range f.transactions {
+		if within(row.CreatedAt, from, until) {
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+func (f *fakeRepository) ReadEarningAccountTransactions(_ uint, from, until time.Time) (rows []EarningAccountRow, err error) {
+	for _, row := range f.earnings {
+		if within(row.CreatedAt, from, until) {
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+func (f *fakeRepository) ReadSavingsWithdrawals(_ uint, from, until time.Time) (rows []SavingsRow, err error) {
+	for _, row := range f.savings {
+		if within(row.CreatedAt, from, until) {
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+func (f *fakeRepository) ReadVoucherBalance(_ uint, before time.Time) (float32, error) {
+	return f.vouchers[before], nil
+}
+
+func (f *fakeRepository) ReadEarningAccountBalances(_ uint, before time.Time) ([]BalanceRow, error) {
+	return f.earned[before], nil
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+}
+
+func (f *fakeMerchantRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	return &presenter.Merchant{Id: id, AccountId: 10, BusinessName: "Mama Mboga", Code: "123455"}, nil
+}
+
+func date(day int, hour int) time.Time {
+	return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC)
+}
+
+func newTestService() Service {
+	toFloat := "FLOAT-5"
+	toMpesa := "MPESA-254712345678"
+	merchantId := "1"
+
+	return NewService(&fakeRepository{
+		transactions: []TransactionRow{
+			{Id: 1, Amount: 1000, Product: consts.FLOAT_PURCHASE, Description: "Voucher Top Up", MerchantId: 1, CreatedAt: date(1, 9)},
+			{Id: 2, Amount: 200, Charge: 10, Product: consts.FLOAT_TRANSFER, Description: "Voucher Transfer", MerchantId: 1, CreatedAt: date(5, 9)},
+			{Id: 3, Amount: 50, Product: consts.FLOAT_TRANSFER, Description: "Voucher Transfer", Destination: &merchantId, MerchantId: 2, CreatedAt: date(6, 9)},
+			{Id: 4, Amount: 30, Product: consts.EARNINGS_WITHDRAW, Description: "Earnings Withdrawal - CASHBACK", Destination: &toFloat, MerchantId: 1, CreatedAt: date(7, 9)},
+			{Id: 5, Amount: 20, Product: consts.SAVINGS_WITHDRAW, Description: "Savings Withdrawal - CASHBACK", Destination: &toMpesa, MerchantId: 1, CreatedAt: date(8, 9)},
+		},
+		earnings: []EarningAccountRow{
+			{Id: 1, Type: "CREDIT", Amount: 40, Account: "CASHBACK", CreatedAt: date(2, 9)},
+			{Id: 2, Type: "DEBIT", Amount: 30, Account: "CASHBACK", CreatedAt: date(7, 9)},
+		},
+		savings: []SavingsRow{
+			{Id: 1, Amount: 20, CreatedAt: date(8, 9)},
+		},
+		vouchers: map[time.Time]float32{date(5, 0): 1000, date(8, 0): 870},
+		earned: map[time.Time][]BalanceRow{
+			date(5, 0): {{Account: "CASHBACK", Balance: 40}},
+			date(8, 0): {{Account: "CASHBACK", Balance: 10}},
+		},
+	}, &fakeMerchantRepository{})
+}
+
+func TestService_GetStatement(t *testing.T) {
+	statement, err := newTestService().GetStatement(1, date(5, 0), date(7, 0))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]float32{consts.STATEMENT_VOUCHER: 1000, "CASHBACK": 40}, statement.OpeningBalances)
+	assert.Equal(t, map[string]float32{consts.STATEMENT_VOUCHER: 870, "CASHBACK": 10}, statement.ClosingBalances)
+
+	var references []string
+	for _, entry := range statement.Entries {
+		references = append(references, entry.Reference)
+	}
+	// The transfer with its charge, the received transfer, then the earnings withdrawal to the voucher
+	assert.Equal(t, []string{"TX2", "TX2", "TX3", "TX4", "EA2"}, references)
+
+	assert.Equal(t, float32(240), statement.TotalDebit)
+	assert.Equal(t, float32(80), statement.TotalCredit)
+	assert.Equal(t, float32(790), *statement.Entries[1].Balance)
+}
+
+func TestService_GetStatement_SavingsHaveNoBalance(t *testing.T) {
+	statement, err := newTestService().GetStatement(1, date(8, 0), date(8, 0))
+	assert.NoError(t, err)
+
+	assert.Len(t, statement.Entries, 1)
+	assert.Equal(t, consts.STATEMENT_SAVINGS, statement.Entries[0].Account)
+	assert.Nil(t, statement.Entries[0].Balance)
+	assert.NotContains(t, statement.ClosingBalances, consts.STATEMENT_SAVINGS)
+}
+
+func TestService_GetStatement_InvalidPeriod(t *testing.T) {
+	_, err := newTestService().GetStatement(1, date(7, 0), date(5, 0))
+	assert.ErrorIs(t, err, pkg.ErrInvalidStatementPeriod)
+
+	_, err = newTestService().GetStatement(1, date(1, 0).AddDate(-2, 0, 0), date(1, 0))
+	assert.ErrorIs(t, err, pkg.ErrInvalidStatementPeriod)
+}
+
+func TestWriteCSV(t *testing.T) {
+	statement, _ := newTestService().GetStatement(1, date(5, 0), date(7, 0))
+
+	var out bytes.Buffer
+	assert.NoError(t, WriteCSV(&out, statement))
+
+	records, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	// Header, two opening balances, five entries and two closing balances
+	assert.Len(t, records, 10)
+	assert.Equal(t, []string{"2024-03-05", "", "Opening Balance", consts.STATEMENT_VOUCHER, "", "", "1000.00"}, records[1])
+	assert.Equal(t, []string{"2024-03-07", "", "Closing Balance", consts.STATEMENT_VOUCHER, "", "", "870.00"}, records[8])
+}
+
+func TestWritePDF(t *testing.T) {
+	statement, _ := newTestService().GetStatement(1, date(1, 0), date(31, 0))
+
+	var out bytes.Buffer
+	assert.NoError(t, WritePDF(&out, statement))
+	assert.True(t, strings.HasPrefix(out.String(), "%PDF-"))
+	assert.Contains(t, out.String(), "(Closing Balances) Tj")
+}
@@ -1,6 +1,8 @@
 package transaction
 
 import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"merchants.sidooh/api/presenter"
 	"merchants.sidooh/pkg/datastore"
 	"merchants.sidooh/pkg/entities"
@@ -10,10 +12,15 @@ import (
 // Repository interface allows us to access the CRUD Operations here.
 type Repository interface {
 	CreateTransaction(transaction *entities.Transaction) (*entities.Transaction, error)
+	CreateCheckedTransaction(transaction *entities.Transaction, check func() error) (*entities.Transaction, error)
 	ReadTransactions(filters Filters) (*[]presenter.Transaction, error)
 	ReadTransaction(id uint) (*entities.Transaction, error)
 	ReadTransactionsByMerchant(merchantId uint) (*[]presenter.Transaction, error)
 	UpdateTransaction(transaction *entities.Transaction) (*entities.Transaction, error)
+
+	CreatePreview(preview *entities.FloatTransferPreview) (*entities.FloatTransferPreview, error)
+	ConsumePreview(token string, merchantId uint, amount float32) (*entities.FloatTransferPreview, error)
+	UpdatePreviewColumn(id uint, column string, value interface{}) error
 }
 type repository struct {
 }
@@ -35,6 +42,29 @@ func (r *repository) CreateTransaction(transaction *entities.Transaction) (*enti
 	return transaction, nil
 }
 
+// CreateCheckedTransaction records the transaction if the check passes, running both in one db transaction with the
+// merchant locked, so concurrent transactions of a merchant are checked one at a time, each seeing those recorded
+// before it.
+func (r *repository) CreateCheckedTransaction(transaction *entities.Transaction, check func() error) (*entities.Transaction, error) {
+	err := datastore.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&entities.Merchant{}, transaction.MerchantId).Error
+		if err != nil {
+			return err
+		}
+
+		if err = check(); err != nil {
+			return err
+		}
+
+		return tx.Create(&transaction).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
 func (r *repository) ReadTransactions(filters Filters) (transactions *[]presenter.Transaction, err error) {
 	query := datastore.DB.Order("id desc")
 	if len(filters.Columns) > 0 {
@@ -73,6 +103,37 @@ func (r *repository) UpdateTransaction(transaction *entities.Transaction) (*enti
 	return r.ReadTransaction(transaction.Id)
 }
 
+func (r *repository) CreatePreview(preview *entities.FloatTransferPreview) (*entities.FloatTransferPreview, error) {
+	result := datastore.DB.Create(&preview)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return preview, nil
+}
+
+// ConsumePreview marks an unexpired preview as used, the conditional update ensures a token is only ever used once.
+func (r *repository) ConsumePreview(token string, merchantId uint, amount float32) (preview *entities.FloatTransferPreview, err error) {
+	now := time.Now()
+
+	result := datastore.DB.Model(&entities.FloatTransferPreview{}).
+		Where("token = ? AND merchant_id = ? AND amount = ? AND used_at IS NULL AND expires_at > ?", token, merchantId, amount, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	err = datastore.DB.Where("token", token).First(&preview).Error
+	return
+}
+
+func (r *repository) UpdatePreviewColumn(id uint, column string, value interface{}) error {
+	return datastore.DB.Model(&entities.FloatTransferPreview{}).Where("id", id).Update(column, value).Error
+}
+
 // NewRepo is the single instance repo that is being created.
 func NewRepo() Repository {
 	return &repository{}
@@ -5,15 +5,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
 	"merchants.sidooh/api/presenter"
 	"merchants.sidooh/pkg"
 	"merchants.sidooh/pkg/clients"
 	"merchants.sidooh/pkg/entities"
 	"merchants.sidooh/pkg/logger"
+	"merchants.sidooh/pkg/services/commission"
 	"merchants.sidooh/pkg/services/earning"
 	"merchants.sidooh/pkg/services/earning_account"
+	"merchants.sidooh/pkg/services/limit"
 	"merchants.sidooh/pkg/services/merchant"
 	"merchants.sidooh/pkg/services/mpesa_store"
+	"merchants.sidooh/pkg/services/operator"
 	"merchants.sidooh/pkg/services/payment"
 	"merchants.sidooh/pkg/services/savings"
 	"merchants.sidooh/utils"
@@ -21,6 +26,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Service interface {
@@ -32,27 +38,34 @@ type Service interface {
 	PurchaseMpesaFloat(transaction *entities.Transaction, agent, store, source, sourceAccount string) (*entities.Transaction, error)
 	MpesaWithdrawal(transaction *entities.Transaction) (*entities.Transaction, error)
 	FloatPurchase(transaction *entities.Transaction) (*entities.Transaction, error)
-	FloatTransfer(transaction *entities.Transaction) (*entities.Transaction, error)
+	PreviewFloatTransfer(transaction *entities.Transaction, recipient, recipientType string) (*presenter.FloatTransferPreview, error)
+	FloatTransfer(transaction *entities.Transaction, previewToken string) (*entities.Transaction, error)
 	FloatWithdraw(transaction *entities.Transaction, destination, account string) (*entities.Transaction, error)
 	WithdrawEarnings(transaction *entities.Transaction, source, destination, account string) (*entities.Transaction, error)
 
 	WithdrawSavings(transaction *entities.Transaction, source, destination, account string) (*entities.Transaction, error)
 
 	CompleteTransaction(payment *entities.Payment, ipn *utils.Payment) error
+	ProcessCommissions() (int, error)
 }
 
 type service struct {
 	repository Repository
 
 	merchantRepository   merchant.Repository
+	merchantService      merchant.Service
 	paymentRepository    payment.Repository
 	savingsRepository    savings.Repository
 	earningAccRepository earning_account.Repository
 	earningRepository    earning.Repository
-	mpesaStoreRepository mpesa_store.Repository
+	commissionRepository commission.Repository
+	mpesaStoreService    mpesa_store.Service
 
 	earningAccService earning_account.Service
 	earningService    earning.Service
+	commissionService commission.Service
+	limitService      limit.Service
+	operatorService   operator.Service
 
 	accountsApi *clients.ApiClient
 	paymentsApi *clients.ApiClient
@@ -94,6 +107,8 @@ func (s *service) GetTransaction(id uint) (results *presenter.Transaction, err e
 		Product:     tx.Product,
 		CreatedAt:   tx.CreatedAt,
 		UpdatedAt:   tx.UpdatedAt,
+
+		OperatorAccountId: tx.OperatorAccountId,
 	}
 
 	if tx.Payment != nil {
@@ -120,12 +135,12 @@ func (s *service) UpdateTransaction(transaction *entities.Transaction) (*entitie
 }
 
 func (s *service) PurchaseMpesaFloat(data *entities.Transaction, agent, store, source, sourceAccount string) (tx *entities.Transaction, err error) {
-	merchant, err := s.merchantRepository.ReadMerchant(data.MerchantId)
+	merchant, err := s.authorize(data)
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err = s.repository.CreateTransaction(data)
+	tx, err = s.createTransaction(merchant, data)
 	if err != nil {
 		return nil, err
 	}
@@ -171,12 +186,12 @@ func (s *service) PurchaseMpesaFloat(data *entities.Transaction, agent, store, s
 }
 
 func (s *service) MpesaWithdrawal(data *entities.Transaction) (tx *entities.Transaction, err error) {
-	merchant, err := s.merchantRepository.ReadMerchant(data.MerchantId)
+	merchant, err := s.authorize(data)
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err = s.repository.CreateTransaction(data)
+	tx, err = s.createTransaction(merchant, data)
 	if err != nil {
 		return nil, err
 	}
@@ -218,12 +233,12 @@ func (s *service) MpesaWithdrawal(data *entities.Transaction) (tx *entities.Tran
 }
 
 func (s *service) FloatPurchase(data *entities.Transaction) (tx *entities.Transaction, err error) {
-	merchant, err := s.merchantRepository.ReadMerchant(data.MerchantId)
+	merchant, err := s.authorize(data)
 	if err != nil {
 		return nil, err
 	}
 
-	tx, err = s.repository.CreateTransaction(data)
+	tx, err = s.createTransaction(merchant, data)
 	if err != nil {
 		return nil, err
 	}
@@ -264,14 +279,80 @@ func (s *service) FloatPurchase(data *entities.Transaction) (tx *entities.Transa
 	return
 }
 
-func (s *service) FloatTransfer(data *entities.Transaction) (transaction *entities.Transaction, err error) {
-	merchant, err := s.merchantRepository.ReadMerchant(data.MerchantId)
+// PreviewFloatTransfer resolves the recipient and the cost of a transfer, the returned token authorizes the transfer itself.
+func (s *service) PreviewFloatTransfer(data *entities.Transaction, recipientValue, recipientType string) (*presenter.FloatTransferPreview, error) {
+	merchant, err := s.authorize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := s.resolveRecipient(recipientValue, recipientType)
+	if err != nil {
+		return nil, err
+	}
+	if recipient.FloatAccountId == merchant.FloatAccountId {
+		return nil, pkg.ErrInvalidMerchant
+	}
+
+	// The confirmed preview keeps the charge, so it can't be shown as free when the charges are unavailable
+	charge, err := s.getFloatTransferCharge(int(data.Amount))
+	if err != nil {
+		return nil, err
+	}
+
+	float, err := s.paymentsApi.FetchFloatAccount(strconv.Itoa(int(merchant.FloatAccountId)))
 	if err != nil {
 		return nil, err
 	}
 
-	recipientAccount, _ := strconv.Atoi(*data.Destination)
-	recipient, err := s.merchantRepository.ReadMerchant(uint(recipientAccount))
+	balance := float32(float.Balance) - data.Amount - float32(charge)
+	if balance < 0 {
+		return nil, pkg.ErrInsufficientBalance
+	}
+
+	ttl := viper.GetDuration("FLOAT_TRANSFER_PREVIEW_TTL")
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+
+	preview, err := s.repository.CreatePreview(&entities.FloatTransferPreview{
+		Token:       utils.RandomString(32),
+		Amount:      data.Amount,
+		Charge:      float32(charge),
+		RecipientId: recipient.Id,
+		MerchantId:  merchant.Id,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &presenter.FloatTransferPreview{
+		Token:        preview.Token,
+		RecipientId:  recipient.Id,
+		BusinessName: utils.MaskName(recipient.BusinessName),
+		Amount:       preview.Amount,
+		Charge:       preview.Charge,
+		Balance:      balance,
+		ExpiresAt:    preview.ExpiresAt,
+	}, nil
+}
+
+func (s *service) FloatTransfer(data *entities.Transaction, previewToken string) (transaction *entities.Transaction, err error) {
+	merchant, err := s.authorize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := s.repository.ConsumePreview(previewToken, merchant.Id, data.Amount)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkg.ErrInvalidPreviewToken
+		}
+		return nil, err
+	}
+
+	recipient, err := s.readActiveMerchant(preview.RecipientId)
 	if err != nil {
 		return nil, err
 	}
@@ -280,11 +361,18 @@ func (s *service) FloatTransfer(data *entities.Transaction) (transaction *entiti
 		return nil, pkg.ErrInvalidMerchant
 	}
 
-	transaction, err = s.repository.CreateTransaction(data)
+	destination := strconv.Itoa(int(recipient.Id))
+	data.Destination = &destination
+
+	transaction, err = s.createTransaction(merchant, data)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = s.repository.UpdatePreviewColumn(preview.Id, "transaction_id", transaction.Id); err != nil {
+		logger.ClientLog.Error("failed to link transfer preview", "preview", preview.Id, "tx", transaction.Id, "err", err)
+	}
+
 	paymentData, err := s.paymentsApi.FloatTransfer(merchant.AccountId, merchant.FloatAccountId, int(transaction.Amount), strconv.Itoa(int(recipient.FloatAccountId)))
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -356,12 +444,12 @@ func (s *service) FloatTransfer(data *entities.Transaction) (transaction *entiti
 }
 
 func (s *service) FloatWithdraw(data *entities.Transaction, destination, account string) (transaction *entities.Transaction, err error) {
-	merchant, err := s.merchantRepository.ReadMerchant(data.MerchantId)
+	merchant, err := s.authorize(data)
 	if err != nil {
 		return nil, err
 	}
 
-	transaction, err = s.repository.CreateTransaction(data)
+	transaction, err = s.createTransaction(merchant, data)
 	if err != nil {
 		return nil, err
 	}
@@ -403,7 +491,7 @@ func (s *service) FloatWithdraw(data *entities.Transaction, destination, account
 }
 
 func (s *service) WithdrawEarnings(data *entities.Transaction, source, destination, account string) (tx *entities.Transaction, err error) {
-	merchant, err := s.merchantRepository.ReadMerchant(data.MerchantId)
+	merchant, err := s.authorize(data)
 	if err != nil {
 		return nil, err
 	}
@@ -417,7 +505,12 @@ func (s *service) WithdrawEarnings(data *entities.Transaction, source, destinati
 		charge = 0.0
 	}
 
-	var earningTXs []entities.EarningAccountTransaction
+	// The debits are planned first so nothing is recorded when the balance is not enough
+	type debit struct {
+		earningAccountId uint
+		amount           float32
+	}
+	var debits []debit
 
 	if source != "" {
 		earningAccount, err := s.earningAccRepository.ReadAccountByAccountIdAndType(merchant.AccountId, source)
@@ -429,12 +522,7 @@ func (s *service) WithdrawEarnings(data *entities.Transaction, source, destinati
 			return nil, pkg.ErrInsufficientBalance
 		}
 
-		_, tx, err := s.earningAccService.DebitAccount(earningAccount.Id, data.Amount+charge)
-		if err != nil {
-			return nil, err
-		}
-
-		earningTXs = append(earningTXs, *tx)
+		debits = append(debits, debit{earningAccount.Id, data.Amount + charge})
 
 	} else {
 		earningAccounts, err := s.earningAccRepository.ReadAccountsByMerchant(data.MerchantId)
@@ -469,11 +557,7 @@ func (s *service) WithdrawEarnings(data *entities.Transaction, source, destinati
 				toDebit = earningAccount.Amount
 			}
 
-			_, tx, err := s.earningAccService.DebitAccount(earningAccount.Id, toDebit)
-			if err != nil {
-				return nil, err
-			}
-			earningTXs = append(earningTXs, *tx)
+			debits = append(debits, debit{earningAccount.Id, toDebit})
 
 			if totalWithdrawal == 0 {
 				break
@@ -481,20 +565,35 @@ func (s *service) WithdrawEarnings(data *entities.Transaction, source, destinati
 		}
 	}
 
-	tx, err = s.repository.CreateTransaction(data)
+	tx, err = s.createTransaction(merchant, data)
 	if err != nil {
 		return nil, err
 	}
 
-	paymentData, err := s.paymentsApi.Withdraw(merchant.AccountId, 1, int(tx.Amount), destination, account)
-	if err != nil {
+	var earningTXs []entities.EarningAccountTransaction
 
-		// TODO: reverse earningTXs
-		for _, earningTx := range earningTXs {
-			_, err := s.earningAccService.CreditAccount(earningTx.EarningAccountId, earningTx.Amount)
-			if err != nil {
+	for _, d := range debits {
+		_, earningTx, err := s.earningAccService.DebitAccount(d.earningAccountId, d.amount, earning_account.Entry{
+			Description:   tx.Description,
+			TransactionId: &tx.Id,
+		})
+		if err != nil {
+			if err := s.reverseEarnings(tx, earningTXs); err != nil {
 				return nil, err
 			}
+
+			tx.Status = "FAILED"
+			_, _ = s.repository.UpdateTransaction(tx)
+
+			return nil, err
+		}
+		earningTXs = append(earningTXs, *earningTx)
+	}
+
+	paymentData, err := s.paymentsApi.Withdraw(merchant.AccountId, 1, int(tx.Amount), destination, account)
+	if err != nil {
+		if err := s.reverseEarnings(tx, earningTXs); err != nil {
+			return nil, err
 		}
 
 		tx.Status = "FAILED"
@@ -525,8 +624,23 @@ func (s *service) WithdrawEarnings(data *entities.Transaction, source, destinati
 	return
 }
 
+// reverseEarnings credits back the earning account debits of a withdrawal that failed.
+func (s *service) reverseEarnings(tx *entities.Transaction, earningTXs []entities.EarningAccountTransaction) error {
+	for _, earningTx := range earningTXs {
+		_, err := s.earningAccService.CreditAccount(earningTx.EarningAccountId, earningTx.Amount, earning_account.Entry{
+			Description:   "Reversal - " + tx.Description,
+			TransactionId: &tx.Id,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *service) WithdrawSavings(data *entities.Transaction, source, destination, account string) (tx *entities.Transaction, err error) {
-	merchant, err := s.merchantRepository.ReadMerchant(data.MerchantId)
+	merchant, err := s.authorize(data)
 	if err != nil {
 		return nil, err
 	}
@@ -557,7 +671,7 @@ func (s *service) WithdrawSavings(data *entities.Transaction, source, destinatio
 		return nil, pkg.ErrInsufficientBalance
 	}
 
-	tx, err = s.repository.CreateTransaction(data)
+	tx, err = s.createTransaction(merchant, data)
 	if err != nil {
 		return nil, err
 	}
@@ -691,9 +805,10 @@ func (s *service) CompleteTransaction(payment *entities.Payment, ipn *utils.Paym
 			return nil
 		}
 
+		// Earnings are not allowed to hold back completing the transaction
 		err := s.computeMpesaWithdrawalCashback(merchant, transaction, payment, ipn)
 		if err != nil {
-			return err
+			logger.ClientLog.Error("Error computing cashback", "tx", transaction.Id, "error", err)
 		}
 
 	case consts.MPESA_FLOAT:
@@ -726,9 +841,10 @@ func (s *service) CompleteTransaction(payment *entities.Payment, ipn *utils.Paym
 			return nil
 		}
 
+		// Earnings are not allowed to hold back completing the transaction
 		err := s.computeCashback(merchant, transaction, payment, ipn)
 		if err != nil {
-			return err
+			logger.ClientLog.Error("Error computing cashback", "tx", transaction.Id, "error", err)
 		}
 
 	case consts.EARNINGS_WITHDRAW:
@@ -769,30 +885,6 @@ func (s *service) computeCashback(merchant *presenter.Merchant, tx *entities.Tra
 		cashback = 0
 	}
 
-	if cashback > 0 {
-
-		s.earningRepository.CreateEarning(&entities.Earning{
-			Amount:        cashback,
-			Type:          "SELF",
-			TransactionId: tx.Id,
-			AccountId:     merchant.AccountId,
-		})
-
-		earningAcc, err := s.earningAccRepository.ReadAccountByAccountIdAndType(merchant.AccountId, "CASHBACK")
-		if err != nil {
-			earningAcc, err = s.earningAccRepository.CreateAccount(&entities.EarningAccount{
-				Type:      "CASHBACK",
-				AccountId: merchant.AccountId,
-			})
-			if err != nil {
-				return err
-			}
-		}
-		s.earningAccService.CreditAccount(earningAcc.Id, cashback)
-		s.earningAccService.DebitAccount(earningAcc.Id, cashback*.8) // Debit acc for savings
-
-	}
-
 	// Compute commissions
 	//TODO Fix this for float purchase using mpesa
 	commission := float32(30) * .1
@@ -800,43 +892,16 @@ func (s *service) computeCashback(merchant *presenter.Merchant, tx *entities.Tra
 		commission = 0
 	}
 
-	if commission > 0 {
-		inviters, err := s.accountsApi.GetInviters(strconv.Itoa(int(merchant.AccountId)))
-		if err != nil {
-			return err
-		}
-
-		if len(inviters) > 1 {
-			for _, inviter := range inviters[1:] {
-				s.earningRepository.CreateEarning(&entities.Earning{
-					Amount:        commission,
-					Type:          "INVITE",
-					TransactionId: tx.Id,
-					AccountId:     uint(inviter.Id),
-				})
-
-				earningAcc, err := s.earningAccRepository.ReadAccountByAccountIdAndType(uint(inviter.Id), "COMMISSION")
-				if err != nil {
-					earningAcc, err = s.earningAccRepository.CreateAccount(&entities.EarningAccount{
-						Type:      "COMMISSION",
-						AccountId: uint(inviter.Id),
-					})
-					if err != nil {
-						return err
-					}
-				}
-				s.earningAccService.CreditAccount(earningAcc.Id, commission)
-				s.earningAccService.DebitAccount(earningAcc.Id, commission*.8) // Debit acc for savings
-			}
-		}
+	if cashback > 0 || commission > 0 {
+		s.deferEarnings(merchant, tx, cashback, "CASHBACK", commission, .8)
 	}
 
 	go func() {
 		date := tx.CreatedAt.Format("02/01/2006, 3:04 PM")
 
 		destination := *tx.Destination
-		if data.Store != "" && len(strings.Split(data.Store, " ")) != 1 {
-			destination = strings.Join(strings.Split(data.Store, " ")[0:4], " ")
+		if name := mpesa_store.ParseStoreName(data.Store); name != "" {
+			destination = name
 		}
 
 		message := fmt.Sprintf("You have purchased KES%v float for %s on %s using Voucher. Cost KES%v. "+
@@ -856,12 +921,11 @@ func (s *service) computeCashback(merchant *presenter.Merchant, tx *entities.Tra
 				fmt.Println(err)
 			}
 		}()
-		_, _ = s.mpesaStoreRepository.CreateStore(&entities.MpesaAgentStoreAccount{
-			Agent:      strings.Split(*tx.Destination, "-")[0],
-			Store:      strings.Split(*tx.Destination, "-")[1],
-			Name:       strings.Join(strings.Split(data.Store, " ")[0:4], " "),
-			MerchantId: merchant.Id,
-		})
+		// Saving the store is idempotent, so retried completions and stores the merchant deleted are left as they are
+		err := s.mpesaStoreService.RecordPurchase(merchant.Id, *tx.Destination, data.Store, tx.CreatedAt)
+		if err != nil {
+			logger.ClientLog.Error("Error recording mpesa store", "tx", tx.Id, "error", err)
+		}
 	}()
 
 	// TODO: add go func with code to debit savings and send to save platform
@@ -875,74 +939,346 @@ func (s *service) computeMpesaWithdrawalCashback(merchant *presenter.Merchant, t
 	// Compute cashback
 	cashback := float32(s.getMpesaWithdrawalCashback(int(tx.Amount)))
 
-	s.earningRepository.CreateEarning(&entities.Earning{
-		Amount:        cashback,
-		Type:          "SELF",
+	// Compute commissions
+	commission := float32(s.getMpesaWithdrawalCommission(int(tx.Amount)))
+
+	if cashback > 0 || commission > 0 {
+		s.deferEarnings(merchant, tx, cashback, "COMMISSION", commission, .2)
+	}
+
+	go func() {
+		float, _ := s.paymentsApi.FetchFloatAccount(strconv.Itoa(int(merchant.FloatAccountId)))
+
+		date := tx.CreatedAt.Format("02/01/2006, 3:04 PM")
+
+		message := fmt.Sprintf("KES%v cash withdrawal by %s on %s was successful. "+
+			"New voucher balance KES%v. Commission earned KES%v. Commission saved KES%v",
+			payment.Amount, *tx.Destination, date, float.Balance, cashback, cashback*.2)
+
+		s.notifyApi.SendSMS("DEFAULT", merchant.Phone, message)
+	}()
+
+	// TODO: add go func with code to debit savings and send to save platform
+	go s.earningService.SaveEarnings()
+
+	return nil
+}
+
+// deferEarnings records the cashback and commission on the transaction to be paid to the merchant and their inviters
+// apart from completing it, so the accounts service or a failed earning account entry only delays them. The first
+// attempt is made right away.
+func (s *service) deferEarnings(merchant *presenter.Merchant, tx *entities.Transaction, cashback float32, cashbackType string, commission, saved float32) {
+	pending, err := s.commissionRepository.CreatePending(&entities.PendingCommission{
+		Amount:        commission,
+		Saved:         saved,
+		Cashback:      cashback,
+		CashbackType:  cashbackType,
+		Status:        consts.COMMISSION_PENDING,
+		NextAttemptAt: time.Now().UTC().Truncate(time.Second),
 		TransactionId: tx.Id,
 		AccountId:     merchant.AccountId,
 	})
+	if err != nil {
+		logger.ClientLog.Error("Error deferring earnings", "tx", tx.Id, "error", err)
+		return
+	}
+	if pending == nil {
+		// Already recorded by an earlier completion
+		return
+	}
+
+	pending.Transaction = *tx
+	go func() {
+		if paid, _ := s.processCommission(*pending); paid {
+			s.earningService.SaveEarnings()
+		}
+	}()
+}
+
+// ProcessCommissions attempts the pending cashback and commissions that are due, returning how many were paid.
+func (s *service) ProcessCommissions() (int, error) {
+	due, err := s.commissionRepository.ReadDuePending(time.Now().UTC(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, pending := range due {
+		if paid, _ := s.processCommission(pending); paid {
+			count++
+		}
+	}
+
+	if count > 0 {
+		go s.earningService.SaveEarnings()
+	}
+
+	return count, nil
+}
+
+// processCommission makes an attempt at paying the pending cashback and commission if no other worker has claimed it.
+// A failed attempt is retried with backoff, until it runs out of attempts and is marked FAILED.
+func (s *service) processCommission(pending entities.PendingCommission) (bool, error) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	claimed, err := s.commissionRepository.ClaimPending(pending.Id, pending.NextAttemptAt, now.Add(commissionRetryDelay(pending.Attempts+1)))
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	err = s.payEarnings(&pending)
+
+	pending.Attempts++
+	pending.Error = ""
+	pending.Status = consts.COMMISSION_PAID
+	if err != nil {
+		logger.ClientLog.Error("Error paying commissions", "tx", pending.TransactionId, "attempt", pending.Attempts, "error", err)
+
+		pending.Error = err.Error()
+		if len(pending.Error) > 255 {
+			pending.Error = pending.Error[:255]
+		}
+		pending.Status = consts.COMMISSION_PENDING
+		pending.NextAttemptAt = now.Add(commissionRetryDelay(pending.Attempts))
+		if pending.Attempts >= commissionMaxAttempts() {
+			pending.Status = consts.COMMISSION_FAILED
+		}
+	}
+
+	if updateErr := s.commissionRepository.UpdatePending(&pending); updateErr != nil {
+		logger.ClientLog.Error("Error updating pending commission", "tx", pending.TransactionId, "error", updateErr)
+		return false, updateErr
+	}
+
+	return err == nil, err
+}
+
+// payEarnings pays the account its cashback on the transaction, then its inviters their shares of the commission.
+// Earnings recorded by an earlier attempt are paid as they were recorded, and the earning account entries already made
+// for them are left as they are, so an attempt that failed part way is completed without paying anyone twice.
+func (s *service) payEarnings(pending *entities.PendingCommission) error {
+	tx := &pending.Transaction
 
-	earningAcc, err := s.earningAccRepository.ReadAccountByAccountIdAndType(merchant.AccountId, "COMMISSION")
+	earnings, err := s.earningRepository.ReadTransactionEarnings(tx.Id)
+	if err != nil {
+		return err
+	}
+
+	if pending.Cashback > 0 {
+		label := "Cashback"
+		if pending.CashbackType == "COMMISSION" {
+			label = "Commission"
+		}
+
+		i := slices.IndexFunc(earnings, func(e entities.Earning) bool { return e.Type == "SELF" })
+		earned := &entities.Earning{Amount: pending.Cashback, Type: "SELF", TransactionId: tx.Id, AccountId: pending.AccountId}
+		if i >= 0 {
+			earned = &earnings[i]
+		} else if earned, err = s.earningRepository.CreateEarning(earned); err != nil {
+			return err
+		}
+
+		if err = s.payEarning(earned, pending.CashbackType, pending.Saved, label+" on", label+" saved from", tx); err != nil {
+			return err
+		}
+	}
+
+	if pending.Amount > 0 {
+		return s.payCommissions(earnings, pending.AccountId, tx, pending.Amount, pending.Saved)
+	}
+
+	return nil
+}
+
+// payCommissions pays the account's inviters their shares of the commission on the transaction, by the product's
+// commission plan, and saves the given portion of each share. The shares are recorded together before any is paid, so
+// a retry pays those recorded rather than splitting again by a plan or referral tree that may have changed since.
+func (s *service) payCommissions(earnings []entities.Earning, accountId uint, tx *entities.Transaction, commission, saved float32) error {
+	shares := slices.DeleteFunc(slices.Clone(earnings), func(e entities.Earning) bool { return e.Type != "INVITE" })
+
+	if len(shares) == 0 {
+		split, err := s.commissionService.Split(accountId, tx.Product, commission)
+		if err != nil {
+			return err
+		}
+		if len(split.Shares) == 0 {
+			return nil
+		}
+
+		for _, share := range split.Shares {
+			shares = append(shares, entities.Earning{
+				Amount:        share.Amount,
+				Type:          "INVITE",
+				Level:         share.Level,
+				Inviters:      split.Chain,
+				TransactionId: tx.Id,
+				AccountId:     share.AccountId,
+			})
+		}
+
+		if shares, err = s.earningRepository.CreateEarnings(shares); err != nil {
+			return err
+		}
+	}
+
+	for i := range shares {
+		if err := s.payEarning(&shares[i], "COMMISSION", saved, "Invite commission on", "Commission saved from", tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// payEarning credits the recorded earning to its account's earning account of the given type and debits the portion
+// saved.
+func (s *service) payEarning(earned *entities.Earning, accType string, saved float32, earnedAs, savedAs string, tx *entities.Transaction) error {
+	earningAcc, err := s.earningAccRepository.ReadAccountByAccountIdAndType(earned.AccountId, accType)
 	if err != nil {
 		earningAcc, err = s.earningAccRepository.CreateAccount(&entities.EarningAccount{
-			Type:      "COMMISSION",
-			AccountId: merchant.AccountId,
+			Type:      accType,
+			AccountId: earned.AccountId,
 		})
 		if err != nil {
 			return err
 		}
 	}
-	s.earningAccService.CreditAccount(earningAcc.Id, cashback)
-	s.earningAccService.DebitAccount(earningAcc.Id, cashback*.2) // Debit acc for savings
 
-	// Compute commissions
-	commission := float32(s.getMpesaWithdrawalCommission(int(tx.Amount)))
+	if _, err = s.earningAccService.CreditAccount(earningAcc.Id, earned.Amount, earningEntry(earnedAs, tx, earned)); err != nil {
+		logger.ClientLog.Error("Error crediting earning", "tx", tx.Id, "earning", earned.Id, "error", err)
+		return err
+	}
 
-	inviters, err := s.accountsApi.GetInviters(strconv.Itoa(int(merchant.AccountId)))
+	// Debit acc for savings
+	if _, _, err = s.earningAccService.DebitAccount(earningAcc.Id, earned.Amount*saved, earningEntry(savedAs, tx, earned)); err != nil {
+		logger.ClientLog.Error("Error debiting earning savings", "tx", tx.Id, "earning", earned.Id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// earningEntry describes an earning account change caused by an earning on the transaction.
+func earningEntry(description string, tx *entities.Transaction, earned *entities.Earning) earning_account.Entry {
+	entry := earning_account.Entry{Description: description + " " + tx.Description, TransactionId: &tx.Id}
+	if earned != nil {
+		entry.EarningId = &earned.Id
+	}
+
+	return entry
+}
+
+// authorize checks that the merchant may move money, that the initiating operator may make the transaction,
+// and that it is within their limits.
+func (s *service) authorize(data *entities.Transaction) (*presenter.Merchant, error) {
+	merchant, err := s.readActiveMerchant(data.MerchantId)
 	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkLimits(merchant, data); err != nil {
+		return nil, err
+	}
+
+	return merchant, nil
+}
+
+// checkLimits checks that the initiating operator may make the transaction and that it is within their and the
+// merchant's limits.
+func (s *service) checkLimits(merchant *presenter.Merchant, data *entities.Transaction) error {
+	if err := s.operatorService.Authorize(merchant, data.OperatorAccountId, data.Product, data.Amount); err != nil {
 		return err
 	}
 
-	if len(inviters) > 1 {
-		for _, inviter := range inviters[1:] {
-			s.earningRepository.CreateEarning(&entities.Earning{
-				Amount:        commission,
-				Type:          "INVITE",
-				TransactionId: tx.Id,
-				AccountId:     uint(inviter.Id),
-			})
+	return s.limitService.Check(merchant, data.Product, data.Amount)
+}
 
-			earningAcc, err := s.earningAccRepository.ReadAccountByAccountIdAndType(uint(inviter.Id), "COMMISSION")
-			if err != nil {
-				earningAcc, err = s.earningAccRepository.CreateAccount(&entities.EarningAccount{
-					Type:      "COMMISSION",
-					AccountId: uint(inviter.Id),
-				})
-				if err != nil {
-					return err
-				}
-			}
-			s.earningAccService.CreditAccount(earningAcc.Id, commission)
-			s.earningAccService.DebitAccount(earningAcc.Id, commission*.2) // Debit acc for savings
+// createTransaction records the authorized transaction, checking the limits again as it is recorded, so concurrent
+// transactions that each passed authorize can't together go over them.
+func (s *service) createTransaction(merchant *presenter.Merchant, data *entities.Transaction) (*entities.Transaction, error) {
+	return s.repository.CreateCheckedTransaction(data, func() error {
+		return s.checkLimits(merchant, data)
+	})
+}
+
+// readActiveMerchant guards money movement, only merchants that completed onboarding and are not suspended or closed may transact.
+func (s *service) readActiveMerchant(id uint) (*presenter.Merchant, error) {
+	merchant, err := s.merchantRepository.ReadMerchant(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if merchant.Status != consts.MERCHANT_ACTIVE || merchant.FloatAccountId == 0 {
+		return nil, pkg.ErrMerchantNotActive
+	}
+
+	return merchant, nil
+}
+
+// resolveRecipient finds the active merchant a transfer is addressed to by their code, phone number or id.
+func (s *service) resolveRecipient(value, recipientType string) (*presenter.Merchant, error) {
+	var recipient *presenter.Merchant
+	var err error
+
+	switch recipientType {
+	case consts.RECIPIENT_PHONE:
+		recipient, err = s.merchantRepository.ReadMerchantByPhone(utils.FormatPhone(value))
+	case consts.RECIPIENT_ID:
+		id, parseErr := strconv.Atoi(value)
+		if parseErr != nil || id <= 0 {
+			return nil, fmt.Errorf("%w: recipient id %q is not valid", pkg.ErrInvalidMerchant, value)
+		}
+		recipient, err = s.merchantRepository.ReadMerchant(uint(id))
+	default:
+		// Checks the code's check digit, so a mistyped code isn't resolved to another merchant
+		recipient, err = s.merchantService.GetMerchantByCode(value)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, pkg.ErrInvalidMerchant
 		}
+		return nil, err
 	}
 
-	go func() {
-		float, _ := s.paymentsApi.FetchFloatAccount(strconv.Itoa(int(merchant.FloatAccountId)))
+	return s.readActiveMerchant(recipient.Id)
+}
 
-		date := tx.CreatedAt.Format("02/01/2006, 3:04 PM")
+// commissionRetryDelay doubles from COMMISSION_RETRY_DELAY with each failed attempt, up to an hour.
+func commissionRetryDelay(attempts int) time.Duration {
+	delay := viper.GetDuration("COMMISSION_RETRY_DELAY")
+	if delay <= 0 {
+		delay = time.Minute
+	}
 
-		message := fmt.Sprintf("KES%v cash withdrawal by %s on %s was successful. "+
-			"New voucher balance KES%v. Commission earned KES%v. Commission saved KES%v",
-			payment.Amount, *tx.Destination, date, float.Balance, cashback, cashback*.2)
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
 
-		s.notifyApi.SendSMS("DEFAULT", merchant.Phone, message)
-	}()
+	return min(delay, time.Hour)
+}
 
-	// TODO: add go func with code to debit savings and send to save platform
-	go s.earningService.SaveEarnings()
+func commissionMaxAttempts() int {
+	attempts := viper.GetInt("COMMISSION_MAX_ATTEMPTS")
+	if attempts <= 0 {
+		return 10
+	}
 
-	return err
+	return attempts
+}
+
+func (s *service) getFloatTransferCharge(amount int) (int, error) {
+	charges, err := s.paymentsApi.GetFloatTransferCharges()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, charge := range charges {
+		if charge.Min <= amount && amount <= charge.Max {
+			return charge.Charge, nil
+		}
+	}
+
+	return 0, nil
 }
 
 func (s *service) getWithdrawalCharge(amount int) int {
@@ -990,19 +1326,24 @@ func (s *service) getMpesaWithdrawalCommission(amount int) int {
 	return 0
 }
 
-func NewService(r Repository, merchantRepo merchant.Repository, paymentRepo payment.Repository, savingsRepo savings.Repository, earningAccRepo earning_account.Repository, earningRepo earning.Repository, mpesaStoreRepo mpesa_store.Repository, earningAccSrv earning_account.Service, earningSrv earning.Service) Service {
+func NewService(r Repository, merchantRepo merchant.Repository, merchantSrv merchant.Service, paymentRepo payment.Repository, savingsRepo savings.Repository, earningAccRepo earning_account.Repository, earningRepo earning.Repository, commissionRepo commission.Repository, mpesaStoreSrv mpesa_store.Service, earningAccSrv earning_account.Service, earningSrv earning.Service, commissionSrv commission.Service, limitSrv limit.Service, operatorSrv operator.Service) Service {
 	return &service{
 		repository: r,
 
 		merchantRepository:   merchantRepo,
+		merchantService:      merchantSrv,
 		paymentRepository:    paymentRepo,
 		savingsRepository:    savingsRepo,
 		earningAccRepository: earningAccRepo,
 		earningRepository:    earningRepo,
-		mpesaStoreRepository: mpesaStoreRepo,
+		commissionRepository: commissionRepo,
+		mpesaStoreService:    mpesaStoreSrv,
 
 		earningAccService: earningAccSrv,
 		earningService:    earningSrv,
+		commissionService: commissionSrv,
+		limitService:      limitSrv,
+		operatorService:   operatorSrv,
 
 		accountsApi: clients.GetAccountClient(),
 		paymentsApi: clients.GetPaymentClient(),
@@ -0,0 +1,576 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"merchants.sidooh/api/presenter"
+	"merchants.sidooh/pkg"
+	"merchants.sidooh/pkg/clients"
+	"merchants.sidooh/pkg/datastore"
+	"merchants.sidooh/pkg/entities"
+	"merchants.sidooh/pkg/services/commission"
+	"merchants.sidooh/pkg/services/earning"
+	"merchants.sidooh/pkg/services/earning_account"
+	"merchants.sidooh/pkg/services/limit"
+	"merchants.sidooh/pkg/services/merchant"
+	"merchants.sidooh/pkg/services/operator"
+	"merchants.sidooh/pkg/services/payment"
+	"merchants.sidooh/utils/consts"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fakeCommissionRepository struct {
+	commission.Repository
+
+	pending map[uint]*entities.PendingCommission
+}
+
+func (f *fakeCommissionRepository) ReadDuePending(before time.Time, limit int) (results []entities.PendingCommission, err error) {
+	for _, p := range f.pending {
+		if p.Status == consts.COMMISSION_PENDING && !p.NextAttemptAt.After(before) {
+			results = append(results, *p)
+		}
+	}
+	return
+}
+
+func (f *fakeCommissionRepository) ClaimPending(id uint, from, until time.Time) (bool, error) {
+	p := f.pending[id]
+	if p.Status != consts.COMMISSION_PENDING || !p.NextAttemptAt.Equal(from) {
+		return false, nil
+	}
+	p.NextAttemptAt = until
+	return true, nil
+}
+
+func (f *fakeCommissionRepository) UpdatePending(data *entities.PendingCommission) error {
+	p := f.pending[data.Id]
+	p.Status, p.Attempts, p.Error, p.NextAttemptAt = data.Status, data.Attempts, data.Error, data.NextAttemptAt
+	return nil
+}
+
+type fakeCommissionService struct {
+	commission.Service
+
+	err    error
+	capped bool // a plan that only pays the first level, a quarter of the commission
+}
+
+func (f *fakeCommissionService) Split(accountId uint, product string, amount float32) (*commission.Split, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.capped {
+		return &commission.Split{Chain: "2,3", Shares: []commission.Share{{AccountId: 2, Level: 1, Amount: amount / 4}}}, nil
+	}
+	return &commission.Split{Chain: "2,3", Shares: []commission.Share{
+		{AccountId: 2, Level: 1, Amount: amount},
+		{AccountId: 3, Level: 2, Amount: amount / 2},
+	}}, nil
+}
+
+type fakeEarningRepository struct {
+	earning.Repository
+
+	earnings []entities.Earning
+	err      error
+}
+
+func (f *fakeEarningRepository) ReadTransactionEarnings(transactionId uint) (results []entities.Earning, err error) {
+	for _, e := range f.earnings {
+		if e.TransactionId == transactionId {
+			results = append(results, e)
+		}
+	}
+	return
+}
+
+func (f *fakeEarningRepository) CreateEarnings(data []entities.Earning) ([]entities.Earning, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	for i := range data {
+		data[i].Id = uint(len(f.earnings) + 1)
+		f.earnings = append(f.earnings, data[i])
+	}
+	return data, nil
+}
+
+func (f *fakeEarningRepository) CreateEarning(data *entities.Earning) (*entities.Earning, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	data.Id = uint(len(f.earnings) + 1)
+	f.earnings = append(f.earnings, *data)
+	return data, nil
+}
+
+type fakeEarningAccRepository struct {
+	earning_account.Repository
+}
+
+func (f *fakeEarningAccRepository) ReadAccountByAccountIdAndType(accountId uint, accType string) (*entities.EarningAccount, error) {
+	return &entities.EarningAccount{ModelID: entities.ModelID{Id: accountId + 100}, Type: accType, AccountId: accountId}, nil
+}
+
+type fakeEarningAccService struct {
+	earning_account.Service
+
+	credits   map[uint]float32
+	applied   map[string]bool
+	err       error
+	failAfter int // entries made before the rest fail
+	made      int
+}
+
+// apply makes an entry once per earning, like the repository does
+func (f *fakeEarningAccService) apply(txType string, accountId uint, amount float32, entry earning_account.Entry) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.failAfter > 0 && f.made >= f.failAfter {
+		return gorm.ErrInvalidDB
+	}
+	f.made++
+	if entry.EarningId != nil {
+		key := fmt.Sprint(*entry.EarningId, txType)
+		if f.applied[key] {
+			return nil
+		}
+		f.applied[key] = true
+	}
+	if txType == "DEBIT" {
+		amount = -amount
+	}
+	f.credits[accountId] += amount
+	return nil
+}
+
+func (f *fakeEarningAccService) CreditAccount(accountId uint, amount float32, entry earning_account.Entry) (*entities.EarningAccount, error) {
+	return nil, f.apply("CREDIT", accountId, amount, entry)
+}
+
+func (f *fakeEarningAccService) DebitAccount(accountId uint, amount float32, entry earning_account.Entry) (*entities.EarningAccount, *entities.EarningAccountTransaction, error) {
+	return nil, nil, f.apply("DEBIT", accountId, amount, entry)
+}
+
+type fakeEarningService struct {
+	earning.Service
+}
+
+func (f *fakeEarningService) SaveEarnings() error {
+	return nil
+}
+
+func newCommissionTestService(pending ...*entities.PendingCommission) (*service, *fakeCommissionRepository, *fakeCommissionService, *fakeEarningRepository, *fakeEarningAccService) {
+	commissions := &fakeCommissionRepository{pending: map[uint]*entities.PendingCommission{}}
+	for _, p := range pending {
+		commissions.pending[p.Id] = p
+	}
+	commissionSrv := &fakeCommissionService{}
+	earnings := &fakeEarningRepository{}
+	earningAccSrv := &fakeEarningAccService{credits: map[uint]float32{}, applied: map[string]bool{}}
+
+	return &service{
+		commissionRepository: commissions,
+		commissionService:    commissionSrv,
+		earningRepository:    earnings,
+		earningAccRepository: &fakeEarningAccRepository{},
+		earningAccService:    earningAccSrv,
+		earningService:       &fakeEarningService{},
+	}, commissions, commissionSrv, earnings, earningAccSrv
+}
+
+func TestService_ProcessCommissions_RetriesOutage(t *testing.T) {
+	due := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	pending := &entities.PendingCommission{
+		ModelID:       entities.ModelID{Id: 1},
+		Amount:        4,
+		Saved:         .5,
+		Status:        consts.COMMISSION_PENDING,
+		NextAttemptAt: due,
+		TransactionId: 9,
+		AccountId:     1,
+		Transaction:   entities.Transaction{ModelID: entities.ModelID{Id: 9}, Product: consts.MPESA_FLOAT},
+	}
+	s, commissions, commissionSrv, earnings, earningAccSrv := newCommissionTestService(pending)
+
+	commissionSrv.err = errors.New("accounts service unavailable")
+	count, err := s.ProcessCommissions()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, consts.COMMISSION_PENDING, pending.Status)
+	assert.Equal(t, 1, pending.Attempts)
+	assert.Equal(t, "accounts service unavailable", pending.Error)
+	assert.True(t, pending.NextAttemptAt.After(time.Now()))
+	assert.Empty(t, earnings.earnings)
+
+	// Not due again until the backoff passes
+	count, _ = s.ProcessCommissions()
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, pending.Attempts)
+
+	commissionSrv.err = nil
+	pending.NextAttemptAt = due
+	count, err = s.ProcessCommissions()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, consts.COMMISSION_PAID, commissions.pending[1].Status)
+	assert.Equal(t, 2, pending.Attempts)
+	assert.Equal(t, "", pending.Error)
+
+	assert.Len(t, earnings.earnings, 2)
+	assert.Equal(t, uint(2), earnings.earnings[1].Level)
+	assert.Equal(t, "2,3", earnings.earnings[1].Inviters)
+	assert.Equal(t, float32(2), earningAccSrv.credits[102])
+	assert.Equal(t, float32(1), earningAccSrv.credits[103])
+}
+
+func TestService_ProcessCommissions_PaysRecordedShares(t *testing.T) {
+	pending := &entities.PendingCommission{
+		ModelID:       entities.ModelID{Id: 1},
+		Amount:        4,
+		Status:        consts.COMMISSION_PENDING,
+		NextAttemptAt: time.Now().UTC().Truncate(time.Second),
+		TransactionId: 9,
+		AccountId:     1,
+		Transaction:   entities.Transaction{ModelID: entities.ModelID{Id: 9}, Product: consts.MPESA_FLOAT},
+	}
+	s, commissions, commissionSrv, earnings, earningAccSrv := newCommissionTestService(pending)
+
+	// The first share is paid, then the earning accounts fail
+	earningAccSrv.failAfter = 2
+	paid, err := s.processCommission(*pending)
+	assert.False(t, paid)
+	assert.ErrorIs(t, err, gorm.ErrInvalidDB)
+	assert.Len(t, earnings.earnings, 2)
+	assert.Equal(t, float32(4), earningAccSrv.credits[102])
+	assert.NotContains(t, earningAccSrv.credits, uint(103))
+
+	// The plan changes before the retry, which pays the shares as recorded, once each
+	earningAccSrv.failAfter = 0
+	commissionSrv.capped = true
+	commissions.pending[1].NextAttemptAt = time.Now().UTC().Truncate(time.Second)
+	paid, err = s.processCommission(*commissions.pending[1])
+	assert.True(t, paid)
+	assert.Nil(t, err)
+	assert.Len(t, earnings.earnings, 2)
+	assert.Equal(t, float32(4), earningAccSrv.credits[102])
+	assert.Equal(t, float32(2), earningAccSrv.credits[103])
+
+	// A claimed attempt isn't made again
+	paid, err = s.processCommission(*pending)
+	assert.False(t, paid)
+	assert.Nil(t, err)
+}
+
+func TestService_ProcessCommissions_RetriesFailedCashback(t *testing.T) {
+	pending := &entities.PendingCommission{
+		ModelID:       entities.ModelID{Id: 1},
+		Saved:         .2,
+		Cashback:      10,
+		CashbackType:  "COMMISSION",
+		Status:        consts.COMMISSION_PENDING,
+		NextAttemptAt: time.Now().UTC().Truncate(time.Second),
+		TransactionId: 9,
+		AccountId:     1,
+		Transaction:   entities.Transaction{ModelID: entities.ModelID{Id: 9}, Product: consts.CASH_WITHDRAW},
+	}
+	s, commissions, _, earnings, earningAccSrv := newCommissionTestService(pending)
+
+	earningAccSrv.err = gorm.ErrInvalidDB
+	paid, err := s.processCommission(*pending)
+	assert.False(t, paid)
+	assert.ErrorIs(t, err, gorm.ErrInvalidDB)
+	assert.Equal(t, consts.COMMISSION_PENDING, pending.Status)
+	assert.Equal(t, gorm.ErrInvalidDB.Error(), pending.Error)
+	assert.Len(t, earnings.earnings, 1)
+	assert.Empty(t, earningAccSrv.credits)
+
+	earningAccSrv.err = nil
+	paid, err = s.processCommission(*commissions.pending[1])
+	assert.True(t, paid)
+	assert.Nil(t, err)
+	assert.Equal(t, consts.COMMISSION_PAID, pending.Status)
+
+	// The earning recorded by the failed attempt is credited, not recorded again
+	assert.Len(t, earnings.earnings, 1)
+	assert.Equal(t, "SELF", earnings.earnings[0].Type)
+	assert.Equal(t, float32(8), earningAccSrv.credits[101])
+}
+
+func TestService_ProcessCommissions_GivesUp(t *testing.T) {
+	pending := &entities.PendingCommission{
+		ModelID:       entities.ModelID{Id: 1},
+		Amount:        4,
+		Status:        consts.COMMISSION_PENDING,
+		Attempts:      9,
+		NextAttemptAt: time.Now().UTC().Truncate(time.Second),
+		TransactionId: 9,
+		AccountId:     1,
+	}
+	s, _, _, earnings, _ := newCommissionTestService(pending)
+	earnings.err = gorm.ErrInvalidDB
+
+	paid, err := s.processCommission(*pending)
+	assert.False(t, paid)
+	assert.ErrorIs(t, err, gorm.ErrInvalidDB)
+	assert.Equal(t, consts.COMMISSION_FAILED, pending.Status)
+	assert.Equal(t, 10, pending.Attempts)
+}
+
+func TestCommissionRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, commissionRetryDelay(1))
+	assert.Equal(t, 4*time.Minute, commissionRetryDelay(3))
+	assert.Equal(t, time.Hour, commissionRetryDelay(10))
+}
+
+type fakeMerchantRepository struct {
+	merchant.Repository
+
+	merchants map[uint]*presenter.Merchant
+}
+
+func (f *fakeMerchantRepository) ReadMerchant(id uint) (*presenter.Merchant, error) {
+	m, ok := f.merchants[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return m, nil
+}
+
+func (f *fakeMerchantRepository) ReadMerchantByCode(code uint) (*presenter.Merchant, error) {
+	for _, m := range f.merchants {
+		if m.Code == strconv.Itoa(int(code)) {
+			return m, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func TestService_ReadActiveMerchant(t *testing.T) {
+	s := &service{merchantRepository: &fakeMerchantRepository{merchants: map[uint]*presenter.Merchant{
+		1: {Id: 1, Status: consts.MERCHANT_ACTIVE, FloatAccountId: 7},
+		2: {Id: 2, Status: consts.MERCHANT_SUSPENDED, FloatAccountId: 7},
+		3: {Id: 3, Status: consts.MERCHANT_CLOSED, FloatAccountId: 7},
+		4: {Id: 4, Status: consts.MERCHANT_PENDING_KYB},
+		5: {Id: 5, Status: consts.MERCHANT_ACTIVE},
+	}}}
+
+	m, err := s.readActiveMerchant(1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), m.Id)
+
+	for _, id := range []uint{2, 3, 4, 5} {
+		_, err = s.readActiveMerchant(id)
+		assert.ErrorIs(t, err, pkg.ErrMerchantNotActive, "merchant %d", id)
+	}
+
+	_, err = s.readActiveMerchant(99)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+type fakeOperatorService struct {
+	operator.Service
+}
+
+func (f *fakeOperatorService) Authorize(merchant *presenter.Merchant, accountId *uint, product string, amount float32) error {
+	return nil
+}
+
+type fakeLimitService struct {
+	limit.Service
+
+	dailyCount int // a daily count limit on the merchant's recorded transactions, none if 0
+}
+
+func (f *fakeLimitService) Check(merchant *presenter.Merchant, product string, amount float32) error {
+	if f.dailyCount == 0 {
+		return nil
+	}
+	var count int64
+	if err := datastore.DB.Model(&entities.Transaction{}).Where("merchant_id", merchant.Id).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) >= f.dailyCount {
+		return pkg.ErrLimitExceeded
+	}
+	return nil
+}
+
+type fakePaymentRepository struct {
+	payment.Repository
+}
+
+func (f *fakePaymentRepository) CreatePayment(data *entities.Payment) (*entities.Payment, error) {
+	return data, nil
+}
+
+// newTransferTestService keeps previews and transactions in the test database and answers payments requests with
+// a float balance of 1000 and a charge of 10.
+func newTransferTestService(t *testing.T) *service {
+	viper.Set("APP_ENV", "TEST")
+	viper.Set("MIGRATE_DB", true)
+	datastore.Init()
+	t.Cleanup(func() { os.Remove("test.db") })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/signin":
+			w.Write([]byte(`{"access_token":"token"}`))
+		case "/float-accounts/7":
+			w.Write([]byte(`{"result":1,"data":{"id":7,"balance":1000}}`))
+		case "/charges/float-transfer":
+			w.Write([]byte(`{"result":1,"data":[{"min":1,"max":70000,"charge":10}]}`))
+		case "/payments/merchant-float-transfer":
+			w.Write([]byte(`{"result":1,"data":{"id":3,"amount":"100","status":"PENDING"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	viper.Set("SIDOOH_ACCOUNTS_API_URL", server.URL)
+	viper.Set("SIDOOH_PAYMENTS_API_URL", server.URL)
+	clients.Init()
+	clients.InitPaymentClient()
+
+	// Transactions are recorded with their merchant locked
+	err := datastore.DB.Create(&[]entities.Merchant{
+		{ModelID: entities.ModelID{Id: 1}, IdNumber: "1", Phone: "254700000001", AccountId: 10},
+		{ModelID: entities.ModelID{Id: 2}, IdNumber: "2", Phone: "254700000002", AccountId: 20},
+	}).Error
+	assert.NoError(t, err)
+
+	merchantRepo := &fakeMerchantRepository{merchants: map[uint]*presenter.Merchant{
+		1: {Id: 1, AccountId: 10, FloatAccountId: 7, Code: "123455", Status: consts.MERCHANT_ACTIVE},
+		2: {Id: 2, AccountId: 20, FloatAccountId: 8, Code: "543215", BusinessName: "Corner Store", Status: consts.MERCHANT_ACTIVE},
+		3: {Id: 3, AccountId: 30, FloatAccountId: 9, Code: "111112", Status: consts.MERCHANT_SUSPENDED},
+	}}
+
+	return &service{
+		repository:         NewRepo(),
+		merchantRepository: merchantRepo,
+		merchantService:    merchant.NewService(merchantRepo),
+		paymentRepository:  &fakePaymentRepository{},
+		operatorService:    &fakeOperatorService{},
+		limitService:       &fakeLimitService{},
+		paymentsApi:        clients.GetPaymentClient(),
+	}
+}
+
+func transfer(amount float32) *entities.Transaction {
+	owner := uint(10)
+	return &entities.Transaction{Amount: amount, MerchantId: 1, Product: consts.FLOAT_TRANSFER, OperatorAccountId: &owner}
+}
+
+func TestService_PreviewFloatTransfer(t *testing.T) {
+	s := newTransferTestService(t)
+
+	preview, err := s.PreviewFloatTransfer(transfer(100), "543215", consts.RECIPIENT_CODE)
+	assert.NoError(t, err)
+	assert.Len(t, preview.Token, 32)
+	assert.Equal(t, uint(2), preview.RecipientId)
+	assert.Equal(t, float32(10), preview.Charge)
+	assert.Equal(t, float32(890), preview.Balance)
+	assert.True(t, preview.ExpiresAt.After(time.Now()))
+
+	preview, err = s.PreviewFloatTransfer(transfer(100), "2", consts.RECIPIENT_ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), preview.RecipientId)
+
+	// A mistyped code fails its check digit rather than reaching another merchant
+	_, err = s.PreviewFloatTransfer(transfer(100), "543216", consts.RECIPIENT_CODE)
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchantCode)
+
+	_, err = s.PreviewFloatTransfer(transfer(100), "2a", consts.RECIPIENT_ID)
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchant)
+	_, err = s.PreviewFloatTransfer(transfer(100), "99", consts.RECIPIENT_ID)
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchant)
+	_, err = s.PreviewFloatTransfer(transfer(100), "1", consts.RECIPIENT_ID)
+	assert.ErrorIs(t, err, pkg.ErrInvalidMerchant)
+	_, err = s.PreviewFloatTransfer(transfer(100), "111112", consts.RECIPIENT_CODE)
+	assert.ErrorIs(t, err, pkg.ErrMerchantNotActive)
+
+	_, err = s.PreviewFloatTransfer(transfer(995), "543215", consts.RECIPIENT_CODE)
+	assert.ErrorIs(t, err, pkg.ErrInsufficientBalance)
+}
+
+func TestService_FloatTransfer_PreviewToken(t *testing.T) {
+	s := newTransferTestService(t)
+
+	preview, err := s.PreviewFloatTransfer(transfer(100), "543215", consts.RECIPIENT_CODE)
+	assert.NoError(t, err)
+
+	_, err = s.FloatTransfer(transfer(150), preview.Token)
+	assert.ErrorIs(t, err, pkg.ErrInvalidPreviewToken)
+
+	tx, err := s.FloatTransfer(transfer(100), preview.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", *tx.Destination)
+
+	_, err = s.FloatTransfer(transfer(100), preview.Token)
+	assert.ErrorIs(t, err, pkg.ErrInvalidPreviewToken)
+
+	expired, err := s.repository.CreatePreview(&entities.FloatTransferPreview{
+		Token: "expired", Amount: 100, RecipientId: 2, MerchantId: 1, ExpiresAt: time.Now().Add(-time.Second),
+	})
+	assert.NoError(t, err)
+	_, err = s.FloatTransfer(transfer(100), expired.Token)
+	assert.ErrorIs(t, err, pkg.ErrInvalidPreviewToken)
+}
+
+func TestService_CreateTransaction_RechecksLimits(t *testing.T) {
+	s := newTransferTestService(t)
+	s.limitService = &fakeLimitService{dailyCount: 1}
+
+	// Both pass authorization before either is recorded
+	first, second := transfer(100), transfer(100)
+	merchant, err := s.authorize(first)
+	assert.NoError(t, err)
+	_, err = s.authorize(second)
+	assert.NoError(t, err)
+
+	_, err = s.createTransaction(merchant, first)
+	assert.NoError(t, err)
+	_, err = s.createTransaction(merchant, second)
+	assert.ErrorIs(t, err, pkg.ErrLimitExceeded)
+
+	var count int64
+	datastore.DB.Model(&entities.Transaction{}).Where("merchant_id", 1).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestService_PreviewFloatTransfer_ChargesUnavailable(t *testing.T) {
+	s := newTransferTestService(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/float-accounts/7":
+			w.Write([]byte(`{"result":1,"data":{"id":7,"balance":1000}}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	viper.Set("SIDOOH_PAYMENTS_API_URL", server.URL)
+	clients.InitPaymentClient()
+	s.paymentsApi = clients.GetPaymentClient()
+
+	preview, err := s.PreviewFloatTransfer(transfer(100), "543215", consts.RECIPIENT_CODE)
+	assert.Error(t, err)
+	assert.Nil(t, preview)
+
+	var count int64
+	datastore.DB.Model(&entities.FloatTransferPreview{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
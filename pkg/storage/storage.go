@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("storage key is invalid")
+
+// Storage stores files under slash separated keys, e.g. merchants/1/KRA_PIN.pdf
+type Storage interface {
+	Put(key string, reader io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+type local struct {
+	root string
+}
+
+func (l *local) Put(key string, reader io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(file, reader); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (l *local) Get(key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (l *local) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path resolves the key inside the root, rejecting keys that would escape it.
+func (l *local) path(key string) (string, error) {
+	if key == "" || strings.Contains(key, "..") || filepath.IsAbs(key) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+func NewLocal(root string) Storage {
+	return &local{root: root}
+}
+
+// New returns the backend set by STORAGE_DRIVER, only the local filesystem is supported for now.
+func New() (Storage, error) {
+	switch driver := strings.ToUpper(viper.GetString("STORAGE_DRIVER")); driver {
+	case "", "LOCAL":
+		root := viper.GetString("STORAGE_PATH")
+		if root == "" {
+			root = "storage"
+		}
+
+		return NewLocal(root), nil
+	default:
+		return nil, fmt.Errorf("storage driver %s is not supported", driver)
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	s := NewLocal(t.TempDir())
+
+	err := s.Put("merchants/1/permit.pdf", strings.NewReader("permit"))
+	assert.Nil(t, err)
+
+	reader, err := s.Get("merchants/1/permit.pdf")
+	assert.Nil(t, err)
+
+	content, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, "permit", string(content))
+
+	assert.Nil(t, s.Delete("merchants/1/permit.pdf"))
+	assert.Nil(t, s.Delete("merchants/1/permit.pdf"))
+
+	_, err = s.Get("merchants/1/permit.pdf")
+	assert.NotNil(t, err)
+}
+
+func TestLocal_InvalidKey(t *testing.T) {
+	s := NewLocal(t.TempDir())
+
+	assert.ErrorIs(t, s.Put("../outside", strings.NewReader("")), ErrInvalidKey)
+	assert.ErrorIs(t, s.Put("/etc/passwd", strings.NewReader("")), ErrInvalidKey)
+	assert.ErrorIs(t, s.Put("", strings.NewReader("")), ErrInvalidKey)
+}
@@ -0,0 +1,143 @@
+package consts
+
+// Merchant lifecycle statuses
+const (
+	MERCHANT_PENDING_KYC = "PENDING_KYC"
+	MERCHANT_PENDING_KYB = "PENDING_KYB"
+	MERCHANT_ACTIVE      = "ACTIVE"
+	MERCHANT_SUSPENDED   = "SUSPENDED"
+	MERCHANT_CLOSED      = "CLOSED"
+)
+
+// Merchant limit tiers, further tiers only need limits to be configured
+const (
+	TIER_STANDARD = "STANDARD"
+)
+
+// Merchant code statuses
+const (
+	CODE_ACTIVE  = "ACTIVE"
+	CODE_RETIRED = "RETIRED"
+)
+
+// Merchant onboarding steps, in the order they are run
+const (
+	ONBOARDING_ACCOUNT_LOOKUP       = "ACCOUNT_LOOKUP"
+	ONBOARDING_KYC                  = "KYC"
+	ONBOARDING_KYB                  = "KYB"
+	ONBOARDING_DOCUMENTS            = "DOCUMENTS"
+	ONBOARDING_CODE_ALLOCATION      = "CODE_ALLOCATION"
+	ONBOARDING_FLOAT_ACCOUNT        = "FLOAT_ACCOUNT"
+	ONBOARDING_WELCOME_NOTIFICATION = "WELCOME_NOTIFICATION"
+	ONBOARDING_COMPLETED            = "COMPLETED"
+)
+
+// Onboarding step statuses
+const (
+	STEP_PENDING   = "PENDING"
+	STEP_COMPLETED = "COMPLETED"
+	STEP_FAILED    = "FAILED"
+)
+
+// KYB document types
+const (
+	DOCUMENT_BUSINESS_PERMIT = "BUSINESS_PERMIT"
+	DOCUMENT_KRA_PIN         = "KRA_PIN"
+	DOCUMENT_ID_FRONT        = "ID_FRONT"
+	DOCUMENT_ID_BACK         = "ID_BACK"
+)
+
+// KYB document review statuses
+const (
+	DOCUMENT_PENDING    = "PENDING"
+	DOCUMENT_APPROVED   = "APPROVED"
+	DOCUMENT_REJECTED   = "REJECTED"
+	DOCUMENT_SUPERSEDED = "SUPERSEDED"
+)
+
+// Merchant operator roles
+const (
+	OPERATOR_OWNER   = "OWNER"
+	OPERATOR_CASHIER = "CASHIER"
+	OPERATOR_VIEWER  = "VIEWER"
+)
+
+// Merchant operator statuses
+const (
+	OPERATOR_ACTIVE  = "ACTIVE"
+	OPERATOR_REMOVED = "REMOVED"
+)
+
+// Ways a float transfer recipient may be identified
+const (
+	RECIPIENT_CODE  = "CODE"
+	RECIPIENT_PHONE = "PHONE"
+	RECIPIENT_ID    = "ID"
+)
+
+// Statement accounts, besides the earning account types
+const (
+	STATEMENT_VOUCHER = "VOUCHER"
+	STATEMENT_SAVINGS = "SAVINGS"
+)
+
+// Sources of merchant changes recorded in the audit log
+const (
+	AUDIT_API        = "API"
+	AUDIT_ONBOARDING = "ONBOARDING"
+	AUDIT_SYSTEM     = "SYSTEM"
+)
+
+// Merchant import statuses
+const (
+	IMPORT_INVALID   = "INVALID"
+	IMPORT_PENDING   = "PENDING"
+	IMPORT_RUNNING   = "RUNNING"
+	IMPORT_COMPLETED = "COMPLETED"
+	IMPORT_PARTIAL   = "PARTIAL"
+)
+
+// Merchant import row statuses
+const (
+	IMPORT_ROW_VALID   = "VALID"
+	IMPORT_ROW_INVALID = "INVALID"
+	IMPORT_ROW_CREATED = "CREATED"
+	IMPORT_ROW_FAILED  = "FAILED"
+)
+
+// Location import changes and file formats
+const (
+	LOCATION_ADDED   = "ADDED"
+	LOCATION_CHANGED = "CHANGED"
+
+	LOCATION_FORMAT_CSV  = "csv"
+	LOCATION_FORMAT_JSON = "json"
+)
+
+// Location levels merchant analytics are grouped by
+const (
+	LEVEL_COUNTY     = "county"
+	LEVEL_SUB_COUNTY = "sub_county"
+	LEVEL_WARD       = "ward"
+)
+
+// Scheduled float purchase statuses
+const (
+	SCHEDULE_ACTIVE = "ACTIVE"
+	SCHEDULE_PAUSED = "PAUSED"
+)
+
+// Scheduled float purchase run statuses
+const (
+	RUN_PENDING   = "PENDING"
+	RUN_SUBMITTED = "SUBMITTED"
+	RUN_FAILED    = "FAILED"
+	RUN_SKIPPED   = "SKIPPED"
+)
+
+// Pending commission statuses
+const (
+	COMMISSION_PENDING = "PENDING"
+	COMMISSION_PAID    = "PAID"
+	COMMISSION_FAILED  = "FAILED"
+)
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidEMVPayload = errors.New("emv payload is invalid")
+
+// EMVField is a data object of an EMVCo QR payload, identified by a two digit id.
+type EMVField struct {
+	Id    string
+	Value string
+}
+
+// emvMaxLength is the longest value a field's two digit length can describe.
+const emvMaxLength = 99
+
+// EncodeEMVFields serializes the fields as id, two digit length and value. Values longer than 99 characters are
+// refused rather than written with a length that would corrupt the payload.
+func EncodeEMVFields(fields ...EMVField) (string, error) {
+	var builder strings.Builder
+	for _, field := range fields {
+		if len(field.Value) > emvMaxLength {
+			return "", fmt.Errorf("%w: field %s is %d characters, at most %d fit", ErrInvalidEMVPayload, field.Id, len(field.Value), emvMaxLength)
+		}
+		builder.WriteString(fmt.Sprintf("%s%02d%s", field.Id, len(field.Value), field.Value))
+	}
+
+	return builder.String(), nil
+}
+
+// EncodeEMVPayload serializes the fields and appends the CRC field (63) that closes a payload.
+func EncodeEMVPayload(fields ...EMVField) (string, error) {
+	payload, err := EncodeEMVFields(fields...)
+	if err != nil {
+		return "", err
+	}
+	payload += "6304"
+
+	return payload + fmt.Sprintf("%04X", CRC16CCITT([]byte(payload))), nil
+}
+
+// ParseEMVFields reads serialized fields into a map keyed by id.
+func ParseEMVFields(data string) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, ErrInvalidEMVPayload
+		}
+
+		length, err := strconv.Atoi(data[2:4])
+		if err != nil || len(data) < 4+length {
+			return nil, ErrInvalidEMVPayload
+		}
+
+		fields[data[:2]] = data[4 : 4+length]
+		data = data[4+length:]
+	}
+
+	return fields, nil
+}
+
+// ParseEMVPayload verifies the payload's CRC and reads its fields.
+func ParseEMVPayload(payload string) (map[string]string, error) {
+	if len(payload) < 8 || payload[len(payload)-8:len(payload)-4] != "6304" {
+		return nil, ErrInvalidEMVPayload
+	}
+
+	crc := payload[len(payload)-4:]
+	if !strings.EqualFold(crc, fmt.Sprintf("%04X", CRC16CCITT([]byte(payload[:len(payload)-4])))) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidEMVPayload)
+	}
+
+	return ParseEMVFields(payload[:len(payload)-8])
+}
+
+// CRC16CCITT computes the CRC-16/CCITT-FALSE checksum EMVCo payloads use, polynomial 0x1021 and initial value 0xFFFF.
+func CRC16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestCRC16CCITT(t *testing.T) {
+	assert.Equal(t, uint16(0x29B1), CRC16CCITT([]byte("123456789")))
+}
+
+func TestEncodeEMVPayload(t *testing.T) {
+	account, err := EncodeEMVFields(EMVField{Id: "00", Value: "ke.sidooh"}, EMVField{Id: "01", Value: "123455"})
+	assert.NoError(t, err)
+	payload, err := EncodeEMVPayload(
+		EMVField{Id: "00", Value: "01"},
+		EMVField{Id: "26", Value: account},
+		EMVField{Id: "59", Value: "Kiosk"},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "00020126230009ke.sidooh01061234555905Kiosk6304", payload[:len(payload)-4])
+
+	fields, err := ParseEMVPayload(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, "Kiosk", fields["59"])
+
+	parsed, err := ParseEMVFields(fields["26"])
+	assert.NoError(t, err)
+	assert.Equal(t, "123455", parsed["01"])
+}
+
+func TestEncodeEMVPayload_TooLong(t *testing.T) {
+	payload, err := EncodeEMVPayload(EMVField{Id: "59", Value: strings.Repeat("K", 99)})
+	assert.NoError(t, err)
+	fields, err := ParseEMVPayload(payload)
+	assert.NoError(t, err)
+	assert.Len(t, fields["59"], 99)
+
+	_, err = EncodeEMVPayload(EMVField{Id: "00", Value: "01"}, EMVField{Id: "59", Value: strings.Repeat("K", 100)})
+	assert.ErrorIs(t, err, ErrInvalidEMVPayload)
+
+	_, err = EncodeEMVFields(EMVField{Id: "00", Value: strings.Repeat("k", 120)})
+	assert.ErrorIs(t, err, ErrInvalidEMVPayload)
+}
+
+func TestParseEMVPayload_Invalid(t *testing.T) {
+	payload, err := EncodeEMVPayload(EMVField{Id: "59", Value: "Kiosk"})
+	assert.NoError(t, err)
+
+	_, err = ParseEMVPayload(payload[:len(payload)-1] + "0")
+	assert.ErrorIs(t, err, ErrInvalidEMVPayload)
+
+	_, err = ParseEMVPayload("0002")
+	assert.ErrorIs(t, err, ErrInvalidEMVPayload)
+
+	_, err = ParseEMVFields("0010ab")
+	assert.ErrorIs(t, err, ErrInvalidEMVPayload)
+}
@@ -0,0 +1,35 @@
+package utils
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// Haversine returns the great-circle distance in kilometres between two coordinates.
+func Haversine(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := radians(lat2 - lat1)
+	dLng := radians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBox returns the latitude and longitude ranges that contain every point within radius kilometres,
+// it lets databases without trigonometric functions narrow down candidates on plain indexed columns.
+func BoundingBox(lat, lng, radius float64) (minLat, maxLat, minLng, maxLng float64) {
+	dLat := radius / earthRadiusKm * 180 / math.Pi
+	minLat, maxLat = math.Max(lat-dLat, -90), math.Min(lat+dLat, 90)
+
+	// Longitude degrees shrink towards the poles, near them the whole range is searched
+	if minLat == -90 || maxLat == 90 {
+		return minLat, maxLat, -180, 180
+	}
+
+	dLng := dLat / math.Cos(radians(lat))
+	return minLat, maxLat, math.Max(lng-dLng, -180), math.Min(lng+dLng, 180)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
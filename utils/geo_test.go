@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	// Nairobi CBD to Mombasa CBD
+	assert.InDelta(t, 440, Haversine(-1.2864, 36.8172, -4.0435, 39.6682), 5)
+	assert.Zero(t, Haversine(-1.2864, 36.8172, -1.2864, 36.8172))
+}
+
+func TestBoundingBox(t *testing.T) {
+	minLat, maxLat, minLng, maxLng := BoundingBox(-1.2864, 36.8172, 5)
+
+	assert.InDelta(t, -1.3314, minLat, 0.001)
+	assert.InDelta(t, -1.2414, maxLat, 0.001)
+	assert.InDelta(t, 36.7722, minLng, 0.001)
+	assert.InDelta(t, 36.8622, maxLng, 0.001)
+
+	// Points on the box edges are at least the radius away
+	assert.GreaterOrEqual(t, Haversine(-1.2864, 36.8172, -1.2864, maxLng), 4.999)
+	assert.GreaterOrEqual(t, Haversine(-1.2864, 36.8172, minLat, 36.8172), 4.999)
+
+	_, _, minLng, maxLng = BoundingBox(89.99, 0, 5)
+	assert.Equal(t, -180.0, minLng)
+	assert.Equal(t, 180.0, maxLng)
+}
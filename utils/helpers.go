@@ -2,9 +2,62 @@ package utils
 
 import (
 	"encoding/json"
+	"strings"
 )
 
 func ConvertStruct(from interface{}, to interface{}) {
 	record, _ := json.Marshal(from)
 	_ = json.Unmarshal(record, to)
 }
+
+// FormatPhone normalises Kenyan numbers to the 254XXXXXXXXX form accounts are stored with.
+func FormatPhone(phone string) string {
+	phone = strings.TrimPrefix(strings.ReplaceAll(strings.TrimSpace(phone), " ", ""), "+")
+
+	if strings.HasPrefix(phone, "0") && len(phone) == 10 {
+		return "254" + phone[1:]
+	}
+	if (strings.HasPrefix(phone, "7") || strings.HasPrefix(phone, "1")) && len(phone) == 9 {
+		return "254" + phone
+	}
+
+	return phone
+}
+
+// MaskName keeps the first two letters of every word, e.g. "Mama Mboga" becomes "Ma** Mb***".
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		for j := 2; j < len(runes); j++ {
+			runes[j] = '*'
+		}
+		words[i] = string(runes)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Levenshtein returns the number of single character edits that turn a into b.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
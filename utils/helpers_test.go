@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormatPhone(t *testing.T) {
+	tests := map[string]string{
+		"0712345678":     "254712345678",
+		"0112345678":     "254112345678",
+		"712345678":      "254712345678",
+		"+254712345678":  "254712345678",
+		"254 712 345678": "254712345678",
+		"254712345678":   "254712345678",
+	}
+
+	for phone, expected := range tests {
+		assert.Equalf(t, expected, FormatPhone(phone), "FormatPhone(%v)", phone)
+	}
+}
+
+func TestMaskName(t *testing.T) {
+	assert.Equal(t, "Ma** Mb*** Sh**", MaskName("Mama Mboga Shop"))
+	assert.Equal(t, "A Ki", MaskName(" A  Ki "))
+	assert.Equal(t, "", MaskName(""))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, Levenshtein("ward", "ward"))
+	assert.Equal(t, 1, Levenshtein("sarit", "sarrit"))
+	assert.Equal(t, 3, Levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, Levenshtein("", "ward"))
+}
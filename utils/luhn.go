@@ -0,0 +1,40 @@
+package utils
+
+import "strconv"
+
+// LuhnCheckDigit computes the check digit to append to the numeric payload.
+func LuhnCheckDigit(payload string) (int, error) {
+	sum := 0
+	double := true
+	for i := len(payload) - 1; i >= 0; i-- {
+		digit, err := strconv.Atoi(string(payload[i]))
+		if err != nil {
+			return 0, err
+		}
+
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return (10 - sum%10) % 10, nil
+}
+
+// ValidLuhn checks that the last digit of the number is the Luhn check digit of the rest.
+func ValidLuhn(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+
+	check, err := LuhnCheckDigit(number[:len(number)-1])
+	if err != nil {
+		return false
+	}
+
+	return strconv.Itoa(check) == number[len(number)-1:]
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLuhnCheckDigit(t *testing.T) {
+	tests := map[string]int{
+		"7992739871": 3,
+		"12345":      5,
+		"10000":      8,
+		"0":          0,
+	}
+
+	for payload, expected := range tests {
+		digit, err := LuhnCheckDigit(payload)
+		assert.NoError(t, err)
+		assert.Equalf(t, expected, digit, "LuhnCheckDigit(%v)", payload)
+	}
+
+	_, err := LuhnCheckDigit("12a4")
+	assert.Error(t, err)
+}
+
+func TestValidLuhn(t *testing.T) {
+	assert.True(t, ValidLuhn("79927398713"))
+	assert.True(t, ValidLuhn("123455"))
+	assert.False(t, ValidLuhn("123454"), "wrong check digit")
+	assert.False(t, ValidLuhn("132455"), "transposed digits")
+	assert.False(t, ValidLuhn("5"))
+	assert.False(t, ValidLuhn("12a45"))
+}
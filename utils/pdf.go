@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 page size in points
+const (
+	PDFPageWidth  = 595.0
+	PDFPageHeight = 842.0
+)
+
+// PDF is a minimal writer for text documents using the standard Helvetica fonts, which need no embedding.
+type PDF struct {
+	pages []*bytes.Buffer
+}
+
+func NewPDF() *PDF {
+	return &PDF{}
+}
+
+// AddPage starts a new page, subsequent drawing goes to it.
+func (p *PDF) AddPage() {
+	p.pages = append(p.pages, new(bytes.Buffer))
+}
+
+// Text draws text with its baseline at y, measured from the top of the page.
+func (p *PDF) Text(x, y, size float64, bold bool, text string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+
+	fmt.Fprintf(p.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, PDFPageHeight-y, pdfEscape(text))
+}
+
+// TextRight draws text that ends at x.
+func (p *PDF) TextRight(x, y, size float64, bold bool, text string) {
+	p.Text(x-PDFTextWidth(text, size), y, size, bold, text)
+}
+
+// Line draws a thin line between two points, measured from the top of the page.
+func (p *PDF) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(p.page(), "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, PDFPageHeight-y1, x2, PDFPageHeight-y2)
+}
+
+// Output writes the document, a document without pages gets a blank one.
+func (p *PDF) Output(w io.Writer) error {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts, each page then takes a page and a content object
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PDFPageWidth, PDFPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+func (p *PDF) page() *bytes.Buffer {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+
+	return p.pages[len(p.pages)-1]
+}
+
+// PDFTextWidth approximates the width of Helvetica text, exact for the digits and separators used in amounts.
+func PDFTextWidth(text string, size float64) float64 {
+	var units float64
+	for _, r := range text {
+		switch {
+		case r == '.' || r == ',' || r == ' ' || r == 'i' || r == 'l':
+			units += 278
+		case r == '-':
+			units += 333
+		case r >= 'A' && r <= 'Z':
+			units += 667
+		default:
+			units += 556
+		}
+	}
+
+	return units * size / 1000
+}
+
+// pdfEscape escapes string delimiters and replaces characters the standard fonts cannot show.
+func pdfEscape(text string) string {
+	var builder strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			builder.WriteRune('\\')
+			builder.WriteRune(r)
+		case r < 32 || r > 126:
+			builder.WriteRune('?')
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDF_Output(t *testing.T) {
+	pdf := NewPDF()
+	pdf.AddPage()
+	pdf.Text(40, 40, 12, true, "Statement (Test)")
+	pdf.Line(40, 50, 555, 50)
+	pdf.AddPage()
+	pdf.TextRight(555, 40, 9, false, "1,000.00")
+
+	var out bytes.Buffer
+	assert.NoError(t, pdf.Output(&out))
+
+	document := out.String()
+	assert.True(t, strings.HasPrefix(document, "%PDF-1.4\n"))
+	assert.True(t, strings.HasSuffix(document, "%%EOF\n"))
+	assert.Contains(t, document, "/Count 2")
+	assert.Contains(t, document, `(Statement \(Test\)) Tj`)
+
+	// The xref offsets point at their objects
+	xref := regexp.MustCompile(`(?s)xref\n0 (\d+)\n0000000000 65535 f \n(.*)trailer`).FindStringSubmatch(document)
+	assert.Len(t, xref, 3)
+	for i, line := range strings.Split(strings.TrimSpace(xref[2]), "\n") {
+		offset, _ := strconv.Atoi(line[:10])
+		assert.True(t, strings.HasPrefix(document[offset:], strconv.Itoa(i+1)+" 0 obj"), "object %d", i+1)
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	assert.Equal(t, `a\(b\)\\c?`, pdfEscape(`a(b)\c`+"é"))
+}
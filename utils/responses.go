@@ -83,6 +83,30 @@ func HandleErrorResponse(ctx *fiber.Ctx, err error) error {
 		return ctx.Status(http.StatusUnprocessableEntity).JSON(ErrorResponse("insufficient balance", nil))
 	}
 
+	if errors.Is(err, pkg.ErrMerchantNotActive) || errors.Is(err, pkg.ErrOperatorNotPermitted) || errors.Is(err, pkg.ErrAdminRequired) {
+		return ctx.Status(http.StatusForbidden).JSON(SimpleValidationErrorResponse(err))
+	}
+
+	if errors.Is(err, pkg.ErrInvalidStatusTransition) ||
+		errors.Is(err, pkg.ErrInvalidMerchantCode) ||
+		errors.Is(err, pkg.ErrMerchantCodeInUse) ||
+		errors.Is(err, pkg.ErrInvalidQRPayload) ||
+		errors.Is(err, pkg.ErrOnboardingInputRequired) ||
+		errors.Is(err, pkg.ErrInvalidDocument) ||
+		errors.Is(err, pkg.ErrDocumentReviewed) ||
+		errors.Is(err, pkg.ErrLimitExceeded) ||
+		errors.Is(err, pkg.ErrInvalidPreviewToken) ||
+		errors.Is(err, pkg.ErrInvalidStatementPeriod) ||
+		errors.Is(err, pkg.ErrInvalidImportFile) ||
+		errors.Is(err, pkg.ErrImportNotResumable) ||
+		errors.Is(err, pkg.ErrInvalidMpesaStore) ||
+		errors.Is(err, pkg.ErrMpesaStoreExists) ||
+		errors.Is(err, pkg.ErrNoPreviousPurchase) ||
+		errors.Is(err, pkg.ErrInvalidSchedule) ||
+		errors.Is(err, pkg.ErrInvalidCommissionPlan) {
+		return ctx.Status(http.StatusUnprocessableEntity).JSON(SimpleValidationErrorResponse(err))
+	}
+
 	// TODO: Handle simple one line errors
 	if errors.Is(err, pkg.ErrInvalidMerchant) ||
 		errors.Is(err, pkg.ErrInvalidUser) ||